@@ -0,0 +1,67 @@
+package calibre
+
+import (
+	"context"
+	"runtime"
+	"sync"
+
+	"github.com/anilpdv/go-calibre/models"
+)
+
+// BatchResult is the outcome of extracting metadata from one file in a batch
+type BatchResult struct {
+	Path     string
+	Metadata *models.Metadata
+	Err      error
+}
+
+// GetMetadataBatch extracts metadata from many files using a pool of
+// concurrency workers (runtime.NumCPU() when zero or negative). Results are
+// returned in the same order as paths, and a failing file only sets the Err of
+// its own result. Each call writes its own temp OPF, so workers never collide.
+// The returned error is non-nil only if ctx ends before every file is done;
+// files that were never started then carry the context's error.
+func (c *Calibre) GetMetadataBatch(ctx context.Context, paths []string, concurrency int) ([]BatchResult, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if concurrency <= 0 {
+		concurrency = runtime.NumCPU()
+	}
+
+	results := make([]BatchResult, len(paths))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				meta, err := c.GetMetadataContext(ctx, paths[i])
+				results[i] = BatchResult{Path: paths[i], Metadata: meta, Err: err}
+			}
+		}()
+	}
+
+	next := 0
+feed:
+	for ; next < len(paths) && ctx.Err() == nil; next++ {
+		select {
+		case jobs <- next:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if next < len(paths) {
+		for i := next; i < len(paths); i++ {
+			results[i] = BatchResult{Path: paths[i], Err: ctx.Err()}
+		}
+		return results, ctx.Err()
+	}
+
+	return results, nil
+}
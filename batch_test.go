@@ -0,0 +1,87 @@
+package calibre
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+)
+
+func TestGetMetadataBatch(t *testing.T) {
+	var mu sync.Mutex
+	opfPaths := map[string]bool{}
+
+	c := &Calibre{
+		Timeout:   DefaultTimeout,
+		ebookMeta: "ebook-meta",
+		Runner: func(ctx context.Context, name string, args ...string) ([]byte, error) {
+			book, opfPath := args[0], args[2]
+			mu.Lock()
+			if opfPaths[opfPath] {
+				t.Errorf("temp OPF %s reused", opfPath)
+			}
+			opfPaths[opfPath] = true
+			mu.Unlock()
+
+			if book == "bad.epub" {
+				return []byte("corrupt file"), errors.New("exit status 1")
+			}
+			opfXML := testOPF(fmt.Sprintf("<dc:title>%s</dc:title>", book), "", "")
+			return nil, os.WriteFile(opfPath, []byte(opfXML), 0644)
+		},
+	}
+
+	var paths []string
+	for i := 0; i < 20; i++ {
+		paths = append(paths, fmt.Sprintf("book%d.epub", i))
+	}
+	paths[7] = "bad.epub"
+
+	results, err := c.GetMetadataBatch(context.Background(), paths, 4)
+	if err != nil {
+		t.Fatalf("GetMetadataBatch failed: %v", err)
+	}
+	if len(results) != len(paths) {
+		t.Fatalf("expected %d results, got %d", len(paths), len(results))
+	}
+
+	for i, r := range results {
+		if r.Path != paths[i] {
+			t.Errorf("result %d has path %q, want %q", i, r.Path, paths[i])
+		}
+		if i == 7 {
+			if r.Err == nil {
+				t.Error("expected an error for bad.epub")
+			}
+			continue
+		}
+		if r.Err != nil || r.Metadata == nil || r.Metadata.Title != paths[i] {
+			t.Errorf("result %d = %+v", i, r)
+		}
+	}
+}
+
+func TestGetMetadataBatchCanceled(t *testing.T) {
+	c := &Calibre{
+		Timeout:   DefaultTimeout,
+		ebookMeta: "ebook-meta",
+		Runner: func(ctx context.Context, name string, args ...string) ([]byte, error) {
+			return nil, errors.New("should not run")
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	results, err := c.GetMetadataBatch(ctx, []string{"a.epub", "b.epub"}, 0)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	for _, r := range results {
+		if r.Err == nil {
+			t.Errorf("%s: expected an error", r.Path)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package calibre
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/anilpdv/go-calibre/models"
+)
+
+var (
+	// gutenbergStart matches the line after which a Project Gutenberg text
+	// begins: "*** START OF THE PROJECT GUTENBERG EBOOK ... ***" and its older
+	// spellings, or the legacy "*END*THE SMALL PRINT!" license trailer
+	gutenbergStart = regexp.MustCompile(`(?im)^[ \t]*(?:\*{3}[ \t]*START OF (?:THE |THIS )?PROJECT GUTENBERG E-?(?:BOOK|TEXT)\b|\*END\*[ \t]*THE SMALL PRINT!).*$`)
+
+	// gutenbergEnd matches the line where a Project Gutenberg text ends, with
+	// or without the surrounding asterisks older files leave off
+	gutenbergEnd = regexp.MustCompile(`(?im)^[ \t]*(?:\*{3}[ \t]*)?END OF (?:THE |THIS )?PROJECT GUTENBERG E-?(?:BOOK|TEXT)\b.*$`)
+)
+
+// StripGutenbergBoilerplate removes the license header and footer Project
+// Gutenberg wraps its texts in: everything up to the end of the START marker
+// line and everything from the END marker on. Modern ("*** START OF THE
+// PROJECT GUTENBERG EBOOK ***") and older marker styles are recognized. Text
+// without the markers is returned unchanged.
+func StripGutenbergBoilerplate(text string) string {
+	stripped := false
+	if loc := gutenbergStart.FindStringIndex(text); loc != nil {
+		text = text[loc[1]:]
+		stripped = true
+	}
+	if loc := gutenbergEnd.FindStringIndex(text); loc != nil {
+		text = text[:loc[0]]
+		stripped = true
+	}
+
+	if stripped {
+		return strings.TrimSpace(text)
+	}
+	return text
+}
+
+// stripBoilerplateChapters wraps emit to drop the Gutenberg license text
+// around a book split into chapters: chapters before the one holding the
+// START marker, the text up to the marker in that one, and everything from
+// the END marker on. Chapters left empty are dropped and the rest renumbered.
+//
+// Until a START marker is seen chapters are held back, since the ones read
+// so far might be license text; flush emits them once the stream is done
+// without one, as for books that aren't from Project Gutenberg.
+func stripBoilerplateChapters(emit func(models.Chapter) error) (wrapped func(models.Chapter) error, flush func() error) {
+	var pending []models.Chapter
+	started, ended := false, false
+	kept := 0
+
+	keep := func(chapter models.Chapter, content string) error {
+		if strings.TrimSpace(content) == "" {
+			return nil
+		}
+		stripped := models.NewChapter(kept, chapter.Title, content)
+		stripped.HTMLContent = chapter.HTMLContent
+		stripped.Footnotes = chapter.Footnotes
+		kept++
+		return emit(stripped)
+	}
+
+	release := func() error {
+		for _, chapter := range pending {
+			if err := keep(chapter, chapter.Content); err != nil {
+				return err
+			}
+		}
+		pending = nil
+		return nil
+	}
+
+	wrapped = func(chapter models.Chapter) error {
+		if ended {
+			return nil
+		}
+
+		content := chapter.Content
+		if !started {
+			if loc := gutenbergStart.FindStringIndex(content); loc != nil {
+				started = true
+				pending = nil
+				content = strings.TrimSpace(content[loc[1]:])
+			}
+		}
+		if loc := gutenbergEnd.FindStringIndex(content); loc != nil {
+			ended = true
+			content = strings.TrimSpace(content[:loc[0]])
+		}
+
+		if !started {
+			if !ended {
+				pending = append(pending, chapter)
+				return nil
+			}
+			// An END marker without a START: nothing held back was license text
+			if err := release(); err != nil {
+				return err
+			}
+		}
+		return keep(chapter, content)
+	}
+
+	return wrapped, release
+}
@@ -0,0 +1,110 @@
+package calibre
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestStripGutenbergBoilerplate(t *testing.T) {
+	tests := []struct {
+		name, text, want string
+	}{
+		{
+			"modern",
+			"The Project Gutenberg eBook of Candide\n\nThis ebook is for the use of anyone.\n\n" +
+				"*** START OF THE PROJECT GUTENBERG EBOOK CANDIDE ***\n\nCHAPTER I\n\nIn a castle...\n\n" +
+				"*** END OF THE PROJECT GUTENBERG EBOOK CANDIDE ***\n\nUpdated editions will replace the previous one.",
+			"CHAPTER I\n\nIn a castle...",
+		},
+		{
+			"this ebook, no spaces",
+			"Header\n***START OF THIS PROJECT GUTENBERG EBOOK EMMA***\nVolume I\n***END OF THIS PROJECT GUTENBERG EBOOK EMMA***\nFooter",
+			"Volume I",
+		},
+		{
+			"legacy etext",
+			"**The Project Gutenberg Etext of Walden**\n\nSmall print...\n\n" +
+				"*END*THE SMALL PRINT! FOR PUBLIC DOMAIN ETEXTS*Ver.04.29.93*END*\n\nWALDEN\n\nEconomy\n\n" +
+				"End of The Project Gutenberg Etext of Walden\n\nMore license text.",
+			"WALDEN\n\nEconomy",
+		},
+		{
+			"no markers",
+			"  Just a book.\n\nAbout Project Gutenberg's founder.\n",
+			"  Just a book.\n\nAbout Project Gutenberg's founder.\n",
+		},
+	}
+	for _, tt := range tests {
+		if got := StripGutenbergBoilerplate(tt.text); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestExtractChaptersStripBoilerplate(t *testing.T) {
+	epub := writeTestEPUB(t, map[string]string{
+		"toc.ncx": testNCX(
+			[2]string{"Header", "header.xhtml"},
+			[2]string{"Chapter 1", "ch1.xhtml"},
+			[2]string{"Chapter 2", "ch2.xhtml"},
+			[2]string{"Chapter 3", "ch3.xhtml"},
+			[2]string{"Chapter 4", "ch4.xhtml"},
+			[2]string{"Footer", "footer.xhtml"},
+		),
+		"header.xhtml": testXHTML("<p>" + loremWords("License", 60) + "</p>"),
+		"ch1.xhtml": testXHTML("<p>" + loremWords("License", 40) + "</p>" +
+			"<p>*** START OF THE PROJECT GUTENBERG EBOOK TEST ***</p><p>" + loremWords("One", 60) + "</p>"),
+		"ch2.xhtml":    testXHTML("<p>" + loremWords("Two", 60) + "</p>"),
+		"ch3.xhtml":    testXHTML("<p>" + loremWords("Three", 60) + "</p>"),
+		"ch4.xhtml":    testXHTML("<p>*** END OF THE PROJECT GUTENBERG EBOOK TEST ***</p><p>" + loremWords("License", 60) + "</p>"),
+		"footer.xhtml": testXHTML("<p>" + loremWords("License", 60) + "</p>"),
+	})
+
+	c := &Calibre{Timeout: DefaultTimeout, ebookConvert: "ebook-convert"}
+	chapters, err := c.ExtractChaptersWithOptions(context.Background(), epub, ChapterOptions{StripBoilerplate: true})
+	if err != nil {
+		t.Fatalf("ExtractChaptersWithOptions failed: %v", err)
+	}
+
+	if len(chapters) != 3 {
+		t.Fatalf("expected the chapters outside the markers to be dropped, got %d chapters", len(chapters))
+	}
+	if !strings.HasPrefix(chapters[0].Content, "One") {
+		t.Errorf("chapter 1 should start after the START marker, got %q", chapters[0].Summary(30))
+	}
+	for i, ch := range chapters {
+		if ch.Index != i || strings.Contains(ch.Content, "License") {
+			t.Errorf("chapter %d: Index %d, content %q", i, ch.Index, ch.Summary(30))
+		}
+	}
+}
+
+func TestExtractChaptersStripBoilerplateNoMarkers(t *testing.T) {
+	epub := writeTestEPUB(t, map[string]string{
+		"toc.ncx": testNCX(
+			[2]string{"Chapter 1", "ch1.xhtml"},
+			[2]string{"Chapter 2", "ch2.xhtml"},
+			[2]string{"Chapter 3", "ch3.xhtml"},
+		),
+		"ch1.xhtml": testXHTML("<p>" + loremWords("One", 60) + "</p>"),
+		"ch2.xhtml": testXHTML("<p>" + loremWords("Two", 60) + "</p>"),
+		"ch3.xhtml": testXHTML("<p>" + loremWords("Three", 60) + "</p>"),
+	})
+
+	c := &Calibre{Timeout: DefaultTimeout, ebookConvert: "ebook-convert"}
+	chapters, err := c.ExtractChaptersWithOptions(context.Background(), epub, ChapterOptions{StripBoilerplate: true})
+	if err != nil {
+		t.Fatalf("ExtractChaptersWithOptions failed: %v", err)
+	}
+
+	// Held back waiting for a START marker, then released unchanged
+	if len(chapters) != 3 {
+		t.Fatalf("expected all 3 chapters, got %d", len(chapters))
+	}
+	for i, want := range []string{"One", "Two", "Three"} {
+		if chapters[i].Index != i || !strings.HasPrefix(chapters[i].Content, want) {
+			t.Errorf("chapter %d: Index %d, content %q", i, chapters[i].Index, chapters[i].Summary(30))
+		}
+	}
+}
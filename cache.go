@@ -0,0 +1,55 @@
+package calibre
+
+import "sync"
+
+// toolCache holds tool detection and version results for the life of the
+// process, so constructing a Calibre per request doesn't search PATH or
+// spawn ebook-meta every time
+var toolCache = struct {
+	sync.RWMutex
+	paths    map[string]map[string]string // BinPath -> tool name -> path
+	versions map[string]string            // ebook-meta path -> version
+}{
+	paths:    make(map[string]map[string]string),
+	versions: make(map[string]string),
+}
+
+// ResetCache clears the cached tool paths and versions, forcing the next
+// New or Version call to detect them again, e.g. after installing Calibre
+// or in tests
+func ResetCache() {
+	toolCache.Lock()
+	defer toolCache.Unlock()
+	toolCache.paths = make(map[string]map[string]string)
+	toolCache.versions = make(map[string]string)
+}
+
+// cachedToolPaths returns the tool paths detected earlier for binPath
+func cachedToolPaths(binPath string) (map[string]string, bool) {
+	toolCache.RLock()
+	defer toolCache.RUnlock()
+	paths, ok := toolCache.paths[binPath]
+	return paths, ok
+}
+
+// cacheToolPaths records the tool paths detected for binPath
+func cacheToolPaths(binPath string, paths map[string]string) {
+	toolCache.Lock()
+	defer toolCache.Unlock()
+	toolCache.paths[binPath] = paths
+}
+
+// cachedVersion returns the version reported earlier by an ebook-meta binary
+func cachedVersion(ebookMeta string) (string, bool) {
+	toolCache.RLock()
+	defer toolCache.RUnlock()
+	version, ok := toolCache.versions[ebookMeta]
+	return version, ok
+}
+
+// cacheVersion records the version reported by an ebook-meta binary
+func cacheVersion(ebookMeta, version string) {
+	toolCache.Lock()
+	defer toolCache.Unlock()
+	toolCache.versions[ebookMeta] = version
+}
@@ -0,0 +1,80 @@
+package calibre
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeFakeEbookMeta writes an ebook-meta script reporting version into dir
+func writeFakeEbookMeta(t *testing.T, dir, version string) {
+	t.Helper()
+	script := "#!/bin/sh\necho 'ebook-meta (calibre " + version + ")'\n"
+	if err := os.WriteFile(filepath.Join(dir, "ebook-meta"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestToolPathCache(t *testing.T) {
+	ResetCache()
+	t.Cleanup(ResetCache)
+	t.Setenv("PATH", "")
+
+	binDir := t.TempDir()
+	writeFakeEbookMeta(t, binDir, "7.1.0")
+	if _, err := NewWithOptions(Options{BinPath: binDir}); err != nil {
+		t.Fatalf("NewWithOptions failed: %v", err)
+	}
+
+	// Detection is cached, so removing the tool goes unnoticed
+	os.Remove(filepath.Join(binDir, "ebook-meta"))
+	c, err := NewWithOptions(Options{BinPath: binDir})
+	if err != nil {
+		t.Fatalf("cached NewWithOptions failed: %v", err)
+	}
+	if c.ebookMeta != filepath.Join(binDir, "ebook-meta") {
+		t.Errorf("ebook-meta = %q, want the cached path", c.ebookMeta)
+	}
+
+	// A different BinPath is detected separately
+	other, err := NewWithOptions(Options{BinPath: t.TempDir()})
+	if err != nil {
+		t.Fatalf("other BinPath: %v", err)
+	}
+	if other.ebookMeta != "" {
+		t.Errorf("other BinPath: ebook-meta = %q, want none", other.ebookMeta)
+	}
+
+	ResetCache()
+	c, err = NewWithOptions(Options{BinPath: binDir})
+	if err != nil {
+		t.Fatalf("NewWithOptions after ResetCache: %v", err)
+	}
+	if _, err := c.Version(); !errors.Is(err, ErrToolNotFound) {
+		t.Errorf("after ResetCache: expected ErrToolNotFound, got %v", err)
+	}
+}
+
+func TestVersionCache(t *testing.T) {
+	ResetCache()
+	t.Cleanup(ResetCache)
+
+	binDir := t.TempDir()
+	writeFakeEbookMeta(t, binDir, "7.1.0")
+	c := &Calibre{ebookMeta: filepath.Join(binDir, "ebook-meta")}
+
+	if v, err := c.Version(); err != nil || v != "7.1.0" {
+		t.Fatalf("Version = %q, %v", v, err)
+	}
+
+	writeFakeEbookMeta(t, binDir, "8.0.0")
+	if v, _ := c.Version(); v != "7.1.0" {
+		t.Errorf("Version = %q, want the cached 7.1.0", v)
+	}
+
+	ResetCache()
+	if v, _ := c.Version(); v != "8.0.0" {
+		t.Errorf("Version after ResetCache = %q, want 8.0.0", v)
+	}
+}
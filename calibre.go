@@ -2,30 +2,68 @@
 // for professional-grade ebook parsing, metadata extraction, chapter detection,
 // and format conversion.
 //
-// This library requires Calibre to be installed on the system.
-// Install via: brew install calibre (macOS) or apt install calibre (Linux)
+// Most operations run Calibre's tools, so Calibre should be installed on the
+// system. Install via: brew install calibre (macOS) or apt install calibre
+// (Linux). Without it, New still succeeds: EPUB metadata and covers are read
+// natively, and operations that need a missing tool return ErrToolNotFound.
 package calibre
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"os/exec"
+	"path/filepath"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 )
 
 // DefaultTimeout is the default timeout for Calibre commands
 const DefaultTimeout = 5 * time.Minute
 
+// CommandRunner executes a command and returns its combined output
+type CommandRunner func(ctx context.Context, name string, args ...string) ([]byte, error)
+
 // Calibre holds configuration for the Calibre wrapper
 type Calibre struct {
 	// Path to Calibre binaries (auto-detected if empty)
 	BinPath string
 
-	// Timeout for commands (defaults to 5 minutes)
+	// Timeout for commands (defaults to 5 minutes). WithTimeout overrides
+	// it for a single call.
 	Timeout time.Duration
 
+	// Runner executes commands (defaults to running the process directly).
+	// Replace it to stub out the Calibre tools, e.g. in tests.
+	Runner CommandRunner
+
+	// DryRun makes every Calibre command fail with a *DryRunError holding
+	// its command line instead of running, to see what an operation would
+	// do. Operations that don't need a command, such as reading an EPUB's
+	// metadata natively, still run as usual.
+	DryRun bool
+
+	// Metrics receives extraction phase timings (optional)
+	Metrics MetricsCollector
+
+	// DetectMissingLanguage makes GetMetadataContext guess the language from
+	// the text with DetectLanguage when the metadata has none
+	DetectMissingLanguage bool
+
+	// TempDir is where temporary files are created (defaults to the OS temp
+	// dir). It must exist and be writable; operations fail up front if it
+	// isn't.
+	TempDir string
+
+	// scratch is the directory under TempDir holding the temporary files of
+	// this instance's BookHandles, created on first use and removed by Close
+	scratchMu sync.Mutex
+	scratch   string
+
 	// Paths to individual tools (auto-detected)
 	ebookMeta    string
 	ebookConvert string
@@ -34,10 +72,40 @@ type Calibre struct {
 	calibredb    string
 }
 
+// Options configures a Calibre instance created with NewWithOptions
+type Options struct {
+	// BinPath is a directory searched for the Calibre tools before PATH,
+	// e.g. /Applications/calibre.app/Contents/MacOS
+	BinPath string
+
+	// Timeout for commands (defaults to DefaultTimeout)
+	Timeout time.Duration
+
+	// TempDir is where temporary files are created (defaults to the OS
+	// temp dir); see Calibre.TempDir
+	TempDir string
+}
+
 // New creates a new Calibre instance with auto-detected paths
 func New() (*Calibre, error) {
+	return NewWithOptions(Options{})
+}
+
+// NewWithOptions creates a new Calibre instance, looking for tools in
+// opts.BinPath before PATH
+func NewWithOptions(opts Options) (*Calibre, error) {
 	c := &Calibre{
+		BinPath: opts.BinPath,
 		Timeout: DefaultTimeout,
+		TempDir: opts.TempDir,
+	}
+	if opts.Timeout > 0 {
+		c.Timeout = opts.Timeout
+	}
+	if opts.TempDir != "" {
+		if err := checkTempDir(opts.TempDir); err != nil {
+			return nil, err
+		}
 	}
 
 	if err := c.detectTools(); err != nil {
@@ -47,33 +115,52 @@ func New() (*Calibre, error) {
 	return c, nil
 }
 
-// detectTools finds the paths to Calibre command-line tools
+// detectTools finds the paths to Calibre command-line tools, checking
+// BinPath first and then PATH. Results are cached per BinPath for the life
+// of the process; see ResetCache.
 func (c *Calibre) detectTools() error {
-	tools := map[string]*string{
-		"ebook-meta":           &c.ebookMeta,
-		"ebook-convert":        &c.ebookConvert,
-		"fetch-ebook-metadata": &c.fetchMeta,
-		"ebook-polish":         &c.ebookPolish,
-		"calibredb":            &c.calibredb,
-	}
-
-	for name, path := range tools {
-		p, err := exec.LookPath(name)
-		if err != nil {
-			// ebook-meta is required, others are optional
-			if name == "ebook-meta" {
-				return fmt.Errorf("calibre not found: %s not in PATH. Install with: brew install calibre", name)
+	found, ok := cachedToolPaths(c.BinPath)
+	if !ok {
+		found = make(map[string]string)
+		for name := range c.toolPaths() {
+			if p, err := c.findTool(name); err == nil {
+				found[name] = p
 			}
-			continue
 		}
-		*path = p
+	}
+
+	// Every tool is optional; methods check for the ones they need
+	if !ok {
+		cacheToolPaths(c.BinPath, found)
+	}
+
+	for name, path := range c.toolPaths() {
+		*path = found[name]
 	}
 
 	return nil
 }
 
-// Version returns the installed Calibre version
+// findTool looks for an executable in BinPath, then in PATH
+func (c *Calibre) findTool(name string) (string, error) {
+	if c.BinPath != "" {
+		if p, err := exec.LookPath(filepath.Join(c.BinPath, name)); err == nil {
+			return p, nil
+		}
+	}
+	return exec.LookPath(name)
+}
+
+// Version returns the installed Calibre version. The result is cached per
+// ebook-meta binary for the life of the process.
 func (c *Calibre) Version() (string, error) {
+	if c.ebookMeta == "" {
+		return "", toolNotFound("ebook-meta")
+	}
+	if version, ok := cachedVersion(c.ebookMeta); ok {
+		return version, nil
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
@@ -90,6 +177,7 @@ func (c *Calibre) Version() (string, error) {
 		return "", fmt.Errorf("could not parse version from: %s", output)
 	}
 
+	cacheVersion(c.ebookMeta, matches[1])
 	return matches[1], nil
 }
 
@@ -110,22 +198,149 @@ func SupportedFormats() []string {
 	}
 }
 
-// runCommand executes a Calibre command with timeout
+// runCommand executes a Calibre command with timeout. c.Timeout is applied on
+// top of the caller's context, so whichever deadline comes first wins.
 func (c *Calibre) runCommand(ctx context.Context, name string, args ...string) ([]byte, error) {
-	if ctx == nil {
-		var cancel context.CancelFunc
-		ctx, cancel = context.WithTimeout(context.Background(), c.Timeout)
-		defer cancel()
+	if c.DryRun {
+		return nil, dryRunError(name, args)
 	}
 
-	cmd := exec.CommandContext(ctx, name, args...)
-	output, err := cmd.CombinedOutput()
+	parent, ctx, cancel, timeout := c.commandContext(ctx)
+	defer cancel()
+
+	run := c.Runner
+	if run == nil {
+		run = execCommand
+	}
+
+	start := time.Now()
+	output, err := run(ctx, name, args...)
 	if err != nil {
-		if ctx.Err() == context.DeadlineExceeded {
-			return nil, fmt.Errorf("command timed out after %v", c.Timeout)
-		}
-		return nil, fmt.Errorf("command failed: %w\nOutput: %s", err, strings.TrimSpace(string(output)))
+		return nil, commandError(parent, ctx, timeout, start, err, output)
 	}
 
 	return output, nil
 }
+
+// runCommandLines runs a command like runCommand, passing each line of its
+// standard output to onLine as it is printed. onLine is always called from
+// the calling goroutine. On failure the error includes standard error.
+// With a custom Runner the output is only available once the command ends,
+// so its lines are replayed afterwards.
+func (c *Calibre) runCommandLines(ctx context.Context, onLine func(line string), name string, args ...string) ([]byte, error) {
+	if c.Runner != nil || c.DryRun {
+		output, err := c.runCommand(ctx, name, args...)
+		if err != nil {
+			return nil, err
+		}
+		scanner := bufio.NewScanner(bytes.NewReader(output))
+		for scanner.Scan() {
+			onLine(scanner.Text())
+		}
+		return output, nil
+	}
+
+	parent, ctx, cancel, timeout := c.commandContext(ctx)
+	defer cancel()
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open stdout: %w", err)
+	}
+
+	start := time.Now()
+	if err := cmd.Start(); err != nil {
+		return nil, commandError(parent, ctx, timeout, start, err, nil)
+	}
+
+	var output bytes.Buffer
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		output.WriteString(scanner.Text() + "\n")
+		onLine(scanner.Text())
+	}
+	// Drain anything the scanner gave up on so the process can exit
+	io.Copy(io.Discard, stdout)
+
+	if err := cmd.Wait(); err != nil {
+		return nil, commandError(parent, ctx, timeout, start, err, stderr.Bytes())
+	}
+
+	return output.Bytes(), nil
+}
+
+// timeoutKey is the context key WithTimeout stores its timeout under
+type timeoutKey struct{}
+
+// WithTimeout returns a context that gives each Calibre command run with it
+// the given timeout instead of c.Timeout, so a quick metadata read and a long
+// PDF conversion can be bounded differently:
+//
+//	ctx := calibre.WithTimeout(ctx, 20*time.Minute)
+//	err := c.Convert(ctx, "book.pdf", "book.epub", calibre.ConvertOptions{})
+//
+// The timeout applies to each command an operation runs, not the operation
+// as a whole, and a sooner deadline on ctx still wins. A zero or negative
+// timeout means c.Timeout.
+func WithTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, timeoutKey{}, timeout)
+}
+
+// commandContext returns the caller's context (Background if nil) and a
+// child context bounded by the WithTimeout timeout or else c.Timeout, along
+// with the timeout applied
+func (c *Calibre) commandContext(ctx context.Context) (context.Context, context.Context, context.CancelFunc, time.Duration) {
+	parent := ctx
+	if parent == nil {
+		parent = context.Background()
+	}
+
+	timeout, _ := parent.Value(timeoutKey{}).(time.Duration)
+	if timeout <= 0 {
+		timeout = c.Timeout
+	}
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(parent, timeout)
+	return parent, ctx, cancel, timeout
+}
+
+// commandError describes a failed command, telling apart the caller's
+// deadline, cancellation, c.Timeout firing, and the tool itself failing
+func commandError(parent, ctx context.Context, timeout time.Duration, start time.Time, err error, output []byte) error {
+	switch {
+	case parent.Err() == context.DeadlineExceeded:
+		return fmt.Errorf("%w after %v: caller's %w", ErrTimeout, time.Since(start).Round(time.Millisecond), context.DeadlineExceeded)
+	case parent.Err() == context.Canceled:
+		return fmt.Errorf("command canceled: %w", context.Canceled)
+	case ctx.Err() == context.DeadlineExceeded:
+		return fmt.Errorf("%w after %v: %w", ErrTimeout, timeout, context.DeadlineExceeded)
+	}
+	return fmt.Errorf("command failed: %w\nOutput: %s", err, strings.TrimSpace(string(output)))
+}
+
+// dryRunError returns the DryRunError for a command, resolving the tool to
+// an absolute path so the command line can be run from anywhere
+func dryRunError(name string, args []string) error {
+	if p, err := exec.LookPath(name); err == nil {
+		name = p
+	}
+	if abs, err := filepath.Abs(name); err == nil && strings.ContainsRune(name, filepath.Separator) {
+		name = abs
+	}
+	return &DryRunError{Argv: append([]string{name}, args...)}
+}
+
+// execCommand is the default CommandRunner
+func execCommand(ctx context.Context, name string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	return cmd.CombinedOutput()
+}
@@ -1,19 +1,88 @@
 package calibre
 
 import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"sort"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/anilpdv/go-calibre/models"
 )
 
 func TestNew(t *testing.T) {
 	c, err := New()
 	if err != nil {
-		t.Skipf("Calibre not installed: %v", err)
+		t.Fatalf("New failed: %v", err)
 	}
 
-	if c.ebookMeta == "" {
-		t.Error("ebook-meta path should be set")
+	// Calibre's tools are optional, but whichever were found are usable
+	for name, path := range c.toolPaths() {
+		if *path == "" {
+			continue
+		}
+		if _, err := os.Stat(*path); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+}
+
+func TestNewWithOptionsBinPath(t *testing.T) {
+	binDir := t.TempDir()
+	for _, name := range []string{"ebook-meta", "ebook-convert"} {
+		if err := os.WriteFile(filepath.Join(binDir, name), []byte("#!/bin/sh\n"), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c, err := NewWithOptions(Options{BinPath: binDir, Timeout: time.Minute})
+	if err != nil {
+		t.Fatalf("NewWithOptions failed: %v", err)
+	}
+	if c.ebookMeta != filepath.Join(binDir, "ebook-meta") {
+		t.Errorf("ebook-meta = %q, want it from BinPath", c.ebookMeta)
+	}
+	if c.ebookConvert != filepath.Join(binDir, "ebook-convert") {
+		t.Errorf("ebook-convert = %q, want it from BinPath", c.ebookConvert)
+	}
+	if c.Timeout != time.Minute {
+		t.Errorf("Timeout = %v, want 1m", c.Timeout)
+	}
+}
+
+func TestNewWithOptionsMissingTool(t *testing.T) {
+	t.Setenv("PATH", "")
+
+	c, err := NewWithOptions(Options{BinPath: t.TempDir()})
+	if err != nil {
+		t.Fatalf("NewWithOptions without Calibre: %v", err)
+	}
+
+	// EPUB metadata is read natively
+	path := writeTestEPUB(t, map[string]string{
+		"META-INF/container.xml": testContainer("content.opf"),
+		"content.opf":            testOPF(`<dc:title>Native Book</dc:title>`, "", ""),
+	})
+	meta, err := c.GetMetadata(path)
+	if err != nil {
+		t.Fatalf("GetMetadata without ebook-meta: %v", err)
+	}
+	if meta.Title != "Native Book" {
+		t.Errorf("Title = %q, want %q", meta.Title, "Native Book")
+	}
+
+	// Anything else needs the tools
+	if _, err := c.GetMetadata(filepath.Join(t.TempDir(), "book.mobi")); !errors.Is(err, ErrToolNotFound) {
+		t.Errorf("GetMetadata(mobi): expected ErrToolNotFound, got %v", err)
+	}
+	if _, err := c.Version(); !errors.Is(err, ErrToolNotFound) {
+		t.Errorf("Version: expected ErrToolNotFound, got %v", err)
 	}
 }
 
@@ -23,6 +92,10 @@ func TestVersion(t *testing.T) {
 		t.Skipf("Calibre not installed: %v", err)
 	}
 
+	if c.ebookMeta == "" {
+		t.Skip("ebook-meta not found")
+	}
+
 	version, err := c.Version()
 	if err != nil {
 		t.Fatalf("Failed to get version: %v", err)
@@ -41,6 +114,10 @@ func TestIsInstalled(t *testing.T) {
 		t.Skipf("Calibre not installed: %v", err)
 	}
 
+	if c.ebookMeta == "" {
+		t.Skip("ebook-meta not found")
+	}
+
 	if !c.IsInstalled() {
 		t.Error("IsInstalled should return true")
 	}
@@ -79,6 +156,10 @@ func TestGetMetadata(t *testing.T) {
 		t.Skipf("Calibre not installed: %v", err)
 	}
 
+	if c.ebookMeta == "" {
+		t.Skip("ebook-meta not found")
+	}
+
 	// Look for test files
 	testFiles := []string{
 		"/Users/anilpdv/Desktop/pg19942-images-3.epub",
@@ -141,3 +222,220 @@ func TestExtractChapters(t *testing.T) {
 		t.Logf("Chapter %d: %s (%d words)", i+1, ch.Title, ch.WordCount)
 	}
 }
+
+// writeTestEPUB builds a minimal EPUB in a temp directory from a map of
+// zip entry names to contents and returns its path. A mimetype entry is
+// added first unless files has one, which is then written like any other.
+func writeTestEPUB(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "test.epub")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	zw := zip.NewWriter(f)
+
+	// mimetype must be the first entry and stored uncompressed
+	if _, ok := files["mimetype"]; !ok {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte("application/epub+zip"))
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(files[name])); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+// testNCX builds an NCX document with one navPoint per title/src pair
+func testNCX(points ...[2]string) string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<ncx xmlns="http://www.daisy.org/z3986/2005/ncx/" version="2005-1">
+<docTitle><text>Test Book</text></docTitle>
+<navMap>
+`)
+	for i, p := range points {
+		fmt.Fprintf(&b, `<navPoint id="np%d" playOrder="%d"><navLabel><text>%s</text></navLabel><content src="%s"/></navPoint>
+`, i+1, i+1, p[0], p[1])
+	}
+	b.WriteString("</navMap>\n</ncx>\n")
+	return b.String()
+}
+
+// testXHTML wraps body markup in a minimal XHTML document
+func testXHTML(body string) string {
+	return `<?xml version="1.0" encoding="UTF-8"?>
+<html xmlns="http://www.w3.org/1999/xhtml"><head></head><body>` + body + `</body></html>`
+}
+
+// loremWords returns n words of filler text starting with the given word
+func loremWords(first string, n int) string {
+	words := []string{first}
+	for len(words) < n {
+		words = append(words, "lorem")
+	}
+	return strings.Join(words, " ")
+}
+
+// testContainer returns a container.xml pointing at the given OPF path
+func testContainer(opfPath string) string {
+	return `<?xml version="1.0"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+<rootfiles><rootfile full-path="` + opfPath + `" media-type="application/oebps-package+xml"/></rootfiles>
+</container>`
+}
+
+// testOPF returns a package document with the given metadata, manifest, and
+// spine markup
+func testOPF(metadata, manifest, spine string) string {
+	return `<?xml version="1.0" encoding="UTF-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="2.0" unique-identifier="uid">
+<metadata xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:opf="http://www.idpf.org/2007/opf">
+` + metadata + `
+</metadata>
+<manifest>
+` + manifest + `
+</manifest>
+<spine toc="ncx">
+` + spine + `
+</spine>
+</package>`
+}
+
+func TestRunCommandCallerDeadline(t *testing.T) {
+	if _, err := exec.LookPath("sleep"); err != nil {
+		t.Skip("sleep not available")
+	}
+
+	c := &Calibre{Timeout: DefaultTimeout}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := c.runCommand(ctx, "sleep", "5")
+	if err == nil {
+		t.Fatal("expected the command to be killed")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("command was not killed promptly (%v)", elapsed)
+	}
+	if !strings.Contains(err.Error(), "context deadline") {
+		t.Errorf("error should mention the context deadline, got %v", err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("error should wrap context.DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestRunCommandInstanceTimeout(t *testing.T) {
+	if _, err := exec.LookPath("sleep"); err != nil {
+		t.Skip("sleep not available")
+	}
+
+	// A context without a deadline still gets c.Timeout
+	c := &Calibre{Timeout: 50 * time.Millisecond}
+	_, err := c.runCommand(context.Background(), "sleep", "5")
+	if err == nil || !strings.Contains(err.Error(), "timed out after 50ms") {
+		t.Errorf("expected instance timeout error, got %v", err)
+	}
+}
+
+func TestRunCommandPerCallTimeout(t *testing.T) {
+	if _, err := exec.LookPath("sleep"); err != nil {
+		t.Skip("sleep not available")
+	}
+
+	// A per-call timeout fires well before the global one
+	c := &Calibre{Timeout: time.Hour}
+	start := time.Now()
+	_, err := c.runCommand(WithTimeout(context.Background(), 50*time.Millisecond), "sleep", "5")
+	if err == nil || !strings.Contains(err.Error(), "timed out after 50ms") {
+		t.Errorf("expected per-call timeout error, got %v", err)
+	}
+	if !errors.Is(err, ErrTimeout) {
+		t.Errorf("error should wrap ErrTimeout, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("command was not killed promptly (%v)", elapsed)
+	}
+
+	// A zero per-call timeout falls back to c.Timeout
+	c.Timeout = 50 * time.Millisecond
+	_, err = c.runCommand(WithTimeout(context.Background(), 0), "sleep", "5")
+	if err == nil || !strings.Contains(err.Error(), "timed out after 50ms") {
+		t.Errorf("expected instance timeout error, got %v", err)
+	}
+
+	// A per-call timeout can also be longer than the global one
+	c.Timeout = time.Nanosecond
+	if _, err := c.runCommand(WithTimeout(context.Background(), time.Minute), "sleep", "0"); err != nil {
+		t.Errorf("expected the longer per-call timeout to apply, got %v", err)
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	c := &Calibre{
+		Timeout:      DefaultTimeout,
+		DryRun:       true,
+		ebookMeta:    "/opt/calibre/ebook-meta",
+		ebookConvert: "/opt/calibre/ebook-convert",
+		Runner: func(ctx context.Context, name string, args ...string) ([]byte, error) {
+			t.Fatalf("dry run executed %s %q", name, args)
+			return nil, nil
+		},
+	}
+	ctx := context.Background()
+	out := filepath.Join(t.TempDir(), "book.mobi")
+
+	err := c.Convert(ctx, "book.epub", out, ConvertOptions{OutputProfile: "kindle"})
+	argv, ok := DryRunCommand(err)
+	if !ok || !errors.Is(err, ErrDryRun) {
+		t.Fatalf("Convert: expected a dry run error, got %v", err)
+	}
+	want := []string{"/opt/calibre/ebook-convert", "book.epub", out, "--output-profile", "kindle"}
+	if strings.Join(argv, "\x00") != strings.Join(want, "\x00") {
+		t.Errorf("Convert argv = %q, want %q", argv, want)
+	}
+
+	err = c.SetMetadata(ctx, "my book.epub", &models.Metadata{Title: "It's Here"})
+	if argv, _ := DryRunCommand(err); len(argv) != 4 || argv[0] != "/opt/calibre/ebook-meta" || argv[3] != "It's Here" {
+		t.Errorf("SetMetadata argv = %q", argv)
+	}
+	var dryRun *DryRunError
+	if !errors.As(err, &dryRun) {
+		t.Fatalf("SetMetadata: expected a DryRunError, got %v", err)
+	}
+	if want := `/opt/calibre/ebook-meta 'my book.epub' --title 'It'\''s Here'`; dryRun.Command() != want {
+		t.Errorf("Command() = %q, want %q", dryRun.Command(), want)
+	}
+
+	// Chapter extraction stops at the first command instead of trying fallbacks
+	_, err = c.ExtractChaptersContext(ctx, filepath.Join(t.TempDir(), "book.mobi"))
+	if argv, ok := DryRunCommand(err); !ok || argv[0] != "/opt/calibre/ebook-convert" || filepath.Ext(argv[2]) != ".epub" {
+		t.Errorf("ExtractChapters: expected the EPUB conversion command, got %v", err)
+	}
+}
@@ -2,14 +2,19 @@ package calibre
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/anilpdv/go-calibre/models"
 	"github.com/anilpdv/go-calibre/ncx"
+	"github.com/anilpdv/go-calibre/opf"
 )
 
 // ChapterOptions configures chapter extraction
@@ -23,8 +28,149 @@ type ChapterOptions struct {
 
 	// KeepHTML preserves HTML content in addition to plain text
 	KeepHTML bool
+
+	// SplitStrategies sets which text-splitting strategies the plain-text
+	// fallback tries, and in what order. Defaults to DefaultSplitStrategies.
+	SplitStrategies []SplitStrategy
+
+	// BlankLineGap is the number of consecutive blank lines treated as a
+	// chapter break by SplitBlankLines. Defaults to DefaultBlankLineGap.
+	BlankLineGap int
+
+	// SplitRegex is a chapter heading pattern tried before SplitStrategies
+	// by the plain-text fallback, e.g. `^Lesson \d+`. It is compiled in
+	// multiline mode and the text is split at the start of each match.
+	SplitRegex string
+
+	// Filter controls which table of contents entries become chapters
+	Filter ChapterFilter
+
+	// Deduplicate drops chapters whose text repeats an earlier chapter's,
+	// e.g. when the NCX lists one content file under several anchors
+	Deduplicate bool
+
+	// StripBoilerplate removes Project Gutenberg license headers and footers:
+	// chapters before the START marker and after the END marker are dropped,
+	// along with the marker chapters' license text. Without a START marker,
+	// chapters only arrive once the whole book has been read.
+	StripBoilerplate bool
+
+	// HeadingHeuristic adds a fallback, tried before plain text splitting,
+	// that splits an EPUB's content at styled paragraphs that look like
+	// chapter headings (see ncx.FindChapterStarts), for books whose
+	// headings are neither in the TOC nor marked up as <h1>-<h3>
+	HeadingHeuristic bool
+
+	// DebugKeepIntermediate keeps the files Calibre produced during
+	// extraction, the converted book.epub and book.txt, instead of deleting
+	// them, to troubleshoot bad chapter detection. ExtractChaptersDebug
+	// reports where they are.
+	DebugKeepIntermediate bool
+
+	// IntermediateDir is where DebugKeepIntermediate keeps the files. It is
+	// created if needed and existing files are overwritten. If empty, a new
+	// directory is made under TempDir.
+	IntermediateDir string
+
+	// skipped receives the entries of the chapter source in use that failed
+	// to extract, for ExtractChaptersPartial
+	skipped func(ChapterError)
+
+	// debug receives the intermediate files once extraction ends, for
+	// ExtractChaptersDebug
+	debug func(*DebugInfo)
+}
+
+// DebugInfo locates the intermediate files kept by
+// ChapterOptions.DebugKeepIntermediate. Paths are empty for files that
+// weren't produced, e.g. ConvertedEPUB when the book's own TOC was used.
+type DebugInfo struct {
+	// Dir is the directory holding the files
+	Dir string
+
+	// ConvertedEPUB is the EPUB ebook-convert generated with its chapter
+	// detection, whose NCX the chapters were read from
+	ConvertedEPUB string
+
+	// TextFile is the plain text the text-splitting fallback split
+	TextFile string
+}
+
+// reportSkipped passes a failed entry to the skipped callback, if any
+func (o ChapterOptions) reportSkipped(e ChapterError) {
+	if o.skipped != nil {
+		o.skipped(e)
+	}
+}
+
+// ChapterFilter controls which table of contents entries are kept as
+// chapters. The zero value keeps the default behavior.
+type ChapterFilter struct {
+	// SkipPatterns are case-insensitive title substrings of entries to drop.
+	// Nil uses DefaultSkipPatterns; an empty slice skips nothing.
+	SkipPatterns []string
+
+	// MinWords is the minimum word count of a chapter's content.
+	// Defaults to DefaultMinWords when zero or negative.
+	MinWords int
+
+	// IncludeFrontMatter keeps entries that don't look like chapters
+	// (e.g. "Preface"), instead of only chapter-like titles
+	IncludeFrontMatter bool
+}
+
+// DefaultSkipPatterns are the front and back matter titles dropped when
+// ChapterFilter.SkipPatterns is nil
+var DefaultSkipPatterns = []string{
+	"transcriber", "note", "copyright", "dedication", "epigraph",
+	"acknowledgment", "about the author", "about the book",
+	"the full project gutenberg", "project gutenberg", "license",
+	"the modern library", "footnotes", "endnotes", "index",
+	"bibliography", "contents", "table of contents",
+}
+
+// DefaultMinWords is the default minimum word count of a chapter
+const DefaultMinWords = 50
+
+// skipPatterns returns the filter's skip patterns or the defaults
+func (f ChapterFilter) skipPatterns() []string {
+	if f.SkipPatterns == nil {
+		return DefaultSkipPatterns
+	}
+	return f.SkipPatterns
+}
+
+// minWords returns the filter's minimum word count or the default
+func (f ChapterFilter) minWords() int {
+	if f.MinWords <= 0 {
+		return DefaultMinWords
+	}
+	return f.MinWords
 }
 
+// SplitStrategy identifies a way of splitting plain text into chapters
+type SplitStrategy string
+
+const (
+	// SplitFormFeed splits on form feed characters (Calibre's page breaks)
+	SplitFormFeed SplitStrategy = "formfeed"
+
+	// SplitStarSeparator splits on "* * *" separator lines
+	SplitStarSeparator SplitStrategy = "stars"
+
+	// SplitPatterns splits on chapter heading patterns like "Chapter 1"
+	SplitPatterns SplitStrategy = "patterns"
+
+	// SplitBlankLines splits on runs of blank lines with no other marker
+	SplitBlankLines SplitStrategy = "blanklines"
+)
+
+// DefaultSplitStrategies is the order used when ChapterOptions.SplitStrategies is empty
+var DefaultSplitStrategies = []SplitStrategy{SplitFormFeed, SplitStarSeparator, SplitPatterns, SplitBlankLines}
+
+// DefaultBlankLineGap is the default number of blank lines that separate chapters
+const DefaultBlankLineGap = 4
+
 // ExtractChapters extracts chapters from an ebook using Calibre's chapter detection
 func (c *Calibre) ExtractChapters(ebookPath string) ([]models.Chapter, error) {
 	return c.ExtractChaptersWithOptions(context.Background(), ebookPath, ChapterOptions{})
@@ -37,64 +183,443 @@ func (c *Calibre) ExtractChaptersContext(ctx context.Context, ebookPath string)
 
 // ExtractChaptersWithOptions extracts chapters with custom options
 func (c *Calibre) ExtractChaptersWithOptions(ctx context.Context, ebookPath string, opts ChapterOptions) ([]models.Chapter, error) {
-	if c.ebookConvert == "" {
-		return nil, fmt.Errorf("ebook-convert not found")
+	stream, errc := c.StreamChapters(ctx, ebookPath, opts)
+
+	var chapters []models.Chapter
+	for chapter := range stream {
+		chapters = append(chapters, chapter)
+	}
+	if err := <-errc; err != nil {
+		return nil, err
+	}
+
+	return chapters, nil
+}
+
+// ExtractChaptersPartial extracts chapters like ExtractChaptersWithOptions,
+// also returning the table of contents entries whose content couldn't be
+// read, such as an href pointing at a missing file. Those entries are left
+// out of the chapters, as they are by the other Extract methods, which don't
+// report them. The error is only set when no chapters could be extracted.
+func (c *Calibre) ExtractChaptersPartial(ctx context.Context, ebookPath string, opts ChapterOptions) ([]models.Chapter, []ChapterError, error) {
+	var skipped []ChapterError
+	opts.skipped = func(e ChapterError) {
+		skipped = append(skipped, e)
+	}
+
+	chapters, err := collectChapters(func(emit func(models.Chapter) error) error {
+		return c.streamChapters(ctx, ebookPath, opts, emit)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return chapters, skipped, nil
+}
+
+// ExtractChaptersDebug extracts chapters like ExtractChaptersWithOptions and
+// reports the intermediate files kept when opts.DebugKeepIntermediate is
+// set, even if extraction fails. Without it the DebugInfo is empty, as the
+// files are deleted.
+func (c *Calibre) ExtractChaptersDebug(ctx context.Context, ebookPath string, opts ChapterOptions) ([]models.Chapter, *DebugInfo, error) {
+	info := &DebugInfo{}
+	opts.debug = func(i *DebugInfo) {
+		info = i
+	}
+
+	chapters, err := collectChapters(func(emit func(models.Chapter) error) error {
+		return c.streamChapters(ctx, ebookPath, opts, emit)
+	})
+	if err != nil {
+		return nil, info, err
+	}
+
+	return chapters, info, nil
+}
+
+// chapterWorkDir returns the directory chapter extraction writes Calibre's
+// output to: a temp dir, or with DebugKeepIntermediate one that outlives it
+func (c *Calibre) chapterWorkDir(opts ChapterOptions) (string, error) {
+	if !opts.DebugKeepIntermediate {
+		return c.mkdirTemp("calibre-chapters-*")
+	}
+
+	if opts.IntermediateDir != "" {
+		if err := os.MkdirAll(opts.IntermediateDir, 0755); err != nil {
+			return "", fmt.Errorf("failed to create intermediate dir: %w", err)
+		}
+		// Files from an earlier run would be reported as this run's
+		for _, name := range []string{"book.epub", "book.txt"} {
+			os.Remove(filepath.Join(opts.IntermediateDir, name))
+		}
+		return opts.IntermediateDir, nil
+	}
+
+	base, err := c.tempBase()
+	if err != nil {
+		return "", err
+	}
+	dir, err := os.MkdirTemp(base, "calibre-chapters-debug-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create intermediate dir: %w", err)
+	}
+	return dir, nil
+}
+
+// intermediateFiles reports which intermediate files exist in dir. Nothing
+// is reported unless they were kept.
+func intermediateFiles(dir string, kept bool) *DebugInfo {
+	info := &DebugInfo{}
+	if !kept {
+		return info
+	}
+
+	info.Dir = dir
+	if path := filepath.Join(dir, "book.epub"); statOK(path) {
+		info.ConvertedEPUB = path
+	}
+	if path := filepath.Join(dir, "book.txt"); statOK(path) {
+		info.TextFile = path
+	}
+	return info
+}
+
+// statOK reports whether a file exists at path
+func statOK(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// ExtractChapter extracts only the chapter at index (0-based). For EPUBs the
+// table of contents is walked natively, reading each entry's range with
+// GetChapterContentRange until the chapter is reached, so the rest of the
+// book isn't touched and chapters are numbered as ExtractChapters numbers
+// them from the table of contents. Other formats, and EPUBs without a usable
+// table of contents, go through the full extraction pipeline. An index past
+// the last chapter returns ErrChapterOutOfRange.
+func (c *Calibre) ExtractChapter(ctx context.Context, ebookPath string, index int) (*models.Chapter, error) {
+	if index < 0 {
+		return nil, fmt.Errorf("%w: %d", ErrChapterOutOfRange, index)
+	}
+	if err := checkDRM(ebookPath); err != nil {
+		return nil, err
+	}
+
+	var found *models.Chapter
+	count := 0
+	take := func(chapter models.Chapter) error {
+		if count == index {
+			found = &chapter
+			return errStopChapters
+		}
+		count++
+		return nil
+	}
+
+	if isEPUB(ebookPath) {
+		sources := []func(context.Context, string, ChapterOptions, func(models.Chapter) error) error{
+			c.streamChaptersFromOriginalNCX,
+			c.streamChaptersFromNav,
+		}
+		for _, source := range sources {
+			count = 0
+			err := source(ctx, ebookPath, ChapterOptions{}, take)
+			if found != nil {
+				return found, nil
+			}
+			if err == nil {
+				return nil, fmt.Errorf("%w: %d (book has %d chapters)", ErrChapterOutOfRange, index, count)
+			}
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+		}
+	}
+
+	count = 0
+	err := c.streamChapters(ctx, ebookPath, ChapterOptions{}, take)
+	if found != nil {
+		return found, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, fmt.Errorf("%w: %d (book has %d chapters)", ErrChapterOutOfRange, index, count)
+}
+
+// errStopChapters ends chapter streaming early once the wanted chapter is found
+var errStopChapters = errors.New("stop chapter extraction")
+
+// StreamChapters extracts chapters like ExtractChaptersWithOptions but sends
+// each one as soon as its content is extracted, so large books are never held
+// in memory at once. The chapter channel is closed when extraction ends, after
+// which the error channel delivers a single error (nil on success).
+// Cancelling ctx stops extraction between chapters; callers that stop reading
+// early must cancel ctx to release the extraction goroutine.
+func (c *Calibre) StreamChapters(ctx context.Context, ebookPath string, opts ChapterOptions) (<-chan models.Chapter, <-chan error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	chapters := make(chan models.Chapter)
+	errc := make(chan error, 1)
+
+	go func() {
+		defer close(errc)
+
+		err := c.streamChapters(ctx, ebookPath, opts, func(chapter models.Chapter) error {
+			select {
+			case chapters <- chapter:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+		close(chapters)
+		errc <- err
+	}()
+
+	return chapters, errc
+}
+
+// streamChapters runs the chapter extraction pipeline, passing each chapter to emit
+func (c *Calibre) streamChapters(ctx context.Context, ebookPath string, opts ChapterOptions, emit func(models.Chapter) error) (err error) {
+	// DRM-protected content can't be read, so fail with a clear error
+	if err := checkDRM(ebookPath); err != nil {
+		return err
+	}
+
+	// Reject a bad SplitRegex up front rather than only if the text fallback runs
+	if opts.SplitRegex != "" {
+		if _, err := compileSplitRegex(opts.SplitRegex); err != nil {
+			return err
+		}
 	}
 
 	// Create temp directory for output
-	tmpDir, err := os.MkdirTemp("", "calibre-chapters-*")
+	tmpDir, err := c.chapterWorkDir(opts)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create temp dir: %w", err)
+		return err
+	}
+	if !opts.DebugKeepIntermediate {
+		defer os.RemoveAll(tmpDir)
+	}
+	if opts.debug != nil {
+		defer func() { opts.debug(intermediateFiles(tmpDir, opts.DebugKeepIntermediate)) }()
+	}
+
+	if opts.Deduplicate {
+		emit = dedupChapters(emit)
+	}
+	if opts.StripBoilerplate {
+		var flush func() error
+		emit, flush = stripBoilerplateChapters(emit)
+		defer func() {
+			if err == nil {
+				err = flush()
+			}
+		}()
+	}
+
+	emitted := 0
+	counted := func(chapter models.Chapter) error {
+		emitted++
+		return emit(chapter)
 	}
-	defer os.RemoveAll(tmpDir)
 
 	// First, try NCX-based extraction (Calibre's proper chapter API)
-	chapters, err := c.extractChaptersWithNCX(ctx, ebookPath, tmpDir, opts)
-	if err == nil && len(chapters) > 0 {
-		return chapters, nil
+	err = c.streamChaptersWithNCX(ctx, ebookPath, tmpDir, opts, counted)
+	if err == nil || emitted > 0 || ctx.Err() != nil || errors.Is(err, ErrDryRun) {
+		return err
+	}
+
+	// Next, look for chapter headings in the styling. A non-EPUB input was
+	// converted to EPUB by the NCX attempt, so that copy is searched instead.
+	if opts.HeadingHeuristic {
+		epubPath := ebookPath
+		if !isEPUB(ebookPath) {
+			epubPath = filepath.Join(tmpDir, "book.epub")
+		}
+		source := func(emit func(models.Chapter) error) error {
+			return c.streamChaptersFromHeadings(ctx, epubPath, opts, emit)
+		}
+		err = streamAtLeast(2, source, counted)
+		if err == nil || emitted > 0 || ctx.Err() != nil {
+			return err
+		}
 	}
 
 	// Fallback to text-based extraction with regex
-	return c.extractChaptersWithText(ctx, ebookPath, tmpDir, opts)
+	chapters, err := c.extractChaptersWithText(ctx, ebookPath, tmpDir, opts)
+	if err != nil {
+		return err
+	}
+	for _, chapter := range chapters {
+		if err := emit(chapter); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
-// extractChaptersWithNCX uses the NCX table of contents for proper chapter detection
-func (c *Calibre) extractChaptersWithNCX(ctx context.Context, ebookPath, tmpDir string, opts ChapterOptions) ([]models.Chapter, error) {
-	// First, try to use the original EPUB's NCX (often has better chapter titles)
-	if strings.HasSuffix(strings.ToLower(ebookPath), ".epub") {
-		chapters, err := c.extractChaptersFromOriginalNCX(ebookPath)
-		if err == nil && len(chapters) >= 3 {
-			return chapters, nil
+// streamChaptersWithNCX uses the NCX table of contents for proper chapter detection
+func (c *Calibre) streamChaptersWithNCX(ctx context.Context, ebookPath, tmpDir string, opts ChapterOptions, emit func(models.Chapter) error) error {
+	if isEPUB(ebookPath) {
+		// First, try to use the original EPUB's NCX (often has better chapter
+		// titles), then the nav document EPUB 3 books may ship instead
+		sources := []func(emit func(models.Chapter) error) error{
+			func(emit func(models.Chapter) error) error {
+				return c.streamChaptersFromOriginalNCX(ctx, ebookPath, opts, emit)
+			},
+			func(emit func(models.Chapter) error) error {
+				return c.streamChaptersFromNav(ctx, ebookPath, opts, emit)
+			},
+		}
+		// An error from emit itself, such as ExtractChapter stopping early,
+		// ends extraction rather than moving on to the next source
+		var emitErr error
+		emitting := func(chapter models.Chapter) error {
+			if err := emit(chapter); err != nil {
+				emitErr = err
+				return err
+			}
+			return nil
+		}
+
+		report := opts.skipped
+		for _, source := range sources {
+			// Only the skipped entries of the source that's used are reported
+			var skipped []ChapterError
+			opts.skipped = func(e ChapterError) {
+				skipped = append(skipped, e)
+			}
+
+			err := streamAtLeast(3, source, emitting)
+			if err == nil && report != nil {
+				for _, e := range skipped {
+					report(e)
+				}
+			}
+			if err == nil || emitErr != nil || ctx.Err() != nil {
+				return err
+			}
 		}
+		opts.skipped = report
 	}
 
 	// Fallback: Convert to EPUB with Calibre's chapter detection
-	return c.extractChaptersWithCalibreNCX(ctx, ebookPath, tmpDir, opts)
+	epubPath := filepath.Join(tmpDir, "book.epub")
+	if err := c.convertForChapters(ctx, ebookPath, epubPath, opts); err != nil {
+		return err
+	}
+
+	return c.streamChaptersFromConvertedEPUB(ctx, epubPath, opts, emit)
+}
+
+// streamAtLeast runs a chapter source, holding its chapters back until at
+// least min have been produced. A source that ends with fewer emits nothing
+// and returns an error, so the caller can try another source.
+func streamAtLeast(min int, source func(emit func(models.Chapter) error) error, emit func(models.Chapter) error) error {
+	var pending []models.Chapter
+	streaming := false
+
+	err := source(func(chapter models.Chapter) error {
+		if streaming {
+			return emit(chapter)
+		}
+
+		pending = append(pending, chapter)
+		if len(pending) < min {
+			return nil
+		}
+
+		streaming = true
+		for _, p := range pending {
+			if err := emit(p); err != nil {
+				return err
+			}
+		}
+		pending = nil
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if !streaming {
+		return fmt.Errorf("found %d chapters, need at least %d", len(pending), min)
+	}
+
+	return nil
+}
+
+// collectChapters runs a chapter source and gathers its chapters into a slice
+func collectChapters(source func(emit func(models.Chapter) error) error) ([]models.Chapter, error) {
+	var chapters []models.Chapter
+	err := source(func(chapter models.Chapter) error {
+		chapters = append(chapters, chapter)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return chapters, nil
 }
 
-// extractChaptersFromOriginalNCX extracts chapters using the original EPUB's NCX
-func (c *Calibre) extractChaptersFromOriginalNCX(epubPath string) ([]models.Chapter, error) {
+// streamChaptersFromOriginalNCX extracts chapters using the original EPUB's NCX
+func (c *Calibre) streamChaptersFromOriginalNCX(ctx context.Context, epubPath string, opts ChapterOptions, emit func(models.Chapter) error) error {
 	// Parse the NCX from the original EPUB
+	start := time.Now()
 	ncxDoc, err := ncx.ExtractNCXFromEPUB(epubPath)
+	c.metrics().ObserveNCXParse(time.Since(start))
 	if err != nil {
-		return nil, fmt.Errorf("failed to extract NCX: %w", err)
+		return fmt.Errorf("failed to extract NCX: %w", err)
 	}
 
-	// Get TOC entries from NCX
+	// Get TOC entries from NCX, in reading order
 	tocEntries := ncxDoc.GetTOC()
 	if len(tocEntries) == 0 {
-		return nil, fmt.Errorf("no chapters found in NCX")
+		return fmt.Errorf("no chapters found in NCX")
 	}
+	ncx.SortByPlayOrder(tocEntries)
 
+	return c.streamChaptersFromTOCEntries(ctx, epubPath, tocEntries, opts, emit)
+}
+
+// streamChaptersFromNav extracts chapters using the original EPUB's nav document
+func (c *Calibre) streamChaptersFromNav(ctx context.Context, epubPath string, opts ChapterOptions, emit func(models.Chapter) error) error {
+	start := time.Now()
+	tocEntries, err := ncx.ExtractNavFromEPUB(epubPath)
+	c.metrics().ObserveNCXParse(time.Since(start))
+	if err != nil {
+		return fmt.Errorf("failed to extract nav: %w", err)
+	}
+
+	return c.streamChaptersFromTOCEntries(ctx, epubPath, tocEntries, opts, emit)
+}
+
+// streamChaptersFromTOCEntries extracts content for the chapter-like entries
+// of an EPUB's table of contents
+func (c *Calibre) streamChaptersFromTOCEntries(ctx context.Context, epubPath string, tocEntries []ncx.TOCEntry, opts ChapterOptions, emit func(models.Chapter) error) error {
 	// Filter to get only chapter-like entries (skip front matter, etc.)
-	chapterEntries := filterChapterEntries(tocEntries)
+	chapterEntries := filterChapterEntries(skipGuideEntries(epubPath, tocEntries), opts.Filter)
 	if len(chapterEntries) == 0 {
-		return nil, fmt.Errorf("no chapter entries found")
+		return fmt.Errorf("no chapter entries found")
 	}
 
 	// Extract chapter content for each entry
-	var chapters []models.Chapter
+	start := time.Now()
+	defer func() { c.metrics().ObserveChapterExtract(time.Since(start)) }()
+
+	count := 0
 	for i, entry := range chapterEntries {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
 		// Get the next href for range extraction
 		nextHref := ""
 		if i+1 < len(chapterEntries) {
@@ -105,11 +630,12 @@ func (c *Calibre) extractChaptersFromOriginalNCX(epubPath string) ([]models.Chap
 		content, err := ncx.GetChapterContentRange(epubPath, entry.Href, nextHref)
 		if err != nil {
 			// Skip chapters we can't extract content for
+			opts.reportSkipped(ChapterError{Index: i, Title: entry.Title, Href: entry.Href, Err: err})
 			continue
 		}
 
 		// Skip very short content (likely front matter or navigation)
-		if len(strings.Fields(content)) < 50 {
+		if len(strings.Fields(content)) < opts.Filter.minWords() {
 			continue
 		}
 
@@ -118,36 +644,95 @@ func (c *Calibre) extractChaptersFromOriginalNCX(epubPath string) ([]models.Chap
 			title = fmt.Sprintf("Chapter %d", i+1)
 		}
 
-		chapters = append(chapters, models.NewChapter(len(chapters), title, content))
+		chapter := models.NewChapter(count, title, content)
+		if opts.KeepHTML {
+			chapter.HTMLContent, _ = ncx.GetChapterHTMLRange(epubPath, entry.Href, nextHref)
+			chapter.Footnotes = chapterFootnotes(chapter.HTMLContent)
+		}
+		if err := emit(chapter); err != nil {
+			return err
+		}
+		count++
+	}
+
+	if count == 0 {
+		return fmt.Errorf("failed to extract any chapter content")
 	}
 
-	if len(chapters) == 0 {
-		return nil, fmt.Errorf("failed to extract any chapter content")
+	return nil
+}
+
+// guideSkipTypes are the guide reference types whose content is never a chapter
+var guideSkipTypes = map[string]bool{
+	"cover":          true,
+	"title-page":     true,
+	"toc":            true,
+	"copyright-page": true,
+	"colophon":       true,
+	"index":          true,
+	"loi":            true,
+	"lot":            true,
+}
+
+// skipGuideEntries drops TOC entries that the EPUB's guide marks as the
+// cover, title page, table of contents or similar, whatever their titles.
+// Books without a guide are returned unchanged.
+func skipGuideEntries(epubPath string, entries []ncx.TOCEntry) []ncx.TOCEntry {
+	guide, err := opf.ParseGuideFromEPUB(epubPath)
+	if err != nil {
+		return entries
 	}
 
-	return chapters, nil
+	var skip []string
+	for typ, href := range guide {
+		if guideSkipTypes[typ] {
+			skip = append(skip, href)
+		}
+	}
+	if len(skip) == 0 {
+		return entries
+	}
+
+	var kept []ncx.TOCEntry
+	for _, entry := range entries {
+		matched := false
+		for _, href := range skip {
+			if guideHrefMatches(href, entry.Href) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			kept = append(kept, entry)
+		}
+	}
+	return kept
+}
+
+// guideHrefMatches reports whether a TOC entry points at a guide reference.
+// A guide reference without a fragment covers its whole file.
+func guideHrefMatches(guideHref, entryHref string) bool {
+	guideFile, guideFragment, _ := strings.Cut(guideHref, "#")
+	entryFile, entryFragment, _ := strings.Cut(entryHref, "#")
+	if entryFile == "" || (guideFile != entryFile && !strings.HasSuffix(guideFile, "/"+entryFile)) {
+		return false
+	}
+	return guideFragment == "" || guideFragment == entryFragment
 }
 
 // filterChapterEntries filters TOC entries to get actual chapter content
-func filterChapterEntries(entries []ncx.TOCEntry) []ncx.TOCEntry {
+func filterChapterEntries(entries []ncx.TOCEntry, filter ChapterFilter) []ncx.TOCEntry {
 	var chapters []ncx.TOCEntry
 
 	// Skip common front/back matter patterns
-	skipPatterns := []string{
-		"transcriber", "note", "copyright", "dedication", "epigraph",
-		"acknowledgment", "about the author", "about the book",
-		"the full project gutenberg", "project gutenberg", "license",
-		"the modern library", "footnotes", "endnotes", "index",
-		"bibliography", "contents", "table of contents",
-	}
-
+	skipPatterns := filter.skipPatterns()
 	for _, entry := range entries {
 		titleLower := strings.ToLower(entry.Title)
 
 		// Skip entries that match skip patterns
 		skip := false
 		for _, pattern := range skipPatterns {
-			if strings.Contains(titleLower, pattern) {
+			if strings.Contains(titleLower, strings.ToLower(pattern)) {
 				skip = true
 				break
 			}
@@ -183,7 +768,7 @@ func filterChapterEntries(entries []ncx.TOCEntry) []ncx.TOCEntry {
 			isChapter = true
 		}
 
-		if isChapter {
+		if isChapter || filter.IncludeFrontMatter {
 			chapters = append(chapters, entry)
 		}
 	}
@@ -191,10 +776,12 @@ func filterChapterEntries(entries []ncx.TOCEntry) []ncx.TOCEntry {
 	return chapters
 }
 
-// extractChaptersWithCalibreNCX uses Calibre's conversion to generate NCX
-func (c *Calibre) extractChaptersWithCalibreNCX(ctx context.Context, ebookPath, tmpDir string, opts ChapterOptions) ([]models.Chapter, error) {
-	// Convert to EPUB with proper chapter detection
-	epubPath := filepath.Join(tmpDir, "book.epub")
+// convertForChapters converts an ebook to EPUB with Calibre's chapter detection
+// and TOC generation enabled
+func (c *Calibre) convertForChapters(ctx context.Context, ebookPath, epubPath string, opts ChapterOptions) error {
+	if c.ebookConvert == "" {
+		return toolNotFound("ebook-convert")
+	}
 
 	args := []string{ebookPath, epubPath}
 
@@ -211,30 +798,49 @@ func (c *Calibre) extractChaptersWithCalibreNCX(ctx context.Context, ebookPath,
 	args = append(args, "--level1-toc", "//h:h1")
 	args = append(args, "--level2-toc", "//h:h2")
 
+	start := time.Now()
 	_, err := c.runCommand(ctx, c.ebookConvert, args...)
+	c.metrics().ObserveConversion(time.Since(start))
 	if err != nil {
-		return nil, fmt.Errorf("ebook-convert to EPUB failed: %w", err)
+		return fmt.Errorf("ebook-convert to EPUB failed: %w", err)
 	}
 
+	return nil
+}
+
+// streamChaptersFromConvertedEPUB reads chapters from an EPUB whose NCX was
+// generated by Calibre's chapter detection
+func (c *Calibre) streamChaptersFromConvertedEPUB(ctx context.Context, epubPath string, opts ChapterOptions, emit func(models.Chapter) error) error {
 	// Parse the NCX from the converted EPUB
+	start := time.Now()
 	ncxDoc, err := ncx.ExtractNCXFromEPUB(epubPath)
+	c.metrics().ObserveNCXParse(time.Since(start))
 	if err != nil {
-		return nil, fmt.Errorf("failed to extract NCX: %w", err)
+		return fmt.Errorf("failed to extract NCX: %w", err)
 	}
 
-	// Get TOC entries from NCX
+	// Get TOC entries from NCX, in reading order
 	tocEntries := ncxDoc.GetTOC()
 	if len(tocEntries) == 0 {
-		return nil, fmt.Errorf("no chapters found in NCX")
+		return fmt.Errorf("no chapters found in NCX")
 	}
+	ncx.SortByPlayOrder(tocEntries)
 
 	// Extract chapter content for each TOC entry
-	var chapters []models.Chapter
+	start = time.Now()
+	defer func() { c.metrics().ObserveChapterExtract(time.Since(start)) }()
+
+	count := 0
 	for i, entry := range tocEntries {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
 		// Get chapter content from the EPUB using the href
 		content, err := ncx.GetChapterContent(epubPath, entry.Href)
 		if err != nil {
 			// Skip chapters we can't extract content for
+			opts.reportSkipped(ChapterError{Index: i, Title: entry.Title, Href: entry.Href, Err: err})
 			continue
 		}
 
@@ -244,18 +850,106 @@ func (c *Calibre) extractChaptersWithCalibreNCX(ctx context.Context, ebookPath,
 			title = fmt.Sprintf("Chapter %d", i+1)
 		}
 
-		chapters = append(chapters, models.NewChapter(i, title, content))
+		chapter := models.NewChapter(i, title, content)
+		if opts.KeepHTML {
+			chapter.HTMLContent, _ = ncx.GetChapterHTMLRange(epubPath, entry.Href, "")
+			chapter.Footnotes = chapterFootnotes(chapter.HTMLContent)
+		}
+		if err := emit(chapter); err != nil {
+			return err
+		}
+		count++
 	}
 
-	if len(chapters) == 0 {
-		return nil, fmt.Errorf("failed to extract any chapter content")
+	if count == 0 {
+		return fmt.Errorf("failed to extract any chapter content")
 	}
 
-	return chapters, nil
+	return nil
+}
+
+// bodyRe captures the content of an HTML document's body
+var bodyRe = regexp.MustCompile(`(?is)<body[^>]*>(.*?)(?:</body>|$)`)
+
+// streamChaptersFromHeadings splits an EPUB's content files, in spine order,
+// at the elements ncx.FindChapterStarts takes for chapter headings. A chapter
+// runs from its heading to the next one, across file boundaries; text before
+// the first heading is treated as front matter and dropped.
+func (c *Calibre) streamChaptersFromHeadings(ctx context.Context, epubPath string, opts ChapterOptions, emit func(models.Chapter) error) error {
+	paths, err := opf.ParseSpineFromEPUB(epubPath)
+	if err != nil {
+		return fmt.Errorf("failed to read spine: %w", err)
+	}
+
+	start := time.Now()
+	defer func() { c.metrics().ObserveChapterExtract(time.Since(start)) }()
+
+	count := 0
+	title := ""
+	var body strings.Builder
+
+	flush := func() error {
+		html := body.String()
+		body.Reset()
+		if title == "" {
+			return nil
+		}
+
+		content := ncx.HTMLToText(html, ncx.TextOptions{BlankLines: true, CollapseWhitespace: true})
+		if len(strings.Fields(content)) < opts.Filter.minWords() {
+			return nil
+		}
+
+		chapter := models.NewChapter(count, title, content)
+		if opts.KeepHTML {
+			chapter.HTMLContent = html
+			chapter.Footnotes = chapterFootnotes(html)
+		}
+		count++
+		return emit(chapter)
+	}
+
+	for _, path := range paths {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		doc, err := ncx.GetChapterHTMLRange(epubPath, path, "")
+		if err != nil {
+			// Skip files we can't read
+			continue
+		}
+		if m := bodyRe.FindStringSubmatch(doc); m != nil {
+			doc = m[1]
+		}
+
+		offset := 0
+		for _, heading := range ncx.FindChapterStarts(doc) {
+			body.WriteString(doc[offset:heading.Offset])
+			if err := flush(); err != nil {
+				return err
+			}
+			title, offset = heading.Title, heading.Offset
+		}
+		body.WriteString(doc[offset:])
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+
+	if count == 0 {
+		return fmt.Errorf("no chapter headings found")
+	}
+
+	return nil
 }
 
 // extractChaptersWithText is the fallback regex-based chapter extraction
 func (c *Calibre) extractChaptersWithText(ctx context.Context, ebookPath, tmpDir string, opts ChapterOptions) ([]models.Chapter, error) {
+	if c.ebookConvert == "" {
+		return nil, toolNotFound("ebook-convert")
+	}
+
 	// Convert to plain text for content extraction
 	txtPath := filepath.Join(tmpDir, "book.txt")
 	txtArgs := []string{ebookPath, txtPath}
@@ -265,7 +959,9 @@ func (c *Calibre) extractChaptersWithText(ctx context.Context, ebookPath, tmpDir
 		txtArgs = append(txtArgs, "--chapter-mark", opts.ChapterMark)
 	}
 
+	start := time.Now()
 	_, err := c.runCommand(ctx, c.ebookConvert, txtArgs...)
+	c.metrics().ObserveConversion(time.Since(start))
 	if err != nil {
 		return nil, fmt.Errorf("ebook-convert to txt failed: %w", err)
 	}
@@ -276,28 +972,61 @@ func (c *Calibre) extractChaptersWithText(ctx context.Context, ebookPath, tmpDir
 		return nil, fmt.Errorf("failed to read text output: %w", err)
 	}
 
+	// Drop the license text before it's split into chapters of its own
+	text := string(txtContent)
+	if opts.StripBoilerplate {
+		text = StripGutenbergBoilerplate(text)
+	}
+
 	// Split by page breaks (form feed character or multiple newlines)
-	chapters := splitIntoChapters(string(txtContent))
+	start = time.Now()
+	chapters, err := splitIntoChapters(text, opts)
+	c.metrics().ObserveChapterExtract(time.Since(start))
+	if err != nil {
+		return nil, err
+	}
 
 	return chapters, nil
 }
 
-// splitIntoChapters splits text content into chapters
-func splitIntoChapters(content string) []models.Chapter {
+// splitIntoChapters splits text content into chapters. A SplitRegex is tried
+// first, then each strategy in order until one produces more than one part.
+func splitIntoChapters(content string, opts ChapterOptions) ([]models.Chapter, error) {
 	var chapters []models.Chapter
 
-	// Calibre uses form feed (\f) or page break markers
-	// Also try splitting on common chapter patterns
+	strategies := opts.SplitStrategies
+	if len(strategies) == 0 {
+		strategies = DefaultSplitStrategies
+	}
 
-	// First try form feed (page break)
-	parts := strings.Split(content, "\f")
-	if len(parts) <= 1 {
-		// Try splitting by "* * *" separator (common in Gutenberg books)
-		parts = splitByStarSeparator(content)
+	parts := []string{content}
+	if opts.SplitRegex != "" {
+		re, err := compileSplitRegex(opts.SplitRegex)
+		if err != nil {
+			return nil, err
+		}
+		parts = splitByRegex(content, re)
 	}
-	if len(parts) <= 1 {
-		// Try chapter heading patterns
-		parts = splitByChapterPatterns(content)
+
+	for _, strategy := range strategies {
+		if len(parts) > 1 {
+			break
+		}
+
+		switch strategy {
+		case SplitFormFeed:
+			// Calibre uses form feed (\f) for page breaks
+			parts = strings.Split(content, "\f")
+		case SplitStarSeparator:
+			// "* * *" separator (common in Gutenberg books)
+			parts = splitByStarSeparator(content)
+		case SplitPatterns:
+			// Chapter heading patterns
+			parts = splitByChapterPatterns(content)
+		case SplitBlankLines:
+			// Large blank-line gaps with no other marker
+			parts = splitByBlankLines(content, opts.BlankLineGap)
+		}
 	}
 
 	for i, part := range parts {
@@ -310,7 +1039,43 @@ func splitIntoChapters(content string) []models.Chapter {
 		chapters = append(chapters, models.NewChapter(i, title, part))
 	}
 
-	return chapters
+	return chapters, nil
+}
+
+// compileSplitRegex compiles a user-supplied chapter heading pattern in
+// multiline mode, so ^ and $ match at line boundaries
+func compileSplitRegex(pattern string) (*regexp.Regexp, error) {
+	re, err := regexp.Compile("(?m)" + pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SplitRegex %q: %w", pattern, err)
+	}
+	return re, nil
+}
+
+// splitByRegex splits content at the start of each match of re. Text before
+// the first match is kept only if it is substantial.
+func splitByRegex(content string, re *regexp.Regexp) []string {
+	matches := re.FindAllStringIndex(content, -1)
+	if len(matches) == 0 {
+		return []string{content}
+	}
+
+	var parts []string
+	if before := strings.TrimSpace(content[:matches[0][0]]); len(before) > 100 {
+		parts = append(parts, before)
+	}
+
+	for i, match := range matches {
+		end := len(content)
+		if i+1 < len(matches) {
+			end = matches[i+1][0]
+		}
+		if part := strings.TrimSpace(content[match[0]:end]); part != "" {
+			parts = append(parts, part)
+		}
+	}
+
+	return parts
 }
 
 // splitByStarSeparator splits content by "* * *" separators (common in Project Gutenberg)
@@ -337,6 +1102,32 @@ func splitByStarSeparator(content string) []string {
 	return []string{content}
 }
 
+// splitByBlankLines splits content on runs of at least gap consecutive blank lines
+func splitByBlankLines(content string, gap int) []string {
+	if gap <= 0 {
+		gap = DefaultBlankLineGap
+	}
+
+	// gap blank lines means gap+1 consecutive line breaks
+	re := regexp.MustCompile(fmt.Sprintf(`\n(?:[ \t]*\n){%d,}`, gap))
+	parts := re.Split(strings.ReplaceAll(content, "\r\n", "\n"), -1)
+
+	var chapters []string
+	for _, part := range parts {
+		trimmed := strings.TrimSpace(part)
+		if len(trimmed) > 100 {
+			chapters = append(chapters, trimmed)
+		}
+	}
+
+	// Need at least 3 chapters to be confident
+	if len(chapters) >= 3 {
+		return chapters
+	}
+
+	return []string{content}
+}
+
 // splitByChapterPatterns splits content by chapter heading patterns
 func splitByChapterPatterns(content string) []string {
 	// Pattern to match chapter headings (order matters - try most specific first)
@@ -463,19 +1254,69 @@ func detectChapterTitle(content string, defaultNum int) string {
 	return fmt.Sprintf("Chapter %d", defaultNum)
 }
 
-// titleCase converts a string to title case
+// minorWords stay lowercase in titles unless they are the first or last word
+var minorWords = map[string]bool{
+	"a": true, "an": true, "the": true,
+	"and": true, "but": true, "or": true, "nor": true, "for": true, "so": true, "yet": true,
+	"as": true, "at": true, "by": true, "in": true, "of": true, "off": true,
+	"on": true, "per": true, "to": true, "via": true,
+}
+
+// maxAcronymLength is the longest all-caps word titleCase keeps as an acronym
+const maxAcronymLength = 5
+
+// chapterFootnotes returns the footnotes referenced in a chapter's HTML
+func chapterFootnotes(html string) []models.Footnote {
+	var footnotes []models.Footnote
+	for _, note := range ncx.ExtractFootnotes(html) {
+		footnotes = append(footnotes, models.Footnote{ID: note.ID, Marker: note.Marker, Text: note.Text})
+	}
+	return footnotes
+}
+
+// titleCase converts a string to title case. Articles, conjunctions and short
+// prepositions stay lowercase except as the first or last word, and all-caps
+// words of up to five letters are kept as likely acronyms. A heading that is
+// all caps and longer than ten characters is shouting rather than acronyms, so
+// it is lowercased first and every word recased.
 func titleCase(s string) string {
-	// If it's all caps, convert to title case
-	if s == strings.ToUpper(s) && len(s) > 10 {
-		words := strings.Fields(strings.ToLower(s))
-		for i, word := range words {
-			if len(word) > 0 {
-				words[i] = strings.ToUpper(string(word[0])) + word[1:]
-			}
+	shouting := s == strings.ToUpper(s) && len(s) > 10
+	if shouting {
+		s = strings.ToLower(s)
+	}
+
+	words := strings.Fields(s)
+	for i, word := range words {
+		letters := strings.TrimFunc(word, func(r rune) bool { return !unicode.IsLetter(r) })
+		switch {
+		case letters == "":
+			continue
+		case !shouting && isAcronym(letters):
+			continue
+		case i > 0 && i < len(words)-1 && minorWords[strings.ToLower(letters)]:
+			words[i] = strings.ToLower(word)
+		default:
+			words[i] = capitalizeWord(word)
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// isAcronym reports whether a word is short and all caps, like "CIA"
+func isAcronym(word string) bool {
+	return utf8.RuneCountInString(word) <= maxAcronymLength &&
+		word == strings.ToUpper(word) && word != strings.ToLower(word)
+}
+
+// capitalizeWord uppercases the first letter of a word, after any leading
+// punctuation such as quotes, leaving the rest as is
+func capitalizeWord(word string) string {
+	for i, r := range word {
+		if unicode.IsLetter(r) {
+			return word[:i] + string(unicode.ToUpper(r)) + word[i+utf8.RuneLen(r):]
 		}
-		return strings.Join(words, " ")
 	}
-	return s
+	return word
 }
 
 // formatChapterTitle normalizes a chapter title
@@ -501,10 +1342,22 @@ func (c *Calibre) GetTOC(ebookPath string) ([]models.TOCEntry, error) {
 	return c.GetTOCContext(context.Background(), ebookPath)
 }
 
-// GetTOCContext extracts TOC with context
+// GetTOCContext extracts TOC with context. For EPUBs the NCX (or EPUB 3 nav
+// document) is read directly without running ebook-convert, and its hierarchy
+// is preserved in Children. Other formats fall back to chapter extraction.
 func (c *Calibre) GetTOCContext(ctx context.Context, ebookPath string) ([]models.TOCEntry, error) {
-	// For now, extract chapters and use their titles as TOC
-	// A more complete implementation would parse the NCX/NAV file from EPUB
+	if isEPUB(ebookPath) {
+		if ncxDoc, err := ncx.ExtractNCXFromEPUB(ebookPath); err == nil {
+			if toc := tocFromNCX(ncxDoc); len(toc) > 0 {
+				return toc, nil
+			}
+		}
+		if entries, err := ncx.ExtractNavFromEPUB(ebookPath); err == nil && len(entries) > 0 {
+			return convertTOCEntries(nestTOCEntries(entries)), nil
+		}
+	}
+
+	// Otherwise, extract chapters and use their titles as TOC
 	chapters, err := c.ExtractChaptersContext(ctx, ebookPath)
 	if err != nil {
 		return nil, err
@@ -520,3 +1373,40 @@ func (c *Calibre) GetTOCContext(ctx context.Context, ebookPath string) ([]models
 
 	return toc, nil
 }
+
+// tocFromNCX maps the NCX tree to TOC entries, keeping their real href,
+// level, and nesting
+func tocFromNCX(ncxDoc *ncx.NCX) []models.TOCEntry {
+	return convertTOCEntries(ncxDoc.GetNestedTOC())
+}
+
+// nestTOCEntries builds a tree from flat entries using their Level
+func nestTOCEntries(flat []ncx.TOCEntry) []ncx.TOCEntry {
+	var build func(i, level int) ([]ncx.TOCEntry, int)
+	build = func(i, level int) ([]ncx.TOCEntry, int) {
+		var entries []ncx.TOCEntry
+		for i < len(flat) && flat[i].Level >= level {
+			entry := flat[i]
+			entry.Children, i = build(i+1, entry.Level+1)
+			entries = append(entries, entry)
+		}
+		return entries, i
+	}
+
+	entries, _ := build(0, 1)
+	return entries
+}
+
+// convertTOCEntries recursively converts ncx TOC entries to model entries
+func convertTOCEntries(entries []ncx.TOCEntry) []models.TOCEntry {
+	var toc []models.TOCEntry
+	for _, entry := range entries {
+		toc = append(toc, models.TOCEntry{
+			Title:    entry.Title,
+			Level:    entry.Level,
+			Href:     entry.Href,
+			Children: convertTOCEntries(entry.Children),
+		})
+	}
+	return toc
+}
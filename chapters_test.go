@@ -0,0 +1,788 @@
+package calibre
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/anilpdv/go-calibre/models"
+	"github.com/anilpdv/go-calibre/ncx"
+)
+
+// decorativeStarsText builds three "Chapter N" sections, each containing a
+// decorative "* * *" scene break in the middle
+func decorativeStarsText() string {
+	var b strings.Builder
+	b.WriteString("A TALE OF SCENES\n\nBy Nobody\n\n")
+	for _, n := range []string{"1", "2", "3"} {
+		b.WriteString("Chapter " + n + "\n\n")
+		b.WriteString(strings.Repeat("The first scene goes on. ", 30))
+		b.WriteString("\n\n* * *\n\n")
+		b.WriteString(strings.Repeat("The second scene follows. ", 30))
+		b.WriteString("\n\n")
+	}
+	return b.String()
+}
+
+func mustSplit(t *testing.T, text string, opts ChapterOptions) []models.Chapter {
+	t.Helper()
+	chapters, err := splitIntoChapters(text, opts)
+	if err != nil {
+		t.Fatalf("splitIntoChapters failed: %v", err)
+	}
+	return chapters
+}
+
+func TestSplitIntoChaptersStrategies(t *testing.T) {
+	text := decorativeStarsText()
+
+	withStars := mustSplit(t, text, ChapterOptions{})
+	if len(withStars) != 4 {
+		t.Errorf("default strategies: expected 4 parts, got %d", len(withStars))
+	}
+
+	withoutStars := mustSplit(t, text, ChapterOptions{
+		SplitStrategies: []SplitStrategy{SplitFormFeed, SplitPatterns},
+	})
+	if len(withoutStars) != 3 {
+		t.Fatalf("without star separator: expected 3 chapters, got %d", len(withoutStars))
+	}
+	for i, ch := range withoutStars {
+		if !strings.HasPrefix(ch.Title, "Chapter ") {
+			t.Errorf("chapter %d title = %q", i, ch.Title)
+		}
+	}
+}
+
+func TestSplitByBlankLineGaps(t *testing.T) {
+	sections := []string{
+		"The storm broke over the harbour. " + strings.Repeat("Waves crashed on the quay. ", 10),
+		"Morning found the village quiet. " + strings.Repeat("Nobody spoke of the night. ", 10),
+		"Years later she returned alone. " + strings.Repeat("The harbour had changed. ", 10),
+	}
+	// Paragraph breaks within a section are a single blank line
+	text := strings.Join(sections, "\n\n\n\n\n\n") + "\n"
+	text = strings.Replace(text, "Waves crashed", "\n\nWaves crashed", 1)
+
+	chapters := mustSplit(t, text, ChapterOptions{})
+	if len(chapters) != 3 {
+		t.Fatalf("expected 3 chapters, got %d", len(chapters))
+	}
+	for i, ch := range chapters {
+		if !strings.HasPrefix(ch.Content, sections[i][:20]) {
+			t.Errorf("chapter %d starts with %q", i, ch.Content[:20])
+		}
+	}
+
+	// A larger required gap should leave the text unsplit
+	chapters = mustSplit(t, text, ChapterOptions{BlankLineGap: 8})
+	if len(chapters) != 1 {
+		t.Errorf("with gap 8 expected 1 chapter, got %d", len(chapters))
+	}
+}
+
+func TestSplitRegex(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("Workbook\n\n")
+	for _, n := range []string{"1", "2", "3", "4"} {
+		b.WriteString("Lesson " + n + "\n\n")
+		b.WriteString(strings.Repeat("Practice the exercise. ", 10))
+		b.WriteString("\n\n")
+	}
+
+	chapters := mustSplit(t, b.String(), ChapterOptions{SplitRegex: `^Lesson \d+`})
+	if len(chapters) != 4 {
+		t.Fatalf("expected 4 lessons, got %d", len(chapters))
+	}
+	for i, ch := range chapters {
+		if want := "Lesson " + string(rune('1'+i)); !strings.HasPrefix(ch.Content, want) {
+			t.Errorf("chapter %d starts with %q, want %q", i, ch.Summary(3), want)
+		}
+	}
+
+	if _, err := splitIntoChapters(b.String(), ChapterOptions{SplitRegex: `Lesson (\d+`}); err == nil {
+		t.Error("expected an error for an invalid SplitRegex")
+	}
+}
+
+func TestExtractChaptersFromNavOnlyEPUB(t *testing.T) {
+	nav := `<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops"><body>
+<nav epub:type="toc"><ol>
+<li><a href="text/ch1.xhtml">Chapter 1</a></li>
+<li><a href="text/ch2.xhtml">Chapter 2</a></li>
+<li><a href="text/ch3.xhtml">Chapter 3</a></li>
+</ol></nav></body></html>`
+
+	epub := writeTestEPUB(t, map[string]string{
+		"META-INF/container.xml": testContainer("OEBPS/content.opf"),
+		"OEBPS/content.opf": testOPF(`<dc:title>Nav Only</dc:title>`,
+			`<item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>`, ""),
+		"OEBPS/nav.xhtml":      nav,
+		"OEBPS/text/ch1.xhtml": testXHTML("<p>" + loremWords("One", 80) + "</p>"),
+		"OEBPS/text/ch2.xhtml": testXHTML("<p>" + loremWords("Two", 80) + "</p>"),
+		"OEBPS/text/ch3.xhtml": testXHTML("<p>" + loremWords("Three", 80) + "</p>"),
+	})
+
+	c := &Calibre{
+		Timeout:      DefaultTimeout,
+		ebookConvert: "ebook-convert",
+		Runner: func(ctx context.Context, name string, args ...string) ([]byte, error) {
+			t.Fatal("nav-only EPUB should not need conversion")
+			return nil, nil
+		},
+	}
+
+	chapters, err := c.ExtractChapters(epub)
+	if err != nil {
+		t.Fatalf("ExtractChapters failed: %v", err)
+	}
+	if len(chapters) != 3 {
+		t.Fatalf("expected 3 chapters, got %d", len(chapters))
+	}
+	if chapters[2].Title != "Chapter 3" || !strings.HasPrefix(chapters[2].Content, "Three") {
+		t.Errorf("chapter 3 = %q / %q", chapters[2].Title, chapters[2].Summary(20))
+	}
+}
+
+func TestExtractChaptersKeepHTML(t *testing.T) {
+	body := `<h2 id="c1">Chapter 1</h2><p>` + loremWords("Alpha", 60) + `</p>` +
+		`<h2 id="c2">Chapter 2</h2><p>` + loremWords("Beta", 60) + `</p>` +
+		`<h2 id="c3">Chapter 3</h2><p>` + loremWords("Gamma", 60) + `</p>`
+
+	epub := writeTestEPUB(t, map[string]string{
+		"toc.ncx": testNCX(
+			[2]string{"Chapter 1", "book.xhtml#c1"},
+			[2]string{"Chapter 2", "book.xhtml#c2"},
+			[2]string{"Chapter 3", "book.xhtml#c3"},
+		),
+		"book.xhtml": testXHTML(body),
+	})
+
+	c := &Calibre{Timeout: DefaultTimeout, ebookConvert: "ebook-convert"}
+	chapters, err := c.ExtractChaptersWithOptions(context.Background(), epub, ChapterOptions{KeepHTML: true})
+	if err != nil {
+		t.Fatalf("ExtractChaptersWithOptions failed: %v", err)
+	}
+	if len(chapters) != 3 {
+		t.Fatalf("expected 3 chapters, got %d", len(chapters))
+	}
+
+	first := chapters[0]
+	if first.HTMLContent == "" || !strings.Contains(first.HTMLContent, "<p>") {
+		t.Errorf("HTMLContent should contain tags, got %q", first.HTMLContent)
+	}
+	if strings.Contains(first.HTMLContent, "Beta") {
+		t.Error("HTMLContent should stop at the next chapter's anchor")
+	}
+	if strings.Contains(first.Content, "<") {
+		t.Errorf("Content should be plain text, got %q", first.Summary(40))
+	}
+
+	// Without KeepHTML the field stays empty
+	chapters, err = c.ExtractChaptersWithOptions(context.Background(), epub, ChapterOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if chapters[0].HTMLContent != "" {
+		t.Error("HTMLContent should be empty without KeepHTML")
+	}
+}
+
+// fourChapterEPUB builds an EPUB whose NCX lists four chapters
+func fourChapterEPUB(t *testing.T) string {
+	t.Helper()
+	return writeTestEPUB(t, map[string]string{
+		"toc.ncx": testNCX(
+			[2]string{"Chapter 1", "ch1.xhtml"},
+			[2]string{"Chapter 2", "ch2.xhtml"},
+			[2]string{"Chapter 3", "ch3.xhtml"},
+			[2]string{"Chapter 4", "ch4.xhtml"},
+		),
+		"ch1.xhtml": testXHTML("<p>" + loremWords("One", 60) + "</p>"),
+		"ch2.xhtml": testXHTML("<p>" + loremWords("Two", 60) + "</p>"),
+		"ch3.xhtml": testXHTML("<p>" + loremWords("Three", 60) + "</p>"),
+		"ch4.xhtml": testXHTML("<p>" + loremWords("Four", 60) + "</p>"),
+	})
+}
+
+func TestStreamChapters(t *testing.T) {
+	epub := fourChapterEPUB(t)
+	c := &Calibre{Timeout: DefaultTimeout, ebookConvert: "ebook-convert"}
+
+	stream, errc := c.StreamChapters(context.Background(), epub, ChapterOptions{})
+
+	var titles []string
+	for chapter := range stream {
+		if chapter.Index != len(titles) {
+			t.Errorf("chapter %q has index %d, want %d", chapter.Title, chapter.Index, len(titles))
+		}
+		titles = append(titles, chapter.Title)
+	}
+	if err := <-errc; err != nil {
+		t.Fatalf("StreamChapters failed: %v", err)
+	}
+
+	want := "Chapter 1,Chapter 2,Chapter 3,Chapter 4"
+	if got := strings.Join(titles, ","); got != want {
+		t.Errorf("titles = %q, want %q", got, want)
+	}
+}
+
+func TestStreamChaptersCanceled(t *testing.T) {
+	epub := fourChapterEPUB(t)
+	c := &Calibre{Timeout: DefaultTimeout, ebookConvert: "ebook-convert"}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream, errc := c.StreamChapters(ctx, epub, ChapterOptions{})
+	<-stream
+	cancel()
+
+	received := 1
+	for range stream {
+		received++
+	}
+	if received >= 4 {
+		t.Errorf("received all %d chapters after cancel", received)
+	}
+	if err := <-errc; !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestFilterChapterEntries(t *testing.T) {
+	entries := []ncx.TOCEntry{
+		{Title: "Preface", Level: 1},
+		{Title: "Chapter 1", Level: 1},
+		{Title: "Chapitre 2", Level: 1},
+		{Title: "Bibliography", Level: 1},
+	}
+
+	titles := func(filter ChapterFilter) string {
+		var got []string
+		for _, e := range filterChapterEntries(entries, filter) {
+			got = append(got, e.Title)
+		}
+		return strings.Join(got, ",")
+	}
+
+	tests := []struct {
+		name   string
+		filter ChapterFilter
+		want   string
+	}{
+		{"default", ChapterFilter{}, "Chapter 1"},
+		{"front matter", ChapterFilter{IncludeFrontMatter: true}, "Preface,Chapter 1,Chapitre 2"},
+		{"no skips", ChapterFilter{SkipPatterns: []string{}, IncludeFrontMatter: true}, "Preface,Chapter 1,Chapitre 2,Bibliography"},
+		{"custom skips", ChapterFilter{SkipPatterns: []string{"CHAPITRE"}, IncludeFrontMatter: true}, "Preface,Chapter 1,Bibliography"},
+	}
+	for _, tt := range tests {
+		if got := titles(tt.filter); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestExtractChaptersMinWords(t *testing.T) {
+	epub := writeTestEPUB(t, map[string]string{
+		"toc.ncx": testNCX(
+			[2]string{"Chapter 1", "ch1.xhtml"},
+			[2]string{"Chapter 2", "ch2.xhtml"},
+			[2]string{"Chapter 3", "ch3.xhtml"},
+			[2]string{"Chapter 4", "ch4.xhtml"},
+		),
+		"ch1.xhtml": testXHTML("<p>" + loremWords("One", 20) + "</p>"),
+		"ch2.xhtml": testXHTML("<p>" + loremWords("Two", 20) + "</p>"),
+		"ch3.xhtml": testXHTML("<p>" + loremWords("Three", 20) + "</p>"),
+		"ch4.xhtml": testXHTML("<p>" + loremWords("Four", 60) + "</p>"),
+	})
+	c := &Calibre{Timeout: DefaultTimeout, ebookConvert: "ebook-convert"}
+
+	chapters, err := c.ExtractChaptersWithOptions(context.Background(), epub, ChapterOptions{
+		Filter: ChapterFilter{MinWords: 10},
+	})
+	if err != nil {
+		t.Fatalf("ExtractChaptersWithOptions failed: %v", err)
+	}
+	if len(chapters) != 4 {
+		t.Errorf("expected 4 chapters with MinWords 10, got %d", len(chapters))
+	}
+}
+
+func TestGetTOCNestedFromNCX(t *testing.T) {
+	ncxDoc := `<?xml version="1.0" encoding="UTF-8"?>
+<ncx xmlns="http://www.daisy.org/z3986/2005/ncx/" version="2005-1"><navMap>
+<navPoint id="p1" playOrder="1"><navLabel><text>Part One</text></navLabel><content src="p1.xhtml"/>
+  <navPoint id="c1" playOrder="2"><navLabel><text>Chapter 1</text></navLabel><content src="c1.xhtml"/></navPoint>
+  <navPoint id="c2" playOrder="3"><navLabel><text>Chapter 2</text></navLabel><content src="c2.xhtml"/></navPoint>
+</navPoint>
+<navPoint id="p2" playOrder="4"><navLabel><text>Part Two</text></navLabel><content src="p2.xhtml"/></navPoint>
+</navMap></ncx>`
+
+	epub := writeTestEPUB(t, map[string]string{"toc.ncx": ncxDoc})
+
+	c := &Calibre{
+		Timeout:      DefaultTimeout,
+		ebookConvert: "ebook-convert",
+		Runner: func(ctx context.Context, name string, args ...string) ([]byte, error) {
+			t.Fatal("GetTOC on an EPUB should not run ebook-convert")
+			return nil, nil
+		},
+	}
+
+	toc, err := c.GetTOC(epub)
+	if err != nil {
+		t.Fatalf("GetTOC failed: %v", err)
+	}
+	if len(toc) != 2 {
+		t.Fatalf("expected 2 top-level entries, got %d", len(toc))
+	}
+	if len(toc[0].Children) != 2 || toc[0].Children[1].Title != "Chapter 2" {
+		t.Errorf("Part One children = %+v", toc[0].Children)
+	}
+	if toc[0].Children[0].Level != 2 || toc[0].Children[0].Href != "c1.xhtml" {
+		t.Errorf("Chapter 1 = %+v", toc[0].Children[0])
+	}
+}
+
+func TestGetTOCFromNav(t *testing.T) {
+	nav := `<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops"><body>
+<nav epub:type="toc"><ol>
+<li><a href="p1.xhtml">Part One</a><ol>
+  <li><a href="c1.xhtml">Chapter 1</a></li>
+  <li><a href="c2.xhtml#x">Chapter 2</a></li>
+</ol></li>
+<li><a href="p2.xhtml">Part Two</a></li>
+</ol></nav></body></html>`
+
+	epub := writeTestEPUB(t, map[string]string{"nav.xhtml": nav})
+
+	c := &Calibre{
+		Timeout:      DefaultTimeout,
+		ebookConvert: "ebook-convert",
+		Runner: func(ctx context.Context, name string, args ...string) ([]byte, error) {
+			t.Fatal("GetTOC on an EPUB should not run ebook-convert")
+			return nil, nil
+		},
+	}
+
+	toc, err := c.GetTOC(epub)
+	if err != nil {
+		t.Fatalf("GetTOC failed: %v", err)
+	}
+	if len(toc) != 2 || toc[1].Title != "Part Two" {
+		t.Fatalf("top-level entries = %+v", toc)
+	}
+	children := toc[0].Children
+	if len(children) != 2 || children[1].Href != "c2.xhtml#x" || children[1].Level != 2 {
+		t.Errorf("Part One children = %+v", children)
+	}
+}
+
+func TestExtractChapter(t *testing.T) {
+	epub := fourChapterEPUB(t)
+	c := &Calibre{Timeout: DefaultTimeout}
+
+	chapter, err := c.ExtractChapter(context.Background(), epub, 2)
+	if err != nil {
+		t.Fatalf("ExtractChapter failed: %v", err)
+	}
+	if chapter.Index != 2 || chapter.Title != "Chapter 3" {
+		t.Errorf("got chapter %d %q, want 2 \"Chapter 3\"", chapter.Index, chapter.Title)
+	}
+	if !strings.Contains(chapter.Content, "Three") || strings.Contains(chapter.Content, "Four") {
+		t.Errorf("chapter content not bounded to chapter 3: %.60q", chapter.Content)
+	}
+
+	for _, index := range []int{4, -1} {
+		if _, err := c.ExtractChapter(context.Background(), epub, index); !errors.Is(err, ErrChapterOutOfRange) {
+			t.Errorf("index %d: expected ErrChapterOutOfRange, got %v", index, err)
+		}
+	}
+}
+
+func TestExtractChapterMatchesExtractChapters(t *testing.T) {
+	// The teaser is too short to be a chapter, so the indexes shift
+	epub := writeTestEPUB(t, map[string]string{
+		"toc.ncx": testNCX(
+			[2]string{"Chapter 1", "ch1.xhtml"},
+			[2]string{"Chapter 2", "ch2.xhtml"},
+			[2]string{"Chapter 3", "ch3.xhtml"},
+			[2]string{"Chapter 4", "ch4.xhtml"},
+			[2]string{"Chapter 5", "ch5.xhtml"},
+		),
+		"ch1.xhtml": testXHTML("<p>" + loremWords("One", 60) + "</p>"),
+		"ch2.xhtml": testXHTML("<p>Coming soon.</p>"),
+		"ch3.xhtml": testXHTML("<p>" + loremWords("Three", 60) + "</p>"),
+		"ch4.xhtml": testXHTML("<p>" + loremWords("Four", 60) + "</p>"),
+		"ch5.xhtml": testXHTML("<p>" + loremWords("Five", 60) + "</p>"),
+	})
+	c := &Calibre{Timeout: DefaultTimeout, ebookConvert: "ebook-convert"}
+
+	all, err := c.ExtractChaptersContext(context.Background(), epub)
+	if err != nil {
+		t.Fatalf("ExtractChaptersContext failed: %v", err)
+	}
+	if len(all) != 4 || all[1].Title != "Chapter 3" {
+		t.Fatalf("ExtractChaptersContext = %d chapters, [1] %q", len(all), all[1].Title)
+	}
+
+	for i := range all {
+		chapter, err := c.ExtractChapter(context.Background(), epub, i)
+		if err != nil {
+			t.Fatalf("ExtractChapter(%d) failed: %v", i, err)
+		}
+		if !reflect.DeepEqual(*chapter, all[i]) {
+			t.Errorf("ExtractChapter(%d) = %q, want %q", i, chapter.Title, all[i].Title)
+		}
+	}
+	if _, err := c.ExtractChapter(context.Background(), epub, len(all)); !errors.Is(err, ErrChapterOutOfRange) {
+		t.Errorf("expected ErrChapterOutOfRange, got %v", err)
+	}
+}
+
+func TestExtractChapterSingleChapterWithoutCalibre(t *testing.T) {
+	epub := writeTestEPUB(t, map[string]string{
+		"toc.ncx":   testNCX([2]string{"Only Chapter", "ch1.xhtml"}),
+		"ch1.xhtml": testXHTML("<p>" + loremWords("One", 60) + "</p>"),
+	})
+
+	// No ebook-convert: a short table of contents is still read natively
+	c := &Calibre{Timeout: DefaultTimeout}
+	chapter, err := c.ExtractChapter(context.Background(), epub, 0)
+	if err != nil {
+		t.Fatalf("ExtractChapter failed: %v", err)
+	}
+	if chapter.Title != "Only Chapter" || !strings.HasPrefix(chapter.Content, "One") {
+		t.Errorf("chapter = %q, %q", chapter.Title, chapter.Summary(20))
+	}
+	if _, err := c.ExtractChapter(context.Background(), epub, 1); !errors.Is(err, ErrChapterOutOfRange) {
+		t.Errorf("expected ErrChapterOutOfRange, got %v", err)
+	}
+}
+
+func TestExtractChaptersDeduplicate(t *testing.T) {
+	epub := writeTestEPUB(t, map[string]string{
+		"toc.ncx": testNCX(
+			[2]string{"Chapter 1", "ch1.xhtml"},
+			[2]string{"Chapter 2", "ch2.xhtml"},
+			[2]string{"Chapter 2 (again)", "ch2.xhtml"},
+			[2]string{"Chapter 3", "ch3.xhtml"},
+		),
+		"ch1.xhtml": testXHTML("<p>" + strings.Repeat("one ", 60) + "</p>"),
+		"ch2.xhtml": testXHTML("<p>" + strings.Repeat("two ", 60) + "</p>"),
+		"ch3.xhtml": testXHTML("<p>" + strings.Repeat("three ", 60) + "</p>"),
+	})
+	c := &Calibre{Timeout: DefaultTimeout, ebookConvert: "ebook-convert"}
+
+	chapters, err := c.ExtractChaptersWithOptions(context.Background(), epub, ChapterOptions{})
+	if err != nil {
+		t.Fatalf("ExtractChapters failed: %v", err)
+	}
+	if len(chapters) != 4 {
+		t.Fatalf("without Deduplicate got %d chapters, want 4", len(chapters))
+	}
+
+	chapters, err = c.ExtractChaptersWithOptions(context.Background(), epub, ChapterOptions{Deduplicate: true})
+	if err != nil {
+		t.Fatalf("ExtractChapters failed: %v", err)
+	}
+	var titles []string
+	for i, ch := range chapters {
+		if ch.Index != i {
+			t.Errorf("chapter %q has index %d, want %d", ch.Title, ch.Index, i)
+		}
+		titles = append(titles, ch.Title)
+	}
+	if got, want := strings.Join(titles, ","), "Chapter 1,Chapter 2,Chapter 3"; got != want {
+		t.Errorf("titles = %q, want %q", got, want)
+	}
+}
+
+func TestNearDuplicate(t *testing.T) {
+	base := strings.Repeat("the same words again ", 50)
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{base, base + "end", true},
+		{"chapter one " + base, "chapter 1 " + base, true},
+		{base, strings.Repeat("entirely different text ", 50), false},
+		{base, base[:len(base)/2], false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		if got := nearDuplicate(tt.a, tt.b); got != tt.want {
+			t.Errorf("nearDuplicate(%.20q, %.20q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestExtractChaptersSkipsGuideFrontMatter(t *testing.T) {
+	body := "<p>" + loremWords("Texte", 60) + "</p>"
+	epub := writeTestEPUB(t, map[string]string{
+		"META-INF/container.xml": testContainer("content.opf"),
+		"content.opf": strings.Replace(testOPF("", "", ""), "</package>",
+			`<guide><reference type="title-page" href="titre.xhtml"/><reference type="toc" href="sommaire.xhtml"/></guide></package>`, 1),
+		"toc.ncx": testNCX(
+			[2]string{"Page de titre de ce livre", "titre.xhtml"},
+			[2]string{"Sommaire de tous les chapitres", "sommaire.xhtml"},
+			[2]string{"Chapitre premier : le départ", "ch1.xhtml"},
+			[2]string{"Chapitre deuxième : le voyage", "ch2.xhtml"},
+			[2]string{"Chapitre troisième : le retour", "ch3.xhtml"},
+		),
+		"titre.xhtml":    testXHTML(body),
+		"sommaire.xhtml": testXHTML(body),
+		"ch1.xhtml":      testXHTML(body),
+		"ch2.xhtml":      testXHTML(body),
+		"ch3.xhtml":      testXHTML(body),
+	})
+	c := &Calibre{Timeout: DefaultTimeout, ebookConvert: "ebook-convert"}
+
+	chapters, err := c.ExtractChaptersWithOptions(context.Background(), epub, ChapterOptions{})
+	if err != nil {
+		t.Fatalf("ExtractChapters failed: %v", err)
+	}
+	for _, ch := range chapters {
+		if !strings.HasPrefix(ch.Title, "Chapitre") {
+			t.Errorf("front matter %q was not skipped", ch.Title)
+		}
+	}
+	if len(chapters) != 3 {
+		t.Errorf("got %d chapters, want 3", len(chapters))
+	}
+}
+
+func TestExtractChaptersPlayOrder(t *testing.T) {
+	navPoint := func(order int, title, src string) string {
+		return fmt.Sprintf(`<navPoint id="np%d" playOrder="%d"><navLabel><text>%s</text></navLabel><content src="%s"/></navPoint>`, order, order, title, src)
+	}
+	ncxDoc := `<?xml version="1.0" encoding="UTF-8"?>
+<ncx xmlns="http://www.daisy.org/z3986/2005/ncx/" version="2005-1"><navMap>` +
+		navPoint(3, "Chapter 3", "ch3.xhtml") +
+		navPoint(1, "Chapter 1", "ch1.xhtml") +
+		navPoint(4, "Chapter 4", "ch4.xhtml") +
+		navPoint(2, "Chapter 2", "ch2.xhtml") +
+		`</navMap></ncx>`
+
+	epub := writeTestEPUB(t, map[string]string{
+		"toc.ncx":   ncxDoc,
+		"ch1.xhtml": testXHTML("<p>" + loremWords("One", 60) + "</p>"),
+		"ch2.xhtml": testXHTML("<p>" + loremWords("Two", 60) + "</p>"),
+		"ch3.xhtml": testXHTML("<p>" + loremWords("Three", 60) + "</p>"),
+		"ch4.xhtml": testXHTML("<p>" + loremWords("Four", 60) + "</p>"),
+	})
+	c := &Calibre{Timeout: DefaultTimeout, ebookConvert: "ebook-convert"}
+
+	chapters, err := c.ExtractChaptersWithOptions(context.Background(), epub, ChapterOptions{})
+	if err != nil {
+		t.Fatalf("ExtractChapters failed: %v", err)
+	}
+	for i, ch := range chapters {
+		if want := fmt.Sprintf("Chapter %d", i+1); ch.Title != want || ch.Index != i {
+			t.Errorf("chapter %d = %d %q, want %q", i, ch.Index, ch.Title, want)
+		}
+		if first := strings.Fields(ch.Content)[0]; first != []string{"One", "Two", "Three", "Four"}[i] {
+			t.Errorf("chapter %d content starts with %q", i, first)
+		}
+	}
+}
+
+func TestTitleCase(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"HOW CANDIDE WAS BROUGHT UP", "How Candide Was Brought Up"},
+		{"war and peace", "War and Peace"},
+		{"the cia and nasa", "The Cia and Nasa"},
+		{"the CIA and NASA", "The CIA and NASA"},
+		{"of mice and men", "Of Mice and Men"},
+		{"what they fought for", "What They Fought For"},
+		{"\"the raven\"", "\"The Raven\""},
+	}
+	for _, tt := range tests {
+		if got := titleCase(tt.in); got != tt.want {
+			t.Errorf("titleCase(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestExtractChaptersFootnotes(t *testing.T) {
+	body := `<h2 id="c1">Chapter 1</h2><p>` + loremWords("Alpha", 60) +
+		`<a epub:type="noteref" href="#fn1">1</a></p>` +
+		`<aside epub:type="footnote" id="fn1"><p>A note on Alpha.</p></aside>` +
+		`<h2 id="c2">Chapter 2</h2><p>` + loremWords("Beta", 60) + `</p>` +
+		`<h2 id="c3">Chapter 3</h2><p>` + loremWords("Gamma", 60) + `</p>`
+
+	epub := writeTestEPUB(t, map[string]string{
+		"toc.ncx": testNCX(
+			[2]string{"Chapter 1", "book.xhtml#c1"},
+			[2]string{"Chapter 2", "book.xhtml#c2"},
+			[2]string{"Chapter 3", "book.xhtml#c3"},
+		),
+		"book.xhtml": testXHTML(body),
+	})
+
+	c := &Calibre{Timeout: DefaultTimeout, ebookConvert: "ebook-convert"}
+	chapters, err := c.ExtractChaptersWithOptions(context.Background(), epub, ChapterOptions{KeepHTML: true})
+	if err != nil {
+		t.Fatalf("ExtractChaptersWithOptions failed: %v", err)
+	}
+
+	want := []models.Footnote{{ID: "fn1", Marker: "1", Text: "A note on Alpha."}}
+	if !reflect.DeepEqual(chapters[0].Footnotes, want) {
+		t.Errorf("chapter 1 Footnotes = %+v, want %+v", chapters[0].Footnotes, want)
+	}
+	if len(chapters[1].Footnotes) != 0 {
+		t.Errorf("chapter 2 should have no footnotes, got %+v", chapters[1].Footnotes)
+	}
+}
+
+func TestExtractChaptersHeadingHeuristic(t *testing.T) {
+	// Headings are styled paragraphs and the NCX only points at the start
+	heading := func(title string) string {
+		return `<p class="chapterHead" style="font-size: 1.6em">` + title + `</p>`
+	}
+	epub := writeTestEPUB(t, map[string]string{
+		"META-INF/container.xml": testContainer("content.opf"),
+		"content.opf": testOPF(
+			`<dc:title>Styled</dc:title>`,
+			`<item id="ncx" href="toc.ncx" media-type="application/x-dtbncx+xml"/>
+<item id="p1" href="part1.xhtml" media-type="application/xhtml+xml"/>
+<item id="p2" href="part2.xhtml" media-type="application/xhtml+xml"/>`,
+			`<itemref idref="p1"/><itemref idref="p2"/>`,
+		),
+		"toc.ncx": testNCX([2]string{"Start", "part1.xhtml"}),
+		"part1.xhtml": testXHTML(`<p>Copyright notice.</p>` +
+			heading("The Storm") + `<p>` + loremWords("One", 60) + `</p>` +
+			heading("The Calm") + `<p>` + loremWords("Two", 30) + `</p>`),
+		"part2.xhtml": testXHTML(`<p>` + loremWords("More", 30) + `</p>` +
+			heading("The Shore") + `<p>` + loremWords("Three", 60) + `</p>`),
+	})
+
+	runner := func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		return nil, errors.New("conversion unavailable")
+	}
+	c := &Calibre{Timeout: DefaultTimeout, Runner: runner, ebookConvert: "ebook-convert"}
+
+	if _, err := c.ExtractChaptersWithOptions(context.Background(), epub, ChapterOptions{}); err == nil {
+		t.Fatal("expected an error without HeadingHeuristic")
+	}
+
+	chapters, err := c.ExtractChaptersWithOptions(context.Background(), epub, ChapterOptions{HeadingHeuristic: true})
+	if err != nil {
+		t.Fatalf("ExtractChaptersWithOptions failed: %v", err)
+	}
+
+	var titles []string
+	for _, ch := range chapters {
+		titles = append(titles, ch.Title)
+	}
+	if want := []string{"The Storm", "The Calm", "The Shore"}; !reflect.DeepEqual(titles, want) {
+		t.Fatalf("titles = %q, want %q", titles, want)
+	}
+
+	// A chapter runs on into the next content file, and front matter is dropped
+	if !strings.Contains(chapters[1].Content, "More") {
+		t.Errorf("chapter 2 should continue into part2.xhtml, got %.60q", chapters[1].Content)
+	}
+	if strings.Contains(chapters[0].Content, "Copyright") {
+		t.Errorf("chapter 1 should not include the front matter, got %.60q", chapters[0].Content)
+	}
+}
+
+func TestExtractChaptersPartial(t *testing.T) {
+	epub := writeTestEPUB(t, map[string]string{
+		"toc.ncx": testNCX(
+			[2]string{"Chapter 1", "ch1.xhtml"},
+			[2]string{"Chapter 2", "missing.xhtml"},
+			[2]string{"Chapter 3", "ch3.xhtml"},
+			[2]string{"Chapter 4", "ch4.xhtml"},
+		),
+		"ch1.xhtml": testXHTML("<p>" + loremWords("One", 60) + "</p>"),
+		"ch3.xhtml": testXHTML("<p>" + loremWords("Three", 60) + "</p>"),
+		"ch4.xhtml": testXHTML("<p>" + loremWords("Four", 60) + "</p>"),
+	})
+	c := &Calibre{Timeout: DefaultTimeout, ebookConvert: "ebook-convert"}
+
+	chapters, skipped, err := c.ExtractChaptersPartial(context.Background(), epub, ChapterOptions{})
+	if err != nil {
+		t.Fatalf("ExtractChaptersPartial failed: %v", err)
+	}
+
+	var titles []string
+	for _, ch := range chapters {
+		titles = append(titles, ch.Title)
+	}
+	if want := []string{"Chapter 1", "Chapter 3", "Chapter 4"}; !reflect.DeepEqual(titles, want) {
+		t.Errorf("titles = %q, want %q", titles, want)
+	}
+
+	if len(skipped) != 1 {
+		t.Fatalf("skipped = %v, want one entry", skipped)
+	}
+	if e := skipped[0]; e.Index != 1 || e.Title != "Chapter 2" || e.Href != "missing.xhtml" || e.Err == nil {
+		t.Errorf("skipped[0] = %+v", e)
+	}
+	if !strings.Contains(skipped[0].Error(), "missing.xhtml") {
+		t.Errorf("Error() = %q, should name the href", skipped[0].Error())
+	}
+
+	// The other Extract methods return the same chapters without the report
+	all, err := c.ExtractChaptersWithOptions(context.Background(), epub, ChapterOptions{})
+	if err != nil {
+		t.Fatalf("ExtractChaptersWithOptions failed: %v", err)
+	}
+	if len(all) != len(chapters) {
+		t.Errorf("ExtractChaptersWithOptions returned %d chapters, want %d", len(all), len(chapters))
+	}
+}
+
+func TestExtractChaptersDebugKeepIntermediate(t *testing.T) {
+	// ebook-convert writes a broken EPUB, so extraction falls back to text
+	text := "Chapter 1\n\n" + loremWords("One", 60) + "\f" + "Chapter 2\n\n" + loremWords("Two", 60)
+	runner := func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		if strings.HasSuffix(args[1], ".txt") {
+			return nil, os.WriteFile(args[1], []byte(text), 0644)
+		}
+		return nil, os.WriteFile(args[1], []byte("not an epub"), 0644)
+	}
+	tmp := t.TempDir()
+	c := &Calibre{Timeout: DefaultTimeout, Runner: runner, ebookConvert: "ebook-convert", TempDir: tmp}
+
+	dir := filepath.Join(t.TempDir(), "debug")
+	opts := ChapterOptions{DebugKeepIntermediate: true, IntermediateDir: dir}
+	chapters, info, err := c.ExtractChaptersDebug(context.Background(), "book.mobi", opts)
+	if err != nil {
+		t.Fatalf("ExtractChaptersDebug failed: %v", err)
+	}
+	if len(chapters) != 2 {
+		t.Errorf("got %d chapters, want 2", len(chapters))
+	}
+
+	want := DebugInfo{Dir: dir, ConvertedEPUB: filepath.Join(dir, "book.epub"), TextFile: filepath.Join(dir, "book.txt")}
+	if *info != want {
+		t.Fatalf("DebugInfo = %+v, want %+v", *info, want)
+	}
+	if data, err := os.ReadFile(info.TextFile); err != nil || string(data) != text {
+		t.Errorf("kept text file = %.30q, %v", data, err)
+	}
+
+	// Off by default: nothing is reported and nothing is left behind
+	_, info, err = c.ExtractChaptersDebug(context.Background(), "book.mobi", ChapterOptions{})
+	if err != nil {
+		t.Fatalf("ExtractChaptersDebug failed: %v", err)
+	}
+	if *info != (DebugInfo{}) {
+		t.Errorf("DebugInfo = %+v without DebugKeepIntermediate, want empty", *info)
+	}
+	if entries, _ := os.ReadDir(tmp); len(entries) != 0 {
+		t.Errorf("temp files left behind: %d entries", len(entries))
+	}
+}
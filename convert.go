@@ -0,0 +1,189 @@
+package calibre
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ConvertOptions configures format conversion
+type ConvertOptions struct {
+	// Page margins in points. Zero leaves Calibre's default.
+	MarginLeft   float64
+	MarginRight  float64
+	MarginTop    float64
+	MarginBottom float64
+
+	// OutputProfile tunes output for a device, e.g. "kindle" or "tablet"
+	OutputProfile string
+
+	// EmbedAllFonts embeds every font referenced by the input
+	EmbedAllFonts bool
+
+	// ExtraArgs are passed to ebook-convert verbatim after the generated
+	// flags, for options ConvertOptions doesn't cover, e.g.
+	// {"--sr1-search", `\s+$`, "--sr1-replace", "", "--filter-css", "font-family"}.
+	// Calibre lets a later flag override an earlier one, so ExtraArgs win
+	// over the typed fields. They must start with a flag: the input and
+	// output paths always come first and can't be given again here.
+	ExtraArgs []string
+
+	// OnProgress, if set, is called with each progress line ebook-convert
+	// prints (e.g. "29% Converting input to HTML..."), as it is printed.
+	// Calls come from the goroutine that called Convert.
+	OnProgress func(percent float64, stage string)
+}
+
+// progressLine matches ebook-convert's progress output, e.g. "29% Converting..."
+var progressLine = regexp.MustCompile(`^\s*(\d+(?:\.\d+)?)%\s*(.*)$`)
+
+// Convert converts an ebook to the format given by outputPath's extension
+func (c *Calibre) Convert(ctx context.Context, inputPath, outputPath string, opts ConvertOptions) error {
+	if c.ebookConvert == "" {
+		return toolNotFound("ebook-convert")
+	}
+
+	if err := checkSupportedFormat(inputPath); err != nil {
+		return err
+	}
+	if filepath.Ext(outputPath) == "" {
+		return fmt.Errorf("output path %q has no extension to infer the format from", outputPath)
+	}
+	if len(opts.ExtraArgs) > 0 && !strings.HasPrefix(opts.ExtraArgs[0], "-") {
+		return fmt.Errorf("extra arguments must start with a flag, not %q", opts.ExtraArgs[0])
+	}
+
+	// Ensure output directory exists
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	args := convertArgs(inputPath, outputPath, opts)
+
+	var err error
+	if opts.OnProgress != nil {
+		_, err = c.runCommandLines(ctx, func(line string) {
+			if percent, stage, ok := parseProgress(line); ok {
+				opts.OnProgress(percent, stage)
+			}
+		}, c.ebookConvert, args...)
+	} else {
+		_, err = c.runCommand(ctx, c.ebookConvert, args...)
+	}
+	if err != nil {
+		return fmt.Errorf("ebook-convert failed: %w", err)
+	}
+
+	return nil
+}
+
+// ConvertStream converts an ebook read from input, such as an HTTP request
+// body, and writes the result to w. The input and output are staged in a
+// temp directory that is removed afterwards, including when ctx is canceled,
+// which also kills ebook-convert.
+func (c *Calibre) ConvertStream(ctx context.Context, input io.Reader, inputFormat, outputFormat string, w io.Writer) error {
+	inputFormat, err := cleanFormat(inputFormat)
+	if err != nil {
+		return err
+	}
+	outputFormat, err = cleanFormat(outputFormat)
+	if err != nil {
+		return err
+	}
+
+	tmpDir, err := c.mkdirTemp("calibre-stream-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	inputPath := filepath.Join(tmpDir, "input."+inputFormat)
+	f, err := os.Create(inputPath)
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	_, err = io.Copy(f, input)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write temp file: %w", err)
+	}
+
+	outputPath := filepath.Join(tmpDir, "output."+outputFormat)
+	if err := c.Convert(ctx, inputPath, outputPath, ConvertOptions{}); err != nil {
+		return err
+	}
+
+	out, err := os.Open(outputPath)
+	if err != nil {
+		return fmt.Errorf("failed to open converted output: %w", err)
+	}
+	defer out.Close()
+
+	if _, err := io.Copy(w, out); err != nil {
+		return fmt.Errorf("failed to write converted output: %w", err)
+	}
+
+	return nil
+}
+
+// convertArgs builds the ebook-convert argument list for a conversion
+func convertArgs(inputPath, outputPath string, opts ConvertOptions) []string {
+	args := []string{inputPath, outputPath}
+
+	margins := []struct {
+		flag  string
+		value float64
+	}{
+		{"--margin-left", opts.MarginLeft},
+		{"--margin-right", opts.MarginRight},
+		{"--margin-top", opts.MarginTop},
+		{"--margin-bottom", opts.MarginBottom},
+	}
+	for _, m := range margins {
+		if m.value != 0 {
+			args = append(args, m.flag, strconv.FormatFloat(m.value, 'f', -1, 64))
+		}
+	}
+
+	if opts.OutputProfile != "" {
+		args = append(args, "--output-profile", opts.OutputProfile)
+	}
+	if opts.EmbedAllFonts {
+		args = append(args, "--embed-all-fonts")
+	}
+
+	return append(args, opts.ExtraArgs...)
+}
+
+// parseProgress extracts the percentage and stage from an ebook-convert
+// progress line
+func parseProgress(line string) (float64, string, bool) {
+	m := progressLine.FindStringSubmatch(line)
+	if m == nil {
+		return 0, "", false
+	}
+	percent, err := strconv.ParseFloat(m[1], 64)
+	if err != nil {
+		return 0, "", false
+	}
+	return percent, strings.TrimSpace(m[2]), true
+}
+
+// checkSupportedFormat returns an error unless the path's extension is one
+// of SupportedFormats
+func checkSupportedFormat(path string) error {
+	ext := strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	for _, f := range SupportedFormats() {
+		if f == ext {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported input format %q for %s", ext, path)
+}
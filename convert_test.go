@@ -0,0 +1,206 @@
+package calibre
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConvertArgs(t *testing.T) {
+	var got []string
+	c := &Calibre{
+		Timeout:      DefaultTimeout,
+		ebookConvert: "/usr/bin/ebook-convert",
+		Runner: func(ctx context.Context, name string, args ...string) ([]byte, error) {
+			got = append([]string{name}, args...)
+			return nil, nil
+		},
+	}
+
+	out := filepath.Join(t.TempDir(), "book.azw3")
+	err := c.Convert(context.Background(), "book.epub", out, ConvertOptions{
+		MarginLeft:    10,
+		OutputProfile: "kindle",
+		EmbedAllFonts: true,
+		ExtraArgs: []string{
+			"--sr1-search", `\s+$`, "--sr1-replace", "",
+			"--filter-css", "font-family,color",
+			"--output-profile", "tablet",
+		},
+	})
+	if err != nil {
+		t.Fatalf("Convert failed: %v", err)
+	}
+
+	want := []string{
+		"/usr/bin/ebook-convert", "book.epub", out,
+		"--margin-left", "10",
+		"--output-profile", "kindle",
+		"--embed-all-fonts",
+		// Extra args follow in order; the later --output-profile wins
+		"--sr1-search", `\s+$`, "--sr1-replace", "",
+		"--filter-css", "font-family,color",
+		"--output-profile", "tablet",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("argv = %q\nwant   %q", got, want)
+	}
+}
+
+func TestConvertValidation(t *testing.T) {
+	c := &Calibre{Timeout: DefaultTimeout}
+	if err := c.Convert(context.Background(), "book.epub", "book.mobi", ConvertOptions{}); err == nil {
+		t.Error("expected an error when ebook-convert is missing")
+	}
+
+	c.ebookConvert = "ebook-convert"
+	c.Runner = func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		t.Fatal("runner should not be called for invalid input")
+		return nil, nil
+	}
+	if err := c.Convert(context.Background(), "book.xyz", "book.mobi", ConvertOptions{}); err == nil {
+		t.Error("expected an error for an unsupported input format")
+	}
+	if err := c.Convert(context.Background(), "book.epub", "book", ConvertOptions{}); err == nil {
+		t.Error("expected an error for an output path without extension")
+	}
+	if err := c.Convert(context.Background(), "book.epub", "book.mobi", ConvertOptions{ExtraArgs: []string{"other.epub", "--title", "X"}}); err == nil {
+		t.Error("expected an error for extra arguments starting with a path")
+	}
+}
+
+func TestConvertFailureIncludesOutput(t *testing.T) {
+	c := &Calibre{
+		Timeout:      DefaultTimeout,
+		ebookConvert: "ebook-convert",
+		Runner: func(ctx context.Context, name string, args ...string) ([]byte, error) {
+			return []byte("Conversion error: DRM protected\n"), errors.New("exit status 1")
+		},
+	}
+
+	err := c.Convert(context.Background(), "book.epub", filepath.Join(t.TempDir(), "out.mobi"), ConvertOptions{})
+	if err == nil || !strings.Contains(err.Error(), "DRM protected") {
+		t.Errorf("expected error with command output, got %v", err)
+	}
+}
+
+func TestConvertOnProgress(t *testing.T) {
+	c := &Calibre{
+		Timeout:      DefaultTimeout,
+		ebookConvert: "ebook-convert",
+		Runner: func(ctx context.Context, name string, args ...string) ([]byte, error) {
+			return []byte("1% Converting input to HTML...\nInput debug line\n50% Running transforms on ebook...\n100% Output saved\n"), nil
+		},
+	}
+
+	var percents []float64
+	var stages []string
+	err := c.Convert(context.Background(), "book.epub", filepath.Join(t.TempDir(), "book.mobi"), ConvertOptions{
+		OnProgress: func(percent float64, stage string) {
+			percents = append(percents, percent)
+			stages = append(stages, stage)
+		},
+	})
+	if err != nil {
+		t.Fatalf("Convert failed: %v", err)
+	}
+
+	if !reflect.DeepEqual(percents, []float64{1, 50, 100}) {
+		t.Errorf("percents = %v", percents)
+	}
+	if stages[1] != "Running transforms on ebook..." {
+		t.Errorf("stages = %q", stages)
+	}
+}
+
+func TestRunCommandLinesStreams(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	c := &Calibre{Timeout: DefaultTimeout}
+
+	var lines []string
+	output, err := c.runCommandLines(context.Background(), func(line string) {
+		lines = append(lines, line)
+	}, "sh", "-c", "echo '10% start'; echo '90% end'")
+	if err != nil {
+		t.Fatalf("runCommandLines failed: %v", err)
+	}
+	if !reflect.DeepEqual(lines, []string{"10% start", "90% end"}) {
+		t.Errorf("lines = %q", lines)
+	}
+	if string(output) != "10% start\n90% end\n" {
+		t.Errorf("output = %q", output)
+	}
+
+	_, err = c.runCommandLines(context.Background(), func(string) {}, "sh", "-c", "echo progress; echo 'bad input' >&2; exit 3")
+	if err == nil || !strings.Contains(err.Error(), "bad input") {
+		t.Errorf("expected the error to include stderr, got %v", err)
+	}
+}
+
+func TestConvertStream(t *testing.T) {
+	var staged string
+	c := &Calibre{
+		Timeout:      DefaultTimeout,
+		ebookConvert: "ebook-convert",
+		Runner: func(ctx context.Context, name string, args ...string) ([]byte, error) {
+			staged = args[0]
+			data, err := os.ReadFile(args[0])
+			if err != nil {
+				return nil, err
+			}
+			return nil, os.WriteFile(args[1], append([]byte("converted:"), data...), 0644)
+		},
+	}
+
+	var out bytes.Buffer
+	err := c.ConvertStream(context.Background(), strings.NewReader("book bytes"), ".EPUB", "mobi", &out)
+	if err != nil {
+		t.Fatalf("ConvertStream failed: %v", err)
+	}
+	if out.String() != "converted:book bytes" {
+		t.Errorf("output = %q", out.String())
+	}
+	if filepath.Ext(staged) != ".epub" {
+		t.Errorf("input staged as %q, want a .epub file", staged)
+	}
+	if _, err := os.Stat(filepath.Dir(staged)); !os.IsNotExist(err) {
+		t.Errorf("temp dir %s was not removed", filepath.Dir(staged))
+	}
+
+	if err := c.ConvertStream(context.Background(), strings.NewReader(""), "../epub", "mobi", &out); err == nil {
+		t.Error("expected an error for a path-like format")
+	}
+}
+
+func TestConvertStreamCanceled(t *testing.T) {
+	var staged string
+	c := &Calibre{
+		Timeout:      DefaultTimeout,
+		ebookConvert: "ebook-convert",
+		Runner: func(ctx context.Context, name string, args ...string) ([]byte, error) {
+			staged = args[0]
+			<-ctx.Done()
+			return nil, ctx.Err()
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	var out bytes.Buffer
+	if err := c.ConvertStream(ctx, strings.NewReader("book"), "epub", "mobi", &out); err == nil {
+		t.Fatal("expected an error after cancel")
+	}
+	if _, err := os.Stat(filepath.Dir(staged)); !os.IsNotExist(err) {
+		t.Errorf("temp dir %s was not removed after cancel", filepath.Dir(staged))
+	}
+}
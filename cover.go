@@ -0,0 +1,223 @@
+package calibre
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/anilpdv/go-calibre/opf"
+)
+
+// ExtractCoverData extracts the cover image into memory and returns it along
+// with its MIME type, sniffed from the image bytes. Books without a cover
+// return ErrNoCover.
+func (c *Calibre) ExtractCoverData(ctx context.Context, ebookPath string) ([]byte, string, error) {
+	tmpDir, err := c.mkdirTemp("calibre-cover-*")
+	if err != nil {
+		return nil, "", err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	coverPath := filepath.Join(tmpDir, "cover.jpg")
+	if err := c.ExtractCoverContext(ctx, ebookPath, coverPath); err != nil {
+		return nil, "", err
+	}
+
+	data, err := os.ReadFile(coverPath)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read cover: %w", err)
+	}
+
+	return data, http.DetectContentType(data), nil
+}
+
+// imageExtFormats maps output file extensions to image formats
+var imageExtFormats = map[string]string{
+	".jpg":  "jpeg",
+	".jpeg": "jpeg",
+	".png":  "png",
+	".gif":  "gif",
+	".webp": "webp",
+	".bmp":  "bmp",
+}
+
+// ExtractCoverWithFormat extracts the cover like ExtractCoverContext and
+// returns its image format ("jpeg", "png", ...), sniffed from the bytes.
+// If outputPath has a .jpg or .png extension the cover is transcoded to
+// match it. Other mismatches leave the file as extracted and return its
+// actual format with an error wrapping ErrCoverFormatMismatch.
+func (c *Calibre) ExtractCoverWithFormat(ctx context.Context, ebookPath, outputPath string) (string, error) {
+	if err := c.ExtractCoverContext(ctx, ebookPath, outputPath); err != nil {
+		return "", err
+	}
+
+	data, err := os.ReadFile(outputPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read cover: %w", err)
+	}
+
+	format := strings.TrimPrefix(http.DetectContentType(data), "image/")
+	want, ok := imageExtFormats[strings.ToLower(filepath.Ext(outputPath))]
+	if !ok || want == format {
+		return format, nil
+	}
+
+	if want == "jpeg" || want == "png" {
+		if img, _, err := image.Decode(bytes.NewReader(data)); err == nil {
+			out, err := encodeImage(img, want)
+			if err != nil {
+				return "", fmt.Errorf("failed to encode cover: %w", err)
+			}
+			if err := os.WriteFile(outputPath, out, 0644); err != nil {
+				return "", fmt.Errorf("failed to write cover: %w", err)
+			}
+			return want, nil
+		}
+	}
+
+	return format, fmt.Errorf("%w: %s contains %s data", ErrCoverFormatMismatch, filepath.Base(outputPath), format)
+}
+
+// SetCover embeds coverPath as the book's new cover using ebook-meta. The
+// cover must be an image file; its type is sniffed from the content. For
+// EPUBs the cover is read back afterwards to confirm it was embedded.
+func (c *Calibre) SetCover(ctx context.Context, ebookPath, coverPath string) error {
+	if c.ebookMeta == "" {
+		return toolNotFound("ebook-meta")
+	}
+
+	f, err := os.Open(coverPath)
+	if err != nil {
+		return fmt.Errorf("failed to open cover: %w", err)
+	}
+	head := make([]byte, 512)
+	n, err := io.ReadFull(f, head)
+	f.Close()
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return fmt.Errorf("failed to read cover: %w", err)
+	}
+	if mimeType := http.DetectContentType(head[:n]); !strings.HasPrefix(mimeType, "image/") {
+		return fmt.Errorf("cover %s is not an image (detected %s)", filepath.Base(coverPath), mimeType)
+	}
+
+	if _, err := c.runCommand(ctx, c.ebookMeta, ebookPath, "--cover", coverPath); err != nil {
+		return fmt.Errorf("failed to set cover: %w", err)
+	}
+
+	if isEPUB(ebookPath) {
+		if _, _, err := c.ExtractCoverData(ctx, ebookPath); err != nil {
+			return fmt.Errorf("cover was not embedded in %s: %w", filepath.Base(ebookPath), err)
+		}
+	}
+
+	return nil
+}
+
+// CoverInfo reports whether an EPUB declares a cover image that is present in
+// the archive, and its declared media type. Only the OPF is read; the image
+// itself is not decoded. A book without a declared cover returns false, "", nil.
+func CoverInfo(epubPath string) (exists bool, mimeType string, err error) {
+	r, err := zip.OpenReader(epubPath)
+	if err != nil {
+		return false, "", fmt.Errorf("failed to open EPUB: %w", err)
+	}
+	defer r.Close()
+
+	pkg, opfPath, err := opf.ReadPackage(&r.Reader)
+	if err != nil {
+		return false, "", err
+	}
+
+	item := pkg.CoverItem()
+	if item == nil {
+		return false, "", nil
+	}
+
+	name := opf.ResolveHref(opfPath, opf.UnescapeHref(item.Href))
+	for _, f := range r.File {
+		if f.Name == name {
+			return true, item.MediaType, nil
+		}
+	}
+
+	return false, item.MediaType, nil
+}
+
+// coverPageImageRe finds the image in a cover page: an <img src> or an SVG
+// <image xlink:href>
+var coverPageImageRe = regexp.MustCompile(`(?is)<(?:img\b[^>]*?\bsrc|image\b[^>]*?\b(?:xlink:)?href)\s*=\s*["']([^"']+)["']`)
+
+// ExtractCoverNative copies an EPUB's cover image out of the archive without
+// Calibre. The cover is the manifest item CoverItem finds, or else the
+// guide's cover reference: either the image itself or a cover page whose
+// first image is used. Books without a cover return ErrNoCover.
+func ExtractCoverNative(epubPath, outputPath string) error {
+	r, err := zip.OpenReader(epubPath)
+	if err != nil {
+		return fmt.Errorf("failed to open EPUB: %w", err)
+	}
+	defer r.Close()
+
+	pkg, opfPath, err := opf.ReadPackage(&r.Reader)
+	if err != nil {
+		return err
+	}
+
+	name, err := coverImagePath(&r.Reader, pkg, opfPath)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range r.File {
+		if f.Name == name {
+			return extractZipFile(f, outputPath)
+		}
+	}
+	return fmt.Errorf("%w: %s is not in the archive", ErrNoCover, name)
+}
+
+// coverImagePath returns the path inside the zip of an EPUB's cover image
+func coverImagePath(r *zip.Reader, pkg *opf.Package, opfPath string) (string, error) {
+	if item := pkg.CoverItem(); item != nil {
+		return opf.ResolveHref(opfPath, opf.UnescapeHref(item.Href)), nil
+	}
+
+	href, ok := pkg.GuideMap()["cover"]
+	if !ok {
+		return "", fmt.Errorf("%w: no cover declared in the OPF", ErrNoCover)
+	}
+	href, _, _ = strings.Cut(href, "#")
+	for _, item := range pkg.Manifest.Items {
+		if item.Href == href && strings.HasPrefix(item.MediaType, "image/") {
+			return opf.ResolveHref(opfPath, opf.UnescapeHref(href)), nil
+		}
+	}
+
+	// The guide points at a cover page; use the image it shows
+	page := opf.ResolveHref(opfPath, opf.UnescapeHref(href))
+	for _, f := range r.File {
+		if f.Name != page {
+			continue
+		}
+		data, err := readZipFile(f)
+		if err != nil {
+			return "", err
+		}
+		m := coverPageImageRe.FindSubmatch(data)
+		if m == nil {
+			return "", fmt.Errorf("%w: cover page %s has no image", ErrNoCover, page)
+		}
+		return path.Join(path.Dir(page), opf.UnescapeHref(string(m[1]))), nil
+	}
+
+	return "", fmt.Errorf("%w: cover page %s is not in the archive", ErrNoCover, page)
+}
@@ -0,0 +1,295 @@
+package calibre
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCoverInfo(t *testing.T) {
+	epub := writeTestEPUB(t, map[string]string{
+		"META-INF/container.xml": testContainer("OEBPS/content.opf"),
+		"OEBPS/content.opf": testOPF(
+			`<dc:title>Cover Test</dc:title><meta name="cover" content="cover-img"/>`,
+			`<item id="cover-img" href="images/cover.png" media-type="image/png"/>`,
+			"",
+		),
+		"OEBPS/images/cover.png": "\x89PNG\r\n\x1a\n",
+	})
+
+	exists, mimeType, err := CoverInfo(epub)
+	if err != nil {
+		t.Fatalf("CoverInfo failed: %v", err)
+	}
+	if !exists {
+		t.Error("cover should exist")
+	}
+	if mimeType != "image/png" {
+		t.Errorf("mimeType = %q, want image/png", mimeType)
+	}
+}
+
+func TestCoverInfoNoCover(t *testing.T) {
+	epub := writeTestEPUB(t, map[string]string{
+		"META-INF/container.xml": testContainer("content.opf"),
+		"content.opf":            testOPF(`<dc:title>No Cover</dc:title>`, `<item id="ch1" href="ch1.xhtml" media-type="application/xhtml+xml"/>`, `<itemref idref="ch1"/>`),
+	})
+
+	exists, mimeType, err := CoverInfo(epub)
+	if err != nil {
+		t.Fatalf("CoverInfo failed: %v", err)
+	}
+	if exists || mimeType != "" {
+		t.Errorf("expected no cover, got exists=%v mimeType=%q", exists, mimeType)
+	}
+}
+
+func TestExtractCoverNative(t *testing.T) {
+	png := "\x89PNG\r\n\x1a\ncover-bytes"
+	epub := writeTestEPUB(t, map[string]string{
+		"META-INF/container.xml": testContainer("OEBPS/content.opf"),
+		"OEBPS/content.opf": testOPF(
+			`<dc:title>Cover Test</dc:title><meta name="cover" content="cover-img"/>`,
+			`<item id="cover-img" href="images/cover.png" media-type="image/png"/>`,
+			"",
+		),
+		"OEBPS/images/cover.png": png,
+	})
+	out := filepath.Join(t.TempDir(), "covers", "cover.png")
+
+	if err := ExtractCoverNative(epub, out); err != nil {
+		t.Fatalf("ExtractCoverNative failed: %v", err)
+	}
+	if data, _ := os.ReadFile(out); string(data) != png {
+		t.Errorf("cover = %q, want %q", data, png)
+	}
+
+	// ExtractCoverContext uses it when ebook-meta is missing
+	c := &Calibre{Timeout: DefaultTimeout}
+	out = filepath.Join(t.TempDir(), "cover.png")
+	if err := c.ExtractCoverContext(context.Background(), epub, out); err != nil {
+		t.Fatalf("ExtractCoverContext failed: %v", err)
+	}
+	if data, _ := os.ReadFile(out); string(data) != png {
+		t.Errorf("cover = %q, want %q", data, png)
+	}
+}
+
+func TestExtractCoverNativeEscapedHref(t *testing.T) {
+	png := "\x89PNG\r\n\x1a\nescaped-cover"
+	epub := writeTestEPUB(t, map[string]string{
+		"META-INF/container.xml": testContainer("OEBPS/content.opf"),
+		"OEBPS/content.opf": testOPF(
+			`<dc:title>Escaped</dc:title><meta name="cover" content="cover-img"/>`,
+			`<item id="cover-img" href="images/front%20cover.png" media-type="image/png"/>`,
+			"",
+		),
+		"OEBPS/images/front cover.png": png,
+	})
+	out := filepath.Join(t.TempDir(), "cover.png")
+
+	if err := ExtractCoverNative(epub, out); err != nil {
+		t.Fatalf("ExtractCoverNative failed: %v", err)
+	}
+	if data, _ := os.ReadFile(out); string(data) != png {
+		t.Errorf("cover = %q, want %q", data, png)
+	}
+}
+
+func TestExtractCoverNativeGuide(t *testing.T) {
+	jpeg := "\xff\xd8\xff\xe0guide-cover"
+	epub := writeTestEPUB(t, map[string]string{
+		"META-INF/container.xml": testContainer("OEBPS/content.opf"),
+		"OEBPS/content.opf": `<?xml version="1.0"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="2.0">
+<metadata xmlns:dc="http://purl.org/dc/elements/1.1/"><dc:title>Guide Cover</dc:title></metadata>
+<manifest>
+<item id="titlepage" href="text/titlepage.xhtml" media-type="application/xhtml+xml"/>
+<item id="img" href="images/front%20cover.jpg" media-type="image/jpeg"/>
+</manifest>
+<spine><itemref idref="titlepage"/></spine>
+<guide><reference type="cover" title="Cover" href="text/titlepage.xhtml"/></guide>
+</package>`,
+		"OEBPS/text/titlepage.xhtml": `<html><body><svg xmlns:xlink="http://www.w3.org/1999/xlink">` +
+			`<image xlink:href="../images/front%20cover.jpg"/></svg></body></html>`,
+		"OEBPS/images/front cover.jpg": jpeg,
+	})
+	out := filepath.Join(t.TempDir(), "cover.jpg")
+
+	if err := ExtractCoverNative(epub, out); err != nil {
+		t.Fatalf("ExtractCoverNative failed: %v", err)
+	}
+	if data, _ := os.ReadFile(out); string(data) != jpeg {
+		t.Errorf("cover = %q, want %q", data, jpeg)
+	}
+
+	noCover := writeTestEPUB(t, map[string]string{
+		"META-INF/container.xml": testContainer("content.opf"),
+		"content.opf":            testOPF(`<dc:title>No Cover</dc:title>`, "", ""),
+	})
+	if err := ExtractCoverNative(noCover, out); !errors.Is(err, ErrNoCover) {
+		t.Errorf("expected ErrNoCover, got %v", err)
+	}
+}
+
+// coverRunner fakes ebook-meta: --get-cover writes cover (if non-nil) and
+// --to-opf writes a minimal OPF
+func coverRunner(cover []byte) CommandRunner {
+	return func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		for i, arg := range args {
+			switch {
+			case arg == "--get-cover" && cover != nil:
+				return nil, os.WriteFile(args[i+1], cover, 0644)
+			case arg == "--to-opf":
+				opfXML := testOPF(`<dc:title>Covered</dc:title>`, "", "")
+				return nil, os.WriteFile(args[i+1], []byte(opfXML), 0644)
+			}
+		}
+		return nil, nil
+	}
+}
+
+func TestExtractCoverData(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	c := &Calibre{Timeout: DefaultTimeout, ebookMeta: "ebook-meta", Runner: coverRunner(png)}
+
+	data, mimeType, err := c.ExtractCoverData(context.Background(), "book.epub")
+	if err != nil {
+		t.Fatalf("ExtractCoverData failed: %v", err)
+	}
+	if !bytes.Equal(data, png) {
+		t.Errorf("data = %q", data)
+	}
+	if mimeType != "image/png" {
+		t.Errorf("mimeType = %q", mimeType)
+	}
+}
+
+func TestExtractCoverDataNoCover(t *testing.T) {
+	c := &Calibre{Timeout: DefaultTimeout, ebookMeta: "ebook-meta", Runner: coverRunner(nil)}
+
+	_, _, err := c.ExtractCoverData(context.Background(), "book.epub")
+	if !errors.Is(err, ErrNoCover) {
+		t.Errorf("expected ErrNoCover, got %v", err)
+	}
+}
+
+func TestGetBookIncludeCover(t *testing.T) {
+	jpeg := []byte("\xff\xd8\xff\xe0\x00\x10JFIF")
+	c := &Calibre{Timeout: DefaultTimeout, ebookMeta: "ebook-meta", Runner: coverRunner(jpeg)}
+
+	book, err := c.GetBookWithOptions(context.Background(), "book.epub", BookOptions{IncludeCover: true})
+	if err != nil {
+		t.Fatalf("GetBookWithOptions failed: %v", err)
+	}
+	if !bytes.Equal(book.CoverData, jpeg) {
+		t.Errorf("CoverData = %q", book.CoverData)
+	}
+
+	book, err = c.GetBookContext(context.Background(), "book.epub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if book.CoverData != nil {
+		t.Error("CoverData should not be loaded by default")
+	}
+}
+
+func TestSetCover(t *testing.T) {
+	dir := t.TempDir()
+	coverPath := filepath.Join(dir, "new.png")
+	if err := os.WriteFile(coverPath, []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var embedded string
+	getCover := coverRunner([]byte("\x89PNG\r\n\x1a\n"))
+	c := &Calibre{
+		Timeout:   DefaultTimeout,
+		ebookMeta: "ebook-meta",
+		Runner: func(ctx context.Context, name string, args ...string) ([]byte, error) {
+			if len(args) == 3 && args[1] == "--cover" {
+				embedded = args[2]
+				return nil, nil
+			}
+			return getCover(ctx, name, args...)
+		},
+	}
+
+	if err := c.SetCover(context.Background(), "book.epub", coverPath); err != nil {
+		t.Fatalf("SetCover failed: %v", err)
+	}
+	if embedded != coverPath {
+		t.Errorf("ebook-meta --cover got %q, want %q", embedded, coverPath)
+	}
+
+	noTool := &Calibre{Timeout: DefaultTimeout}
+	if err := noTool.SetCover(context.Background(), "book.epub", coverPath); !errors.Is(err, ErrToolNotFound) {
+		t.Errorf("without ebook-meta: expected ErrToolNotFound, got %v", err)
+	}
+
+	// The cover didn't stick
+	getCover = coverRunner(nil)
+	if err := c.SetCover(context.Background(), "book.epub", coverPath); !errors.Is(err, ErrNoCover) {
+		t.Errorf("expected ErrNoCover when the cover can't be read back, got %v", err)
+	}
+}
+
+func TestSetCoverRejectsNonImage(t *testing.T) {
+	ran := false
+	c := &Calibre{
+		Timeout:   DefaultTimeout,
+		ebookMeta: "ebook-meta",
+		Runner: func(ctx context.Context, name string, args ...string) ([]byte, error) {
+			ran = true
+			return nil, nil
+		},
+	}
+
+	notImage := filepath.Join(t.TempDir(), "cover.jpg")
+	os.WriteFile(notImage, []byte("<html>not a picture</html>"), 0644)
+
+	if err := c.SetCover(context.Background(), "book.epub", notImage); err == nil {
+		t.Error("expected an error for a non-image cover")
+	}
+	if err := c.SetCover(context.Background(), "book.epub", filepath.Join(t.TempDir(), "missing.png")); err == nil {
+		t.Error("expected an error for a missing cover")
+	}
+	if ran {
+		t.Error("ebook-meta should not run for an invalid cover")
+	}
+}
+
+func TestExtractCoverWithFormat(t *testing.T) {
+	png := testPNG(t, 4, 4)
+	c := &Calibre{Timeout: DefaultTimeout, ebookMeta: "ebook-meta", Runner: coverRunner(png)}
+	dir := t.TempDir()
+
+	format, err := c.ExtractCoverWithFormat(context.Background(), "book.epub", filepath.Join(dir, "cover.png"))
+	if err != nil || format != "png" {
+		t.Errorf("matching extension: got %q, %v", format, err)
+	}
+
+	// PNG bytes for a .jpg path are transcoded
+	jpgPath := filepath.Join(dir, "cover.jpg")
+	format, err = c.ExtractCoverWithFormat(context.Background(), "book.epub", jpgPath)
+	if err != nil || format != "jpeg" {
+		t.Errorf("transcoded: got %q, %v", format, err)
+	}
+	data, err := os.ReadFile(jpgPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(data, []byte("\xff\xd8\xff")) {
+		t.Errorf("cover.jpg does not contain JPEG data: %q", data[:8])
+	}
+
+	// No encoder for the extension: the mismatch is reported
+	format, err = c.ExtractCoverWithFormat(context.Background(), "book.epub", filepath.Join(dir, "cover.gif"))
+	if !errors.Is(err, ErrCoverFormatMismatch) || format != "png" {
+		t.Errorf("mismatch: got %q, %v", format, err)
+	}
+}
@@ -0,0 +1,64 @@
+package calibre
+
+import (
+	"hash/fnv"
+	"strings"
+
+	"github.com/anilpdv/go-calibre/models"
+)
+
+// duplicateSimilarity is how much of two chapters' text must match for the
+// later one to count as a duplicate
+const duplicateSimilarity = 0.95
+
+// dedupChapters wraps emit to drop chapters whose text is identical or
+// nearly identical to an earlier chapter's, renumbering the ones kept
+func dedupChapters(emit func(models.Chapter) error) func(models.Chapter) error {
+	seen := map[uint64]bool{}
+	var kept []string
+
+	return func(chapter models.Chapter) error {
+		text := strings.ToLower(strings.Join(strings.Fields(chapter.Content), " "))
+
+		h := fnv.New64a()
+		h.Write([]byte(text))
+		sum := h.Sum64()
+		if seen[sum] {
+			return nil
+		}
+		for _, prev := range kept {
+			if nearDuplicate(prev, text) {
+				return nil
+			}
+		}
+
+		seen[sum] = true
+		kept = append(kept, text)
+		chapter.Index = len(kept) - 1
+		return emit(chapter)
+	}
+}
+
+// nearDuplicate reports whether a and b differ in at most 5% of their length.
+// Only their common prefix and suffix are compared, which is cheap and
+// catches the usual case of the same text with a changed heading or anchor.
+func nearDuplicate(a, b string) bool {
+	shorter, longer := len(a), len(b)
+	if shorter > longer {
+		shorter, longer = longer, shorter
+	}
+	if longer == 0 || float64(shorter) < duplicateSimilarity*float64(longer) {
+		return false
+	}
+
+	prefix := 0
+	for prefix < shorter && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < shorter-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	return float64(prefix+suffix) >= duplicateSimilarity*float64(longer)
+}
@@ -0,0 +1,56 @@
+package calibre
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/anilpdv/go-calibre/models"
+)
+
+// ExtractDialogue finds quoted passages in chapter text. Both straight (")
+// and curly (“ ”) double quotes are recognized; single quotes nested inside a
+// passage are kept as part of it. A quote left open is closed at the end of
+// its paragraph, which also matches the convention of multi-paragraph speech.
+func ExtractDialogue(chapterText string) []models.DialogueLine {
+	var lines []models.DialogueLine
+
+	open := -1 // byte offset of the current opening quote
+	emit := func(end, closeLen int) {
+		_, openLen := utf8.DecodeRuneInString(chapterText[open:])
+		text := strings.TrimSpace(chapterText[open+openLen : end])
+		if text != "" {
+			lines = append(lines, models.DialogueLine{Text: text, Start: open, End: end + closeLen})
+		}
+		open = -1
+	}
+
+	prevNewline := false
+	for i, r := range chapterText {
+		switch {
+		case r == '\n':
+			// A blank line ends the paragraph and any unterminated quote
+			if prevNewline && open != -1 {
+				emit(i, 0)
+			}
+			prevNewline = true
+			continue
+		case open == -1 && (r == '"' || r == '“'):
+			open = i
+		case open != -1 && (r == '"' || r == '”'):
+			emit(i, utf8.RuneLen(r))
+		case open != -1 && r == '“':
+			// A new opening quote before the old one closed
+			emit(i, 0)
+			open = i
+		}
+		if r != ' ' && r != '\t' && r != '\r' {
+			prevNewline = false
+		}
+	}
+
+	if open != -1 {
+		emit(len(chapterText), 0)
+	}
+
+	return lines
+}
@@ -0,0 +1,42 @@
+package calibre
+
+import "testing"
+
+func TestExtractDialogue(t *testing.T) {
+	text := "The rain had not stopped. \"Where are you going?\" she asked.\n\n" +
+		"He shrugged. “Out, to see ‘the old man’ again.” Then he left.\n\n" +
+		"“I will not wait forever,\n\nShe called after him.\n\n" +
+		"Nobody answered. A stray ” mark means nothing."
+
+	lines := ExtractDialogue(text)
+
+	want := []string{
+		"Where are you going?",
+		"Out, to see ‘the old man’ again.",
+		"I will not wait forever,",
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("expected %d lines, got %d: %+v", len(want), len(lines), lines)
+	}
+	for i, line := range lines {
+		if line.Text != want[i] {
+			t.Errorf("line %d = %q, want %q", i, line.Text, want[i])
+		}
+		if line.Start < 0 || line.End > len(text) || line.Start >= line.End {
+			t.Errorf("line %d has bad position %d-%d", i, line.Start, line.End)
+		}
+	}
+
+	if got := text[lines[0].Start:lines[0].End]; got != "\"Where are you going?\"" {
+		t.Errorf("line 0 span = %q", got)
+	}
+	if got := text[lines[1].Start:lines[1].End]; got != "“Out, to see ‘the old man’ again.”" {
+		t.Errorf("line 1 span = %q", got)
+	}
+}
+
+func TestExtractDialogueNoQuotes(t *testing.T) {
+	if lines := ExtractDialogue("Just narration here."); len(lines) != 0 {
+		t.Errorf("expected no dialogue, got %+v", lines)
+	}
+}
@@ -0,0 +1,140 @@
+package calibre
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// fontObfuscationAlgorithms are encryption.xml algorithms used only to
+// obfuscate embedded fonts, which is not DRM
+var fontObfuscationAlgorithms = map[string]bool{
+	"http://www.idpf.org/2008/embedding": true,
+	"http://ns.adobe.com/pdf/enc#RC":     true,
+}
+
+// DetectDRM reports whether an ebook is DRM protected by looking for known
+// markers, without running Calibre. EPUBs are checked for Adobe ADEPT and
+// Apple FairPlay files and for encrypted content in META-INF/encryption.xml;
+// Mobipocket files (MOBI, AZW, AZW3, PRC) for the encryption flag in their
+// header; and KFX files for the DRMION container. Other formats return false.
+func DetectDRM(ebookPath string) (bool, error) {
+	switch strings.ToLower(filepath.Ext(ebookPath)) {
+	case ".epub", ".kepub":
+		return detectEPUBDRM(ebookPath)
+	case ".mobi", ".azw", ".azw3", ".azw4", ".prc", ".kfx":
+		return detectMobiDRM(ebookPath)
+	}
+	return false, nil
+}
+
+// checkDRM returns ErrDRMProtected if the book is DRM protected. Detection
+// failures are ignored so the Calibre tools can report them instead.
+func checkDRM(ebookPath string) error {
+	if drm, err := DetectDRM(ebookPath); err == nil && drm {
+		return fmt.Errorf("%w: %s", ErrDRMProtected, filepath.Base(ebookPath))
+	}
+	return nil
+}
+
+// encryptionDoc is the subset of META-INF/encryption.xml needed for DRM and
+// font obfuscation detection
+type encryptionDoc struct {
+	EncryptedData []struct {
+		Method struct {
+			Algorithm string `xml:"Algorithm,attr"`
+		} `xml:"EncryptionMethod"`
+		CipherReference struct {
+			URI string `xml:"URI,attr"`
+		} `xml:"CipherData>CipherReference"`
+	} `xml:"EncryptedData"`
+}
+
+// detectEPUBDRM checks an EPUB's META-INF directory for DRM markers
+func detectEPUBDRM(epubPath string) (bool, error) {
+	r, err := zip.OpenReader(epubPath)
+	if err != nil {
+		return false, fmt.Errorf("failed to open EPUB: %w", err)
+	}
+	defer r.Close()
+
+	for _, f := range r.File {
+		switch f.Name {
+		case "META-INF/rights.xml", "META-INF/sinf.xml":
+			// Adobe ADEPT and Apple FairPlay licenses
+			return true, nil
+		case "META-INF/encryption.xml":
+			rc, err := f.Open()
+			if err != nil {
+				return false, fmt.Errorf("failed to open encryption.xml: %w", err)
+			}
+			var doc encryptionDoc
+			err = xml.NewDecoder(rc).Decode(&doc)
+			rc.Close()
+			if err != nil {
+				return false, fmt.Errorf("%w encryption.xml: %w", ErrParse, err)
+			}
+
+			// Encrypted resources other than obfuscated fonts mean DRM
+			for _, data := range doc.EncryptedData {
+				if !fontObfuscationAlgorithms[data.Method.Algorithm] {
+					return true, nil
+				}
+			}
+		}
+	}
+
+	return false, nil
+}
+
+// kfxDRMMagic starts a DRM-wrapped KFX container
+var kfxDRMMagic = []byte("\xeaDRMION\xee")
+
+// detectMobiDRM reads the Palm database header of a Mobipocket file and
+// checks the encryption type in its first record
+func detectMobiDRM(path string) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer f.Close()
+
+	header := make([]byte, 86)
+	if _, err := io.ReadFull(f, header); err != nil {
+		// Too short to be a Palm database
+		return false, nil
+	}
+	if bytes.HasPrefix(header, kfxDRMMagic) {
+		return true, nil
+	}
+
+	// Anything else that isn't a Mobipocket Palm database, such as a plain
+	// KFX container, has no header to check
+	switch string(header[60:68]) {
+	case "BOOKMOBI", "TEXtREAd":
+	default:
+		return false, nil
+	}
+
+	// Palm database: the record list starts at byte 78, with the record
+	// count at 76 and the first record's offset at 78
+	if binary.BigEndian.Uint16(header[76:78]) == 0 {
+		return false, nil
+	}
+	record0 := int64(binary.BigEndian.Uint32(header[78:82]))
+
+	// PalmDOC header: encryption type is a 2-byte field at offset 12
+	enc := make([]byte, 2)
+	if _, err := f.ReadAt(enc, record0+12); err != nil {
+		return false, nil
+	}
+
+	// 1 is old Mobipocket encryption, 2 is Mobipocket DRM
+	return binary.BigEndian.Uint16(enc) != 0, nil
+}
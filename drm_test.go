@@ -0,0 +1,114 @@
+package calibre
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func testEncryptionXML(algorithm string) string {
+	return `<?xml version="1.0"?>
+<encryption xmlns="urn:oasis:names:tc:opendocument:xmlns:container" xmlns:enc="http://www.w3.org/2001/04/xmlenc#">
+<enc:EncryptedData><enc:EncryptionMethod Algorithm="` + algorithm + `"/>
+<enc:CipherData><enc:CipherReference URI="OEBPS/text/ch1.xhtml"/></enc:CipherData></enc:EncryptedData>
+</encryption>`
+}
+
+func TestDetectDRMEPUB(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  bool
+	}{
+		{"plain", map[string]string{}, false},
+		{"font obfuscation", map[string]string{
+			"META-INF/encryption.xml": testEncryptionXML("http://www.idpf.org/2008/embedding"),
+		}, false},
+		{"encrypted content", map[string]string{
+			"META-INF/encryption.xml": testEncryptionXML("http://www.w3.org/2001/04/xmlenc#aes128-cbc"),
+		}, true},
+		{"adobe rights", map[string]string{"META-INF/rights.xml": "<rights/>"}, true},
+		{"fairplay", map[string]string{"META-INF/sinf.xml": "<fairplay/>"}, true},
+	}
+
+	for _, tt := range tests {
+		files := map[string]string{"META-INF/container.xml": testContainer("content.opf")}
+		for name, data := range tt.files {
+			files[name] = data
+		}
+		got, err := DetectDRM(writeTestEPUB(t, files))
+		if err != nil {
+			t.Errorf("%s: DetectDRM failed: %v", tt.name, err)
+		} else if got != tt.want {
+			t.Errorf("%s: DetectDRM = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// writeTestMobi writes a minimal Palm database whose first record carries
+// the given PalmDOC encryption type
+func writeTestMobi(t *testing.T, name string, encryption uint16) string {
+	t.Helper()
+	data := make([]byte, 96+16)
+	copy(data, "Test Book")
+	copy(data[60:], "BOOKMOBI")
+	binary.BigEndian.PutUint16(data[76:], 1)  // record count
+	binary.BigEndian.PutUint32(data[78:], 96) // first record offset
+	binary.BigEndian.PutUint16(data[96+12:], encryption)
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestDetectDRMMobi(t *testing.T) {
+	if drm, err := DetectDRM(writeTestMobi(t, "plain.azw3", 0)); err != nil || drm {
+		t.Errorf("unencrypted AZW3: DetectDRM = %v, %v", drm, err)
+	}
+	if drm, err := DetectDRM(writeTestMobi(t, "locked.azw", 2)); err != nil || !drm {
+		t.Errorf("encrypted AZW: DetectDRM = %v, %v", drm, err)
+	}
+	// A KFX container that happens to have bytes where a Palm header would
+	// mark encryption isn't a Palm database at all
+	kfx := make([]byte, 96+16)
+	copy(kfx, "CONT")
+	binary.BigEndian.PutUint16(kfx[76:], 1)
+	binary.BigEndian.PutUint32(kfx[78:], 96)
+	binary.BigEndian.PutUint16(kfx[96+12:], 2)
+	kfxPath := filepath.Join(t.TempDir(), "book.kfx")
+	if err := os.WriteFile(kfxPath, kfx, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if drm, err := DetectDRM(kfxPath); err != nil || drm {
+		t.Errorf("plain KFX: DetectDRM = %v, %v", drm, err)
+	}
+
+	if drm, err := DetectDRM("book.txt"); err != nil || drm {
+		t.Errorf("unsupported format: DetectDRM = %v, %v", drm, err)
+	}
+}
+
+func TestExtractChaptersDRMProtected(t *testing.T) {
+	epub := writeTestEPUB(t, map[string]string{
+		"META-INF/container.xml": testContainer("content.opf"),
+		"META-INF/rights.xml":    "<rights/>",
+	})
+
+	c := &Calibre{
+		Timeout:      DefaultTimeout,
+		ebookConvert: "ebook-convert",
+		Runner: func(ctx context.Context, name string, args ...string) ([]byte, error) {
+			t.Fatal("DRM-protected book should not be converted")
+			return nil, nil
+		},
+	}
+
+	if _, err := c.ExtractChapters(epub); !errors.Is(err, ErrDRMProtected) {
+		t.Errorf("expected ErrDRMProtected, got %v", err)
+	}
+}
@@ -0,0 +1,122 @@
+package calibre
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Sentinel errors for the ways an operation can fail. Returned errors wrap
+// them with context, so check for them with errors.Is.
+var (
+	// ErrToolNotFound is returned when a required Calibre tool isn't installed
+	ErrToolNotFound = errors.New("calibre tool not found")
+
+	// ErrTimeout is returned when a command runs past its deadline
+	ErrTimeout = errors.New("command timed out")
+
+	// ErrNoCover is returned when a book has no cover image
+	ErrNoCover = errors.New("book has no cover")
+
+	// ErrNoMetadataFound is returned when an online lookup has no results
+	ErrNoMetadataFound = errors.New("no metadata found")
+
+	// ErrParse is returned when a document such as an OPF can't be parsed
+	ErrParse = errors.New("failed to parse")
+
+	// ErrDRMProtected is returned when a book is encrypted with DRM
+	ErrDRMProtected = errors.New("book is DRM protected")
+
+	// ErrDuplicateInLibrary is returned when calibredb refuses to add a book
+	// that already exists in the library
+	ErrDuplicateInLibrary = errors.New("book already exists in library")
+
+	// ErrCoverFormatMismatch is returned when an extracted cover's image
+	// format doesn't match the output file's extension
+	ErrCoverFormatMismatch = errors.New("cover format does not match file extension")
+
+	// ErrChapterOutOfRange is returned when a chapter index is past the end
+	// of the book
+	ErrChapterOutOfRange = errors.New("chapter index out of range")
+
+	// ErrNoPageBreaks is returned when a PDF's text conversion has no page
+	// breaks to split pages at
+	ErrNoPageBreaks = errors.New("no page breaks in converted text")
+
+	// ErrDryRun matches the DryRunError returned instead of running a
+	// command when Calibre.DryRun is set
+	ErrDryRun = errors.New("dry run")
+)
+
+// toolNotFound returns an ErrToolNotFound error naming the missing tool
+func toolNotFound(name string) error {
+	return fmt.Errorf("%w: %s", ErrToolNotFound, name)
+}
+
+// DryRunError is returned in place of running a Calibre command when
+// Calibre.DryRun is set. Argv is the complete command line, starting with
+// the tool's absolute path. Use errors.As (or DryRunCommand) to get it.
+type DryRunError struct {
+	Argv []string
+}
+
+// Error returns the command as a line that can be pasted into a shell
+func (e *DryRunError) Error() string {
+	return "dry run: " + e.Command()
+}
+
+// Is makes DryRunError match ErrDryRun
+func (e *DryRunError) Is(target error) bool {
+	return target == ErrDryRun
+}
+
+// Command returns Argv quoted for a POSIX shell
+func (e *DryRunError) Command() string {
+	quoted := make([]string, len(e.Argv))
+	for i, arg := range e.Argv {
+		quoted[i] = shellQuote(arg)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// DryRunCommand returns the command line carried by a DryRunError in err's
+// chain, and whether there was one
+func DryRunCommand(err error) ([]string, bool) {
+	var dryRun *DryRunError
+	if errors.As(err, &dryRun) {
+		return dryRun.Argv, true
+	}
+	return nil, false
+}
+
+// shellQuote single-quotes arg unless it only has characters that are safe
+// unquoted
+func shellQuote(arg string) string {
+	if arg != "" && strings.Trim(arg, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_./:=,+@%") == "" {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+// ChapterError reports a table of contents entry whose content couldn't be
+// extracted, as returned by ExtractChaptersPartial
+type ChapterError struct {
+	// Index is the entry's position among the chapter entries tried
+	Index int
+
+	// Title and Href identify the entry
+	Title string
+	Href  string
+
+	Err error
+}
+
+// Error describes the failed entry and why it failed
+func (e ChapterError) Error() string {
+	return fmt.Sprintf("chapter %d (%q, %s): %v", e.Index, e.Title, e.Href, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e ChapterError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,53 @@
+package calibre
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestErrToolNotFound(t *testing.T) {
+	c := &Calibre{Timeout: DefaultTimeout}
+
+	err := c.Convert(context.Background(), "book.epub", "book.mobi", ConvertOptions{})
+	if !errors.Is(err, ErrToolNotFound) {
+		t.Errorf("Convert: expected ErrToolNotFound, got %v", err)
+	}
+	if _, err := c.GetMetadata("book.mobi"); !errors.Is(err, ErrToolNotFound) {
+		t.Errorf("GetMetadata: expected ErrToolNotFound, got %v", err)
+	}
+}
+
+func TestErrTimeout(t *testing.T) {
+	c := &Calibre{
+		Timeout: 10 * time.Millisecond,
+		Runner: func(ctx context.Context, name string, args ...string) ([]byte, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		},
+	}
+
+	_, err := c.runCommand(context.Background(), "ebook-meta")
+	if !errors.Is(err, ErrTimeout) {
+		t.Errorf("expected ErrTimeout, got %v", err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("timeout should still wrap context.DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestErrParse(t *testing.T) {
+	c := &Calibre{
+		Timeout:   DefaultTimeout,
+		ebookMeta: "ebook-meta",
+		Runner: func(ctx context.Context, name string, args ...string) ([]byte, error) {
+			return nil, os.WriteFile(args[2], []byte("<package><metadata>"), 0644)
+		},
+	}
+
+	if _, err := c.GetMetadata("book.epub"); !errors.Is(err, ErrParse) {
+		t.Errorf("expected ErrParse, got %v", err)
+	}
+}
@@ -0,0 +1,82 @@
+package calibre
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/anilpdv/go-calibre/models"
+)
+
+// MetadataQuery describes a book to look up online. At least one field must be set.
+type MetadataQuery struct {
+	Title   string
+	Authors []string
+	ISBN    string
+}
+
+// FetchOnlineMetadata looks up book metadata from online sources using
+// fetch-ebook-metadata. It returns ErrNoMetadataFound when nothing matches.
+func (c *Calibre) FetchOnlineMetadata(ctx context.Context, query MetadataQuery) (*models.Metadata, error) {
+	if c.fetchMeta == "" {
+		return nil, fmt.Errorf("%w: fetch-ebook-metadata (online lookup is optional and needs it in PATH)", ErrToolNotFound)
+	}
+
+	var args []string
+	if query.Title != "" {
+		args = append(args, "--title", query.Title)
+	}
+	if len(query.Authors) > 0 {
+		args = append(args, "--authors", strings.Join(query.Authors, " & "))
+	}
+	if query.ISBN != "" {
+		args = append(args, "--isbn", query.ISBN)
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("metadata query needs a title, authors, or ISBN")
+	}
+
+	// --opf prints the result as OPF on stdout
+	args = append(args, "--opf")
+
+	output, err := c.runCommand(ctx, c.fetchMeta, args...)
+	if err != nil {
+		if strings.Contains(err.Error(), "No results found") {
+			return nil, fmt.Errorf("%w for %s", ErrNoMetadataFound, describeQuery(query))
+		}
+		return nil, fmt.Errorf("fetch-ebook-metadata failed: %w", err)
+	}
+
+	parsed, err := parseOPFOutput(output)
+	if err != nil {
+		return nil, fmt.Errorf("%w OPF: %w", ErrParse, err)
+	}
+
+	// A successful run can still produce an essentially empty record
+	if isUnknown(parsed.Title) && (len(parsed.Authors) == 0 || isUnknown(parsed.Authors[0])) {
+		return nil, fmt.Errorf("%w for %s", ErrNoMetadataFound, describeQuery(query))
+	}
+
+	return metadataFromParsed(parsed), nil
+}
+
+// isUnknown reports whether a value is empty or Calibre's "Unknown" placeholder
+func isUnknown(s string) bool {
+	s = strings.TrimSpace(s)
+	return s == "" || strings.EqualFold(s, "unknown")
+}
+
+// describeQuery formats a query for error messages
+func describeQuery(q MetadataQuery) string {
+	var parts []string
+	if q.Title != "" {
+		parts = append(parts, fmt.Sprintf("title %q", q.Title))
+	}
+	if len(q.Authors) > 0 {
+		parts = append(parts, fmt.Sprintf("authors %q", strings.Join(q.Authors, " & ")))
+	}
+	if q.ISBN != "" {
+		parts = append(parts, fmt.Sprintf("ISBN %s", q.ISBN))
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,52 @@
+package calibre
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestFetchOnlineMetadata(t *testing.T) {
+	var gotArgs []string
+	c := &Calibre{
+		Timeout:   DefaultTimeout,
+		fetchMeta: "fetch-ebook-metadata",
+		Runner: func(ctx context.Context, name string, args ...string) ([]byte, error) {
+			gotArgs = args
+			return []byte(testOPF(`<dc:title>Candide</dc:title><dc:creator opf:role="aut">Voltaire</dc:creator>`, "", "")), nil
+		},
+	}
+
+	meta, err := c.FetchOnlineMetadata(context.Background(), MetadataQuery{Title: "Candide"})
+	if err != nil {
+		t.Fatalf("FetchOnlineMetadata failed: %v", err)
+	}
+	if meta.Title != "Candide" || len(meta.Authors) != 1 || meta.Authors[0] != "Voltaire" {
+		t.Errorf("meta = %+v", meta)
+	}
+	if len(gotArgs) != 3 || gotArgs[0] != "--title" || gotArgs[2] != "--opf" {
+		t.Errorf("args = %q", gotArgs)
+	}
+}
+
+func TestFetchOnlineMetadataNotFound(t *testing.T) {
+	c := &Calibre{
+		Timeout:   DefaultTimeout,
+		fetchMeta: "fetch-ebook-metadata",
+		Runner: func(ctx context.Context, name string, args ...string) ([]byte, error) {
+			return []byte(testOPF(`<dc:title>Unknown</dc:title>`, "", "")), nil
+		},
+	}
+
+	_, err := c.FetchOnlineMetadata(context.Background(), MetadataQuery{ISBN: "0000000000"})
+	if !errors.Is(err, ErrNoMetadataFound) {
+		t.Errorf("expected ErrNoMetadataFound, got %v", err)
+	}
+}
+
+func TestFetchOnlineMetadataMissingTool(t *testing.T) {
+	c := &Calibre{Timeout: DefaultTimeout}
+	if _, err := c.FetchOnlineMetadata(context.Background(), MetadataQuery{Title: "x"}); err == nil {
+		t.Error("expected an error when fetch-ebook-metadata is missing")
+	}
+}
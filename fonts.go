@@ -0,0 +1,250 @@
+package calibre
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/anilpdv/go-calibre/ncx"
+	"github.com/anilpdv/go-calibre/opf"
+)
+
+// FontInfo describes a font embedded in an EPUB
+type FontInfo struct {
+	// Name is the font's path inside the EPUB
+	Name string
+
+	// Size is the uncompressed size in bytes
+	Size int64
+
+	// Format is "ttf", "otf", "woff" or "woff2"
+	Format string
+
+	// Obfuscated is set for fonts mangled with the IDPF or Adobe font
+	// obfuscation algorithm listed in META-INF/encryption.xml
+	Obfuscated bool
+
+	// Deobfuscated is set when an obfuscated font was written out in the
+	// clear, which needs the OPF's unique identifier
+	Deobfuscated bool
+
+	// OutputPath is where the font was written, if an output directory was given
+	OutputPath string
+}
+
+// fontMediaTypes maps the manifest media types used for fonts to a format
+var fontMediaTypes = map[string]string{
+	"font/ttf":                    "ttf",
+	"font/otf":                    "otf",
+	"font/woff":                   "woff",
+	"font/woff2":                  "woff2",
+	"font/sfnt":                   "ttf",
+	"application/font-sfnt":       "ttf",
+	"application/x-font-ttf":      "ttf",
+	"application/x-font-truetype": "ttf",
+	"application/x-font-otf":      "otf",
+	"application/x-font-opentype": "otf",
+	"application/vnd.ms-opentype": "otf",
+	"application/font-woff":       "woff",
+	"application/font-woff2":      "woff2",
+	"application/x-font-woff":     "woff",
+	"application/x-truetype-font": "ttf",
+	"application/x-opentype-font": "otf",
+}
+
+// fontExtensions maps font file extensions to a format
+var fontExtensions = map[string]string{
+	".ttf":   "ttf",
+	".otf":   "otf",
+	".woff":  "woff",
+	".woff2": "woff2",
+}
+
+// ExtractFonts lists the fonts embedded in an EPUB, found by file extension
+// and through the OPF manifest. If outputDir is non-empty each font is also
+// written there, keeping its path inside the archive. Obfuscated fonts are
+// written in the clear when the OPF's unique identifier is available, and as
+// stored otherwise.
+func ExtractFonts(epubPath, outputDir string) ([]FontInfo, error) {
+	r, err := zip.OpenReader(epubPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open EPUB: %w", err)
+	}
+	defer r.Close()
+
+	manifestFonts := map[string]string{}
+	uid := ""
+	if pkg, opfPath, err := opf.ReadPackage(&r.Reader); err == nil {
+		uid = pkg.UniqueIdentifierValue()
+		for _, item := range pkg.Manifest.Items {
+			if format, ok := fontMediaTypes[strings.ToLower(item.MediaType)]; ok {
+				manifestFonts[opf.ResolveHref(opfPath, item.Href)] = format
+			}
+		}
+	}
+
+	obfuscated, err := obfuscatedFonts(&r.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	var fonts []FontInfo
+	for _, f := range r.File {
+		name := path.Clean(f.Name)
+		format, ok := manifestFonts[name]
+		if !ok {
+			if format, ok = fontExtensions[strings.ToLower(path.Ext(name))]; !ok {
+				continue
+			}
+		}
+
+		algorithm := obfuscated[name]
+		font := FontInfo{
+			Name:       name,
+			Size:       int64(f.UncompressedSize64),
+			Format:     format,
+			Obfuscated: algorithm != "",
+		}
+
+		var clear []byte
+		if font.Obfuscated && uid != "" {
+			if data, err := readZipFile(f); err == nil {
+				clear, err = ncx.DeobfuscateFontAlgorithm(data, uid, algorithm)
+				font.Deobfuscated = err == nil
+			}
+		}
+
+		// The header is mangled in obfuscated fonts, so only trust it in the clear
+		switch {
+		case font.Deobfuscated:
+			if sniffed := fontFormat(clear); sniffed != "" {
+				font.Format = sniffed
+			}
+		case !font.Obfuscated:
+			if sniffed := sniffFontFormat(f); sniffed != "" {
+				font.Format = sniffed
+			}
+		}
+
+		if outputDir != "" {
+			dest, err := safeJoin(outputDir, name)
+			if err != nil {
+				return fonts, err
+			}
+			if font.Deobfuscated {
+				err = writeOutputFile(dest, clear)
+			} else {
+				err = extractZipFile(f, dest)
+			}
+			if err != nil {
+				return fonts, err
+			}
+			font.OutputPath = dest
+		}
+
+		fonts = append(fonts, font)
+	}
+
+	return fonts, nil
+}
+
+// obfuscatedFonts maps the archive paths META-INF/encryption.xml lists as
+// mangled with a font obfuscation algorithm to that algorithm
+func obfuscatedFonts(r *zip.Reader) (map[string]string, error) {
+	fonts := map[string]string{}
+	for _, f := range r.File {
+		if f.Name != "META-INF/encryption.xml" {
+			continue
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("failed to open encryption.xml: %w", err)
+		}
+		var doc encryptionDoc
+		err = xml.NewDecoder(rc).Decode(&doc)
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%w encryption.xml: %w", ErrParse, err)
+		}
+
+		for _, data := range doc.EncryptedData {
+			if !fontObfuscationAlgorithms[data.Method.Algorithm] {
+				continue
+			}
+			// URIs are relative to the container root and may be escaped
+			uri := data.CipherReference.URI
+			if unescaped, err := url.PathUnescape(uri); err == nil {
+				uri = unescaped
+			}
+			fonts[path.Clean(strings.TrimPrefix(uri, "/"))] = data.Method.Algorithm
+		}
+	}
+	return fonts, nil
+}
+
+// sniffFontFormat identifies a font entry from its first bytes, returning ""
+// if they aren't a known font signature
+func sniffFontFormat(f *zip.File) string {
+	rc, err := f.Open()
+	if err != nil {
+		return ""
+	}
+	defer rc.Close()
+
+	magic := make([]byte, 4)
+	if _, err := io.ReadFull(rc, magic); err != nil {
+		return ""
+	}
+	return fontFormat(magic)
+}
+
+// fontFormat identifies a font from its signature
+func fontFormat(data []byte) string {
+	if len(data) < 4 {
+		return ""
+	}
+	switch string(data[:4]) {
+	case "\x00\x01\x00\x00", "true":
+		return "ttf"
+	case "OTTO":
+		return "otf"
+	case "wOFF":
+		return "woff"
+	case "wOF2":
+		return "woff2"
+	}
+	return ""
+}
+
+// readZipFile reads a zip entry into memory
+func readZipFile(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", f.Name, err)
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", f.Name, err)
+	}
+	return data, nil
+}
+
+// writeOutputFile writes data to dest, creating parent directories
+func writeOutputFile(dest string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+	if err := os.WriteFile(dest, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", dest, err)
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package calibre
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/anilpdv/go-calibre/ncx"
+)
+
+// fontEncryptionXML marks the listed fonts as IDPF-obfuscated
+func fontEncryptionXML(uris ...string) string {
+	doc := `<encryption xmlns="urn:oasis:names:tc:opendocument:xmlns:container" xmlns:enc="http://www.w3.org/2001/04/xmlenc#">`
+	for _, uri := range uris {
+		doc += `<enc:EncryptedData><enc:EncryptionMethod Algorithm="http://www.idpf.org/2008/embedding"/>` +
+			`<enc:CipherData><enc:CipherReference URI="` + uri + `"/></enc:CipherData></enc:EncryptedData>`
+	}
+	return doc + `</encryption>`
+}
+
+func TestExtractFonts(t *testing.T) {
+	epub := writeTestEPUB(t, map[string]string{
+		"META-INF/container.xml":  testContainer("OEBPS/content.opf"),
+		"META-INF/encryption.xml": fontEncryptionXML("OEBPS/fonts/Body%20Bold.otf"),
+		"OEBPS/content.opf": testOPF("", `<item id="f1" href="fonts/Body.ttf" media-type="font/ttf"/>
+<item id="f2" href="fonts/Body%20Bold.otf" media-type="application/vnd.ms-opentype"/>
+<item id="f3" href="fonts/Mono" media-type="application/font-woff"/>`, ""),
+		"OEBPS/fonts/Body.ttf":      "\x00\x01\x00\x00glyphs",
+		"OEBPS/fonts/Body Bold.otf": "\x13\x37mangled",
+		"OEBPS/fonts/Mono":          "wOF2data",
+		"OEBPS/text/ch1.xhtml":      "<html/>",
+	})
+	outDir := t.TempDir()
+
+	fonts, err := ExtractFonts(epub, outDir)
+	if err != nil {
+		t.Fatalf("ExtractFonts failed: %v", err)
+	}
+
+	want := map[string]FontInfo{
+		"OEBPS/fonts/Body Bold.otf": {Size: 9, Format: "otf", Obfuscated: true},
+		"OEBPS/fonts/Body.ttf":      {Size: 10, Format: "ttf"},
+		"OEBPS/fonts/Mono":          {Size: 8, Format: "woff2"},
+	}
+	if len(fonts) != len(want) {
+		t.Fatalf("got %d fonts, want %d: %+v", len(fonts), len(want), fonts)
+	}
+	for _, font := range fonts {
+		w, ok := want[font.Name]
+		if !ok {
+			t.Errorf("unexpected font %q", font.Name)
+			continue
+		}
+		if font.Size != w.Size || font.Format != w.Format || font.Obfuscated != w.Obfuscated {
+			t.Errorf("%s = %+v, want %+v", font.Name, font, w)
+		}
+		if font.OutputPath != filepath.Join(outDir, filepath.FromSlash(font.Name)) {
+			t.Errorf("%s written to %q", font.Name, font.OutputPath)
+		}
+		if _, err := os.Stat(font.OutputPath); err != nil {
+			t.Errorf("%s not written: %v", font.Name, err)
+		}
+	}
+}
+
+func TestExtractFontsListOnly(t *testing.T) {
+	epub := writeTestEPUB(t, map[string]string{"fonts/a.woff": "wOFFdata"})
+
+	fonts, err := ExtractFonts(epub, "")
+	if err != nil {
+		t.Fatalf("ExtractFonts failed: %v", err)
+	}
+	if len(fonts) != 1 || fonts[0].Format != "woff" || fonts[0].OutputPath != "" {
+		t.Errorf("fonts = %+v", fonts)
+	}
+}
+
+func TestExtractFontsDeobfuscates(t *testing.T) {
+	uid := "urn:uuid:0f8c3c3e-8a3b-4d2e-9f71-2b1c4a5d6e7f"
+	clear := append([]byte("\x00\x01\x00\x00"), bytes.Repeat([]byte("glyph"), 300)...)
+
+	epub := writeTestEPUB(t, map[string]string{
+		"META-INF/container.xml":  testContainer("content.opf"),
+		"META-INF/encryption.xml": fontEncryptionXML("fonts/serif.ttf"),
+		"content.opf":             testOPF(`<dc:identifier id="uid">`+uid+`</dc:identifier>`, "", ""),
+		"fonts/serif.ttf":         string(ncx.DeobfuscateFont(clear, uid)),
+	})
+	outDir := t.TempDir()
+
+	fonts, err := ExtractFonts(epub, outDir)
+	if err != nil {
+		t.Fatalf("ExtractFonts failed: %v", err)
+	}
+	if len(fonts) != 1 || !fonts[0].Obfuscated || !fonts[0].Deobfuscated || fonts[0].Format != "ttf" {
+		t.Fatalf("fonts = %+v", fonts)
+	}
+
+	data, err := os.ReadFile(fonts[0].OutputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, clear) {
+		t.Error("written font is still obfuscated")
+	}
+}
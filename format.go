@@ -0,0 +1,110 @@
+package calibre
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// zipFormats are formats stored as ZIP archives, which the content alone
+// cannot tell apart from a plain ZIP
+var zipFormats = map[string]bool{
+	"cbz": true, "docx": true, "fbz": true, "htmlz": true,
+	"kepub": true, "odt": true, "oebzip": true, "txtz": true,
+}
+
+// mobiFormats share the BOOKMOBI Palm database header
+var mobiFormats = map[string]bool{
+	"azw": true, "azw3": true, "azw4": true, "mobi": true, "prc": true,
+}
+
+// DetectFormat identifies an ebook's format from its content rather than its
+// extension, returning one of SupportedFormats. EPUB, PDF, MOBI, PDB, TPZ and
+// RTF are recognized by their magic bytes. Where several formats share a
+// container (ZIP archives, the MOBI header), a matching extension decides.
+func DetectFormat(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open file: %w", err)
+	}
+	defer f.Close()
+
+	header := make([]byte, 68)
+	n, err := io.ReadFull(f, header)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+	header = header[:n]
+	ext := strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+
+	switch {
+	case bytes.HasPrefix(header, []byte("PK\x03\x04")):
+		return detectZipFormat(path, ext)
+	case bytes.HasPrefix(header, []byte("%PDF")):
+		return "pdf", nil
+	case bytes.HasPrefix(header, []byte(`{\rtf`)):
+		return "rtf", nil
+	case bytes.HasPrefix(header, []byte("TPZ")):
+		return "tpz", nil
+	case len(header) >= 68 && string(header[60:68]) == "BOOKMOBI":
+		if mobiFormats[ext] {
+			return ext, nil
+		}
+		return "mobi", nil
+	case len(header) >= 68 && string(header[60:68]) == "TEXtREAd":
+		return "pdb", nil
+	}
+
+	return "", fmt.Errorf("unrecognized ebook format: %s", filepath.Base(path))
+}
+
+// detectZipFormat tells an EPUB from other ZIP-based formats by its mimetype
+// entry
+func detectZipFormat(path, ext string) (string, error) {
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open ZIP: %w", err)
+	}
+	defer r.Close()
+
+	for _, f := range r.File {
+		if f.Name != "mimetype" {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return "", fmt.Errorf("failed to open mimetype: %w", err)
+		}
+		data, err := io.ReadAll(io.LimitReader(rc, 64))
+		rc.Close()
+		if err != nil {
+			return "", fmt.Errorf("failed to read mimetype: %w", err)
+		}
+		if strings.TrimSpace(string(data)) == "application/epub+zip" {
+			if ext == "kepub" {
+				return ext, nil
+			}
+			return "epub", nil
+		}
+		break
+	}
+
+	if zipFormats[ext] {
+		return ext, nil
+	}
+	return "zip", nil
+}
+
+// cleanFormat normalizes a caller-supplied format name such as ".EPUB" to
+// "epub" for use as a temp file extension, rejecting anything path-like
+func cleanFormat(format string) (string, error) {
+	clean := strings.TrimPrefix(strings.ToLower(strings.TrimSpace(format)), ".")
+	if clean == "" || strings.ContainsAny(clean, `/\`) {
+		return "", fmt.Errorf("invalid format %q", format)
+	}
+	return clean, nil
+}
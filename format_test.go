@@ -0,0 +1,88 @@
+package calibre
+
+import (
+	"archive/zip"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDetectFormat(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	// A plain ZIP without an EPUB mimetype
+	zipPath := filepath.Join(dir, "archive.bin")
+	f, err := os.Create(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	w, _ := zw.Create("page1.jpg")
+	w.Write([]byte("jpeg"))
+	zw.Close()
+	f.Close()
+
+	epub := writeTestEPUB(t, map[string]string{"content.opf": "<package/>"})
+	misnamed := filepath.Join(dir, "upload.bin")
+	data, err := os.ReadFile(epub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(misnamed, data, 0644)
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{epub, "epub"},
+		{misnamed, "epub"},
+		{zipPath, "zip"},
+		{write("doc.pdf.txt", "%PDF-1.7\n..."), "pdf"},
+		{write("notes", `{\rtf1\ansi hello}`), "rtf"},
+		{write("kindle.tpz", "TPZ0rest"), "tpz"},
+		{writeTestMobi(t, "book.bin", 0), "mobi"},
+		{writeTestMobi(t, "book.azw3", 0), "azw3"},
+	}
+	for _, tt := range tests {
+		got, err := DetectFormat(tt.path)
+		if err != nil {
+			t.Errorf("DetectFormat(%s) failed: %v", filepath.Base(tt.path), err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("DetectFormat(%s) = %q, want %q", filepath.Base(tt.path), got, tt.want)
+		}
+	}
+
+	if _, err := DetectFormat(write("plain.txt", "just some text")); err == nil {
+		t.Error("expected an error for unrecognized content")
+	}
+}
+
+func TestGetBookDetectsFormat(t *testing.T) {
+	data, err := os.ReadFile(writeTestEPUB(t, map[string]string{
+		"META-INF/container.xml": testContainer("content.opf"),
+		"content.opf":            testOPF(`<dc:title>Upload</dc:title>`, "", ""),
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "upload.bin")
+	os.WriteFile(path, data, 0644)
+
+	book, err := (&Calibre{Timeout: DefaultTimeout}).GetBookContext(context.Background(), path)
+	if err != nil {
+		t.Fatalf("GetBookContext failed: %v", err)
+	}
+	if book.Format != ".epub" {
+		t.Errorf("Format = %q, want .epub", book.Format)
+	}
+}
@@ -0,0 +1,209 @@
+package calibre
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/anilpdv/go-calibre/models"
+	"github.com/anilpdv/go-calibre/ncx"
+)
+
+// BookHandle is an opened ebook whose metadata, chapters, cover, and TOC are
+// loaded lazily on first use and cached. Non-EPUB books are converted to EPUB
+// at most once and the result is shared by every method.
+// Call Close to remove the handle's temporary files.
+type BookHandle struct {
+	c    *Calibre
+	ctx  context.Context
+	path string
+
+	mu       sync.Mutex
+	tmpDir   string
+	epubPath string // original EPUB, or the converted copy in tmpDir
+
+	metadata *models.Metadata
+	chapters []models.Chapter
+	toc      []models.TOCEntry
+	cover    []byte
+}
+
+// Open returns a handle for lazily reading an ebook. The context is used for
+// every command the handle runs.
+func (c *Calibre) Open(ctx context.Context, path string) (*BookHandle, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("failed to open book: %w", err)
+	}
+
+	tmpDir, err := c.mkdirScratch("calibre-book-*")
+	if err != nil {
+		return nil, err
+	}
+
+	return &BookHandle{c: c, ctx: ctx, path: path, tmpDir: tmpDir}, nil
+}
+
+// Path returns the path the handle was opened with
+func (h *BookHandle) Path() string {
+	return h.path
+}
+
+// Close removes the handle's temporary files
+func (h *BookHandle) Close() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.tmpDir == "" {
+		return nil
+	}
+	err := os.RemoveAll(h.tmpDir)
+	h.tmpDir = ""
+	return err
+}
+
+// Metadata returns the book's metadata, reading it on first call
+func (h *BookHandle) Metadata() (*models.Metadata, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.metadata == nil {
+		meta, err := h.c.GetMetadataContext(h.ctx, h.path)
+		if err != nil {
+			return nil, err
+		}
+		h.metadata = meta
+	}
+	return h.metadata, nil
+}
+
+// Chapters returns the book's chapters, extracting them on first call
+func (h *BookHandle) Chapters() ([]models.Chapter, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.chapters != nil {
+		return h.chapters, nil
+	}
+
+	if err := h.checkOpen(); err != nil {
+		return nil, err
+	}
+
+	// Prefer the original EPUB's NCX, as ExtractChapters does
+	if isEPUB(h.path) {
+		chapters, err := collectChapters(func(emit func(models.Chapter) error) error {
+			return h.c.streamChaptersFromOriginalNCX(h.ctx, h.path, ChapterOptions{}, emit)
+		})
+		if err == nil && len(chapters) >= 3 {
+			h.chapters = chapters
+			return chapters, nil
+		}
+	}
+
+	epubPath, err := h.convertedEPUB()
+	if err == nil {
+		chapters, err := collectChapters(func(emit func(models.Chapter) error) error {
+			return h.c.streamChaptersFromConvertedEPUB(h.ctx, epubPath, ChapterOptions{}, emit)
+		})
+		if err == nil && len(chapters) > 0 {
+			h.chapters = chapters
+			return chapters, nil
+		}
+	}
+
+	// Fallback to text-based extraction
+	chapters, err := h.c.extractChaptersWithText(h.ctx, h.path, h.tmpDir, ChapterOptions{})
+	if err != nil {
+		return nil, err
+	}
+	h.chapters = chapters
+	return chapters, nil
+}
+
+// TOC returns the book's table of contents from its (possibly converted) NCX
+func (h *BookHandle) TOC() ([]models.TOCEntry, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.toc != nil {
+		return h.toc, nil
+	}
+
+	if err := h.checkOpen(); err != nil {
+		return nil, err
+	}
+
+	epubPath := h.path
+	if !isEPUB(h.path) {
+		var err error
+		if epubPath, err = h.convertedEPUB(); err != nil {
+			return nil, err
+		}
+	}
+
+	ncxDoc, err := ncx.ExtractNCXFromEPUB(epubPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract NCX: %w", err)
+	}
+
+	h.toc = tocFromNCX(ncxDoc)
+	return h.toc, nil
+}
+
+// Cover returns the cover image bytes, extracting them on first call
+func (h *BookHandle) Cover() ([]byte, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.cover != nil {
+		return h.cover, nil
+	}
+
+	if err := h.checkOpen(); err != nil {
+		return nil, err
+	}
+
+	data, _, err := h.c.ExtractCoverData(h.ctx, h.path)
+	if err != nil {
+		return nil, err
+	}
+	h.cover = data
+	return data, nil
+}
+
+// convertedEPUB converts the book to EPUB once and returns the cached path.
+// Callers must hold h.mu.
+func (h *BookHandle) convertedEPUB() (string, error) {
+	if h.epubPath != "" {
+		return h.epubPath, nil
+	}
+
+	if h.c.ebookConvert == "" {
+		return "", toolNotFound("ebook-convert")
+	}
+
+	epubPath := filepath.Join(h.tmpDir, "book.epub")
+	if err := h.c.convertForChapters(h.ctx, h.path, epubPath, ChapterOptions{}); err != nil {
+		return "", err
+	}
+
+	h.epubPath = epubPath
+	return epubPath, nil
+}
+
+// checkOpen returns an error if the handle has been closed.
+// Callers must hold h.mu.
+func (h *BookHandle) checkOpen() error {
+	if h.tmpDir == "" {
+		return fmt.Errorf("book handle is closed")
+	}
+	return nil
+}
+
+// isEPUB reports whether a path has an .epub extension
+func isEPUB(path string) bool {
+	return strings.HasSuffix(strings.ToLower(path), ".epub")
+}
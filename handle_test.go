@@ -0,0 +1,87 @@
+package calibre
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+func TestBookHandleCachesConversion(t *testing.T) {
+	epub := writeTestEPUB(t, map[string]string{
+		"toc.ncx": testNCX(
+			[2]string{"Chapter 1", "ch1.xhtml"},
+			[2]string{"Chapter 2", "ch2.xhtml"},
+		),
+		"ch1.xhtml": testXHTML("<p>" + loremWords("First", 100) + "</p>"),
+		"ch2.xhtml": testXHTML("<p>" + loremWords("Second", 100) + "</p>"),
+	})
+
+	book := filepath.Join(t.TempDir(), "book.mobi")
+	if err := os.WriteFile(book, []byte("not really a mobi"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var calls int32
+	convert := convertingRunner(t, epub)
+	c := &Calibre{
+		Timeout:      DefaultTimeout,
+		ebookConvert: "ebook-convert",
+		Runner: func(ctx context.Context, name string, args ...string) ([]byte, error) {
+			atomic.AddInt32(&calls, 1)
+			return convert(ctx, name, args...)
+		},
+	}
+
+	defer c.Close()
+
+	h, err := c.Open(context.Background(), book)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer h.Close()
+
+	for i := 0; i < 3; i++ {
+		chapters, err := h.Chapters()
+		if err != nil {
+			t.Fatalf("Chapters failed: %v", err)
+		}
+		if len(chapters) != 2 {
+			t.Fatalf("expected 2 chapters, got %d", len(chapters))
+		}
+	}
+
+	toc, err := h.TOC()
+	if err != nil {
+		t.Fatalf("TOC failed: %v", err)
+	}
+	if len(toc) != 2 || toc[1].Href != "ch2.xhtml" {
+		t.Errorf("TOC = %+v", toc)
+	}
+
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Errorf("expected 1 conversion, got %d", n)
+	}
+}
+
+func TestBookHandleClosed(t *testing.T) {
+	book := filepath.Join(t.TempDir(), "book.mobi")
+	if err := os.WriteFile(book, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := &Calibre{Timeout: DefaultTimeout}
+	defer c.Close()
+
+	h, err := c.Open(context.Background(), book)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if err := h.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if _, err := h.Chapters(); err == nil {
+		t.Error("expected an error from a closed handle")
+	}
+}
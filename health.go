@@ -0,0 +1,65 @@
+package calibre
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// healthCheckTimeout bounds HealthCheck's round trip. Calibre takes a few
+// seconds to start, so this allows for two slow starts and little else.
+const healthCheckTimeout = 30 * time.Second
+
+// healthCheckTitle marks the document HealthCheck converts, so its metadata
+// can be recognized when read back
+const healthCheckTitle = "go-calibre health check"
+
+// HealthCheck confirms Calibre works end to end, for readiness probes: a
+// one-line HTML document is converted to EPUB with ebook-convert and its
+// title read back with ebook-meta. Unlike IsInstalled, which only runs
+// --version, this catches installs that start but can't convert, such as
+// ones missing Qt libraries or with broken plugins. It gives up after
+// 30 seconds, or sooner if ctx ends first.
+func (c *Calibre) HealthCheck(ctx context.Context) error {
+	if c.ebookConvert == "" {
+		return toolNotFound("ebook-convert")
+	}
+	if c.ebookMeta == "" {
+		return toolNotFound("ebook-meta")
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	tmpDir, err := c.mkdirTemp("calibre-health-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	htmlPath := filepath.Join(tmpDir, "health.html")
+	doc := "<html><head><title>" + healthCheckTitle + "</title></head><body><p>ok</p></body></html>\n"
+	if err := os.WriteFile(htmlPath, []byte(doc), 0644); err != nil {
+		return fmt.Errorf("failed to write health check document: %w", err)
+	}
+
+	epubPath := filepath.Join(tmpDir, "health.epub")
+	if err := c.Convert(ctx, htmlPath, epubPath, ConvertOptions{}); err != nil {
+		return fmt.Errorf("health check conversion failed: %w", err)
+	}
+
+	meta, err := c.readMetadata(ctx, epubPath)
+	if err != nil {
+		return fmt.Errorf("health check metadata read failed: %w", err)
+	}
+	if meta.Title != healthCheckTitle {
+		return fmt.Errorf("health check read back title %q, want %q", meta.Title, healthCheckTitle)
+	}
+
+	return nil
+}
@@ -0,0 +1,67 @@
+package calibre
+
+import (
+	"context"
+	"errors"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// healthRunner fakes a working Calibre: ebook-convert copies the input to
+// the output and ebook-meta reports the <title> it finds there
+func healthRunner(convertErr error) CommandRunner {
+	titleRe := regexp.MustCompile(`<title>(.*?)</title>`)
+	return func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		switch name {
+		case "ebook-convert":
+			if convertErr != nil {
+				return nil, convertErr
+			}
+			data, err := os.ReadFile(args[0])
+			if err != nil {
+				return nil, err
+			}
+			return nil, os.WriteFile(args[1], data, 0644)
+		case "ebook-meta":
+			data, err := os.ReadFile(args[0])
+			if err != nil {
+				return nil, err
+			}
+			title := ""
+			if m := titleRe.FindSubmatch(data); m != nil {
+				title = string(m[1])
+			}
+			opfXML := testOPF(`<dc:title>`+title+`</dc:title>`, "", "")
+			return nil, os.WriteFile(args[len(args)-1], []byte(opfXML), 0644)
+		}
+		return nil, errors.New("unexpected command " + name)
+	}
+}
+
+func TestHealthCheck(t *testing.T) {
+	c := &Calibre{
+		Timeout:      DefaultTimeout,
+		TempDir:      t.TempDir(),
+		Runner:       healthRunner(nil),
+		ebookMeta:    "ebook-meta",
+		ebookConvert: "ebook-convert",
+	}
+
+	if err := c.HealthCheck(context.Background()); err != nil {
+		t.Fatalf("HealthCheck failed: %v", err)
+	}
+
+	// A broken install that still answers --version fails the round trip
+	c.Runner = healthRunner(errors.New("libQt6Core.so.6: cannot open shared object file"))
+	err := c.HealthCheck(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "libQt6Core") {
+		t.Errorf("expected the conversion error, got %v", err)
+	}
+
+	c.ebookConvert = ""
+	if err := c.HealthCheck(context.Background()); !errors.Is(err, ErrToolNotFound) {
+		t.Errorf("expected ErrToolNotFound, got %v", err)
+	}
+}
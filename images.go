@@ -0,0 +1,127 @@
+package calibre
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/anilpdv/go-calibre/opf"
+)
+
+// ImageOptions configures image extraction
+type ImageOptions struct {
+	// SkipCover leaves out the image the OPF declares as the cover
+	SkipCover bool
+}
+
+// imageMediaTypes are the manifest media types treated as images
+var imageMediaTypes = map[string]bool{
+	"image/jpeg":    true,
+	"image/png":     true,
+	"image/gif":     true,
+	"image/svg+xml": true,
+	"image/webp":    true,
+}
+
+// imageExtensions are the file extensions treated as images
+var imageExtensions = map[string]bool{
+	".jpg":  true,
+	".jpeg": true,
+	".png":  true,
+	".gif":  true,
+	".svg":  true,
+	".webp": true,
+}
+
+// ExtractImages writes every image in an EPUB to outputDir and returns the
+// written paths
+func ExtractImages(epubPath, outputDir string) ([]string, error) {
+	return ExtractImagesWithOptions(epubPath, outputDir, ImageOptions{})
+}
+
+// ExtractImagesWithOptions writes the EPUB's images to outputDir, keeping
+// their paths inside the archive. Images are found through the OPF manifest
+// and by file extension. Entries whose path would escape outputDir are
+// rejected.
+func ExtractImagesWithOptions(epubPath, outputDir string, opts ImageOptions) ([]string, error) {
+	r, err := zip.OpenReader(epubPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open EPUB: %w", err)
+	}
+	defer r.Close()
+
+	images := map[string]bool{}
+	coverName := ""
+	if pkg, opfPath, err := opf.ReadPackage(&r.Reader); err == nil {
+		for _, item := range pkg.Manifest.Items {
+			if imageMediaTypes[strings.ToLower(item.MediaType)] {
+				images[opf.ResolveHref(opfPath, item.Href)] = true
+			}
+		}
+		if item := pkg.CoverItem(); item != nil {
+			coverName = opf.ResolveHref(opfPath, item.Href)
+		}
+	}
+
+	var written []string
+	for _, f := range r.File {
+		name := path.Clean(f.Name)
+		if !images[name] && !imageExtensions[strings.ToLower(path.Ext(name))] {
+			continue
+		}
+		if opts.SkipCover && name == coverName {
+			continue
+		}
+
+		dest, err := safeJoin(outputDir, name)
+		if err != nil {
+			return written, err
+		}
+		if err := extractZipFile(f, dest); err != nil {
+			return written, err
+		}
+		written = append(written, dest)
+	}
+
+	return written, nil
+}
+
+// safeJoin joins an archive entry name onto dir, rejecting names that would
+// land outside dir
+func safeJoin(dir, name string) (string, error) {
+	dest := filepath.Join(dir, filepath.FromSlash(name))
+	rel, err := filepath.Rel(dir, dest)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) || filepath.IsAbs(name) {
+		return "", fmt.Errorf("illegal file path in EPUB: %s", name)
+	}
+	return dest, nil
+}
+
+// extractZipFile writes a single zip entry to dest, creating parent directories
+func extractZipFile(f *zip.File, dest string) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", f.Name, err)
+	}
+	defer rc.Close()
+
+	out, err := os.Create(dest)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", dest, err)
+	}
+	defer out.Close()
+
+	if _, err := io.Copy(out, rc); err != nil {
+		return fmt.Errorf("failed to write %s: %w", dest, err)
+	}
+
+	return out.Close()
+}
@@ -0,0 +1,85 @@
+package calibre
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+func imagesEPUB(t *testing.T, extra map[string]string) string {
+	t.Helper()
+	files := map[string]string{
+		"META-INF/container.xml": testContainer("OEBPS/content.opf"),
+		"OEBPS/content.opf": testOPF(`<dc:title>Pictures</dc:title>`,
+			`<item id="cover" href="images/cover.jpg" media-type="image/jpeg" properties="cover-image"/>
+<item id="fig1" href="images/fig1.png" media-type="image/png"/>
+<item id="art" href="art/plate" media-type="image/webp"/>`, ""),
+		"OEBPS/images/cover.jpg": "cover",
+		"OEBPS/images/fig1.png":  "fig1",
+		"OEBPS/art/plate":        "plate",
+		"OEBPS/misc/map.svg":     "<svg/>",
+		"OEBPS/text/ch1.xhtml":   testXHTML("<p>text</p>"),
+	}
+	for name, data := range extra {
+		files[name] = data
+	}
+	return writeTestEPUB(t, files)
+}
+
+func TestExtractImages(t *testing.T) {
+	outDir := t.TempDir()
+	paths, err := ExtractImages(imagesEPUB(t, nil), outDir)
+	if err != nil {
+		t.Fatalf("ExtractImages failed: %v", err)
+	}
+
+	var got []string
+	for _, p := range paths {
+		rel, _ := filepath.Rel(outDir, p)
+		got = append(got, filepath.ToSlash(rel))
+	}
+	sort.Strings(got)
+	want := []string{"OEBPS/art/plate", "OEBPS/images/cover.jpg", "OEBPS/images/fig1.png", "OEBPS/misc/map.svg"}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("got %v, want %v", got, want)
+			break
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join(outDir, "OEBPS", "images", "fig1.png"))
+	if err != nil || string(data) != "fig1" {
+		t.Errorf("fig1.png = %q, %v", data, err)
+	}
+}
+
+func TestExtractImagesSkipCover(t *testing.T) {
+	outDir := t.TempDir()
+	paths, err := ExtractImagesWithOptions(imagesEPUB(t, nil), outDir, ImageOptions{SkipCover: true})
+	if err != nil {
+		t.Fatalf("ExtractImagesWithOptions failed: %v", err)
+	}
+	if len(paths) != 3 {
+		t.Errorf("expected 3 images, got %v", paths)
+	}
+	if _, err := os.Stat(filepath.Join(outDir, "OEBPS", "images", "cover.jpg")); !os.IsNotExist(err) {
+		t.Error("cover should not be extracted with SkipCover")
+	}
+}
+
+func TestExtractImagesZipSlip(t *testing.T) {
+	parent := t.TempDir()
+	outDir := filepath.Join(parent, "out")
+
+	epub := imagesEPUB(t, map[string]string{"../evil.png": "evil"})
+	if _, err := ExtractImages(epub, outDir); err == nil {
+		t.Fatal("expected an error for an entry escaping the output directory")
+	}
+	if _, err := os.Stat(filepath.Join(parent, "evil.png")); !os.IsNotExist(err) {
+		t.Error("entry was written outside the output directory")
+	}
+}
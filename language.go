@@ -0,0 +1,108 @@
+package calibre
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// languageStopwords are the most frequent function words of each language
+// DetectLanguage recognizes, keyed by ISO 639-1 code
+var languageStopwords = map[string][]string{
+	"en": {"the", "and", "of", "to", "in", "is", "that", "it", "was", "he",
+		"for", "with", "his", "on", "be", "at", "by", "had", "not", "are",
+		"but", "from", "have", "she", "which", "you", "were", "her", "they",
+		"this", "would", "been", "a"},
+	"fr": {"le", "la", "les", "et", "des", "du", "une", "est", "qui", "dans",
+		"pour", "pas", "il", "elle", "au", "aux", "ce", "sur", "ne", "avec",
+		"mais", "son", "ses", "nous", "vous", "je", "était", "avait", "plus",
+		"cette", "leur", "tout", "de", "que", "un", "se", "a"},
+	"de": {"der", "die", "das", "und", "ist", "nicht", "ein", "eine", "ich",
+		"zu", "den", "dem", "mit", "sich", "des", "auf", "für", "im", "sie",
+		"er", "auch", "als", "wie", "war", "hatte", "aber", "noch", "nach",
+		"wir", "wird", "einen", "so"},
+	"es": {"el", "los", "las", "y", "en", "una", "es", "por", "con", "su",
+		"para", "al", "lo", "como", "más", "pero", "sus", "le", "ya", "fue",
+		"este", "había", "muy", "sin", "sobre", "también", "del", "yo",
+		"ella", "esta", "todo", "cuando", "de", "la", "que", "no", "un", "a",
+		"se"},
+	"it": {"il", "di", "che", "è", "per", "un", "non", "del", "della", "si",
+		"gli", "da", "lo", "i", "ma", "come", "sono", "era", "anche", "più",
+		"ha", "nel", "alla", "questo", "io", "lui", "aveva", "delle", "dei",
+		"quando", "ancora", "molto", "la", "e", "a", "in", "le", "una"},
+	"pt": {"o", "os", "do", "da", "em", "um", "uma", "não", "para", "com",
+		"por", "no", "na", "dos", "das", "mais", "mas", "ao", "ele", "ela",
+		"foi", "como", "seu", "sua", "também", "muito", "é", "eu", "isso",
+		"quando", "já", "nos", "de", "que", "a", "e", "se", "as"},
+}
+
+// stopwordLanguages maps each stopword to the languages listing it
+var stopwordLanguages = func() map[string][]string {
+	m := make(map[string][]string)
+	for lang, words := range languageStopwords {
+		for _, w := range words {
+			m[w] = append(m[w], lang)
+		}
+	}
+	return m
+}()
+
+const (
+	// languageSampleWords caps how much of a sample detectLanguage reads
+	languageSampleWords = 2000
+
+	// minLanguageHits is the fewest stopwords needed for a confident answer
+	minLanguageHits = 5
+)
+
+// DetectLanguage guesses a book's primary language from its text, for books
+// whose metadata lacks one. The first chapter is scored against stopword
+// tables for English, French, German, Spanish, Italian and Portuguese, and
+// the best match returned as an ISO 639-1 code such as "en". Text that
+// matches none of them clearly returns an error.
+func (c *Calibre) DetectLanguage(ctx context.Context, ebookPath string) (string, error) {
+	chapter, err := c.ExtractChapter(ctx, ebookPath, 0)
+	if err != nil {
+		return "", err
+	}
+
+	lang, ok := detectLanguage(chapter.Content)
+	if !ok {
+		return "", fmt.Errorf("could not detect the language of %s", ebookPath)
+	}
+	return lang, nil
+}
+
+// detectLanguage scores text against the stopword tables, reporting false
+// when too few stopwords match or two languages tie
+func detectLanguage(text string) (string, bool) {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	if len(words) > languageSampleWords {
+		words = words[:languageSampleWords]
+	}
+
+	scores := make(map[string]int)
+	for _, w := range words {
+		for _, lang := range stopwordLanguages[w] {
+			scores[lang]++
+		}
+	}
+
+	best, bestScore, tied := "", 0, false
+	for lang, score := range scores {
+		switch {
+		case score > bestScore:
+			best, bestScore, tied = lang, score, false
+		case score == bestScore:
+			tied = true
+		}
+	}
+
+	if bestScore < minLanguageHits || tied {
+		return "", false
+	}
+	return best, true
+}
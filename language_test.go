@@ -0,0 +1,80 @@
+package calibre
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestDetectLanguageText(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"It was the best of times, it was the worst of times, and he had not been to the town which they would visit with her.", "en"},
+		{"Il était une fois une princesse qui vivait dans un château avec son père, mais elle ne pouvait pas sortir de la tour.", "fr"},
+		{"Es war einmal ein Mann, der mit seiner Frau in einem kleinen Haus auf dem Land lebte, und sie hatte nicht viel Geld.", "de"},
+		{"En un lugar de la Mancha, de cuyo nombre no quiero acordarme, no ha mucho tiempo que vivía un hidalgo de los de lanza en astillero y adarga antigua.", "es"},
+		{"Nel mezzo del cammin di nostra vita mi ritrovai per una selva oscura, che la diritta via era smarrita, e io non sapevo come uscire.", "it"},
+		{"Não é fácil dizer o que ele sentia quando a viu pela primeira vez, mas foi como se o mundo inteiro parasse para os dois na praça da cidade.", "pt"},
+	}
+	for _, tt := range tests {
+		got, ok := detectLanguage(tt.text)
+		if !ok || got != tt.want {
+			t.Errorf("detectLanguage(%.30q) = %q, %v; want %q", tt.text, got, ok, tt.want)
+		}
+	}
+
+	if lang, ok := detectLanguage("lorem ipsum dolor sit amet"); ok {
+		t.Errorf("detectLanguage on filler text = %q, want no match", lang)
+	}
+}
+
+// untaggedEPUB returns an EPUB with no dc:language and one chapter of text
+func untaggedEPUB(t *testing.T, language, text string) string {
+	t.Helper()
+	return writeTestEPUB(t, map[string]string{
+		"META-INF/container.xml": testContainer("content.opf"),
+		"content.opf": testOPF(
+			`<dc:title>Le Livre</dc:title>`+language,
+			`<item id="ncx" href="toc.ncx" media-type="application/x-dtbncx+xml"/>
+<item id="ch1" href="ch1.xhtml" media-type="application/xhtml+xml"/>`,
+			`<itemref idref="ch1"/>`,
+		),
+		"toc.ncx":   testNCX([2]string{"Chapter 1", "ch1.xhtml"}),
+		"ch1.xhtml": testXHTML("<p>" + text + "</p>"),
+	})
+}
+
+func TestGetMetadataDetectMissingLanguage(t *testing.T) {
+	french := strings.Repeat("Il était une fois une princesse qui vivait dans un château avec son père. ", 5)
+	epub := untaggedEPUB(t, "", french)
+
+	c := &Calibre{Timeout: DefaultTimeout}
+	meta, err := c.GetMetadataContext(context.Background(), epub)
+	if err != nil {
+		t.Fatalf("GetMetadataContext failed: %v", err)
+	}
+	if meta.Language != "" {
+		t.Errorf("Language = %q without DetectMissingLanguage, want empty", meta.Language)
+	}
+
+	c.DetectMissingLanguage = true
+	meta, err = c.GetMetadataContext(context.Background(), epub)
+	if err != nil {
+		t.Fatalf("GetMetadataContext failed: %v", err)
+	}
+	if meta.Language != "fr" {
+		t.Errorf("Language = %q, want fr", meta.Language)
+	}
+
+	// A language in the metadata is kept as is
+	tagged := untaggedEPUB(t, "<dc:language>de</dc:language>", french)
+	meta, err = c.GetMetadataContext(context.Background(), tagged)
+	if err != nil {
+		t.Fatalf("GetMetadataContext failed: %v", err)
+	}
+	if meta.Language != "de" {
+		t.Errorf("Language = %q, want the recorded de", meta.Language)
+	}
+}
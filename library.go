@@ -0,0 +1,210 @@
+package calibre
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/anilpdv/go-calibre/models"
+)
+
+// addedIDsLine matches calibredb add's "Added book ids: 1, 2" line
+var addedIDsLine = regexp.MustCompile(`(?m)^Added book ids?:[ \t]*([\d, ]*)`)
+
+// libraryFields are the calibredb list fields read into models.Book
+const libraryFields = "title,authors,series,series_index,tags,identifiers"
+
+// libraryEntry is one book in calibredb's --for-machine JSON
+type libraryEntry struct {
+	ID          int               `json:"id"`
+	Title       string            `json:"title"`
+	Authors     string            `json:"authors"` // joined with " & "
+	Series      string            `json:"series"`
+	SeriesIndex float64           `json:"series_index"`
+	Tags        []string          `json:"tags"`
+	Identifiers map[string]string `json:"identifiers"`
+}
+
+// ListLibraryBooks lists the books in a Calibre library using calibredb
+func (c *Calibre) ListLibraryBooks(ctx context.Context, libraryPath string) ([]models.Book, error) {
+	return c.ListLibraryBooksWithColumns(ctx, libraryPath, nil)
+}
+
+// ListLibraryBooksWithColumns lists a library's books like ListLibraryBooks,
+// also reading the given custom columns (lookup names such as "#shelf") into
+// each book's CustomColumns. Columns a book has no value for are left out of
+// its map. Multi-value columns are joined with ", ".
+func (c *Calibre) ListLibraryBooksWithColumns(ctx context.Context, libraryPath string, columns []string) ([]models.Book, error) {
+	fields := libraryFields
+	for _, col := range columns {
+		if len(col) < 2 || col[0] != '#' || strings.ContainsAny(col, ", ") {
+			return nil, fmt.Errorf("invalid custom column %q: lookup names start with '#'", col)
+		}
+		// calibredb names custom fields *name rather than #name
+		fields += ",*" + col[1:]
+	}
+
+	if err := c.RequireTool("calibredb"); err != nil {
+		return nil, err
+	}
+
+	output, err := c.runCommand(ctx, c.calibredb, "list",
+		"--for-machine",
+		"--fields", fields,
+		"--library-path", libraryPath,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("calibredb list failed: %w", err)
+	}
+
+	return parseLibraryList(output, columns)
+}
+
+// parseLibraryList decodes calibredb's JSON listing, skipping any log lines
+// printed before it, and reads the given custom columns
+func parseLibraryList(output []byte, columns []string) ([]models.Book, error) {
+	start := bytes.IndexByte(output, '[')
+	if start == -1 {
+		return nil, fmt.Errorf("%w library listing: no JSON in calibredb output", ErrParse)
+	}
+
+	var raw []json.RawMessage
+	if err := json.Unmarshal(output[start:], &raw); err != nil {
+		return nil, fmt.Errorf("%w library listing: %w", ErrParse, err)
+	}
+
+	books := make([]models.Book, 0, len(raw))
+	for _, data := range raw {
+		var e libraryEntry
+		if err := json.Unmarshal(data, &e); err != nil {
+			return nil, fmt.Errorf("%w library listing: %w", ErrParse, err)
+		}
+
+		book := models.Book{
+			Title:       e.Title,
+			Series:      e.Series,
+			SeriesIndex: e.SeriesIndex,
+			Tags:        e.Tags,
+			Identifiers: e.Identifiers,
+			ISBN:        e.Identifiers["isbn"],
+		}
+		if e.Authors != "" {
+			book.Authors = strings.Split(e.Authors, " & ")
+		}
+
+		if len(columns) > 0 {
+			var fields map[string]json.RawMessage
+			if err := json.Unmarshal(data, &fields); err != nil {
+				return nil, fmt.Errorf("%w library listing: %w", ErrParse, err)
+			}
+			for _, col := range columns {
+				if value, ok := customColumnValue(fields["*"+col[1:]]); ok {
+					if book.CustomColumns == nil {
+						book.CustomColumns = make(map[string]string)
+					}
+					book.CustomColumns[col] = value
+				}
+			}
+		}
+
+		books = append(books, book)
+	}
+
+	return books, nil
+}
+
+// customColumnValue formats a custom column's JSON value as a string,
+// reporting false for a missing, null or empty value
+func customColumnValue(data json.RawMessage) (string, bool) {
+	var value any
+	if len(data) == 0 || json.Unmarshal(data, &value) != nil {
+		return "", false
+	}
+	return formatColumnValue(value)
+}
+
+// formatColumnValue formats a decoded JSON value, joining lists with ", "
+func formatColumnValue(value any) (string, bool) {
+	switch v := value.(type) {
+	case string:
+		return v, v != ""
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case bool:
+		return strconv.FormatBool(v), true
+	case []any:
+		var parts []string
+		for _, item := range v {
+			if s, ok := formatColumnValue(item); ok {
+				parts = append(parts, s)
+			}
+		}
+		return strings.Join(parts, ", "), len(parts) > 0
+	}
+	return "", false
+}
+
+// AddToLibrary adds an ebook to a Calibre library and returns its new book
+// id. A book the library already has returns ErrDuplicateInLibrary.
+func (c *Calibre) AddToLibrary(ctx context.Context, libraryPath, ebookPath string) (int, error) {
+	ids, err := c.AddManyToLibrary(ctx, libraryPath, []string{ebookPath})
+	if err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, fmt.Errorf("calibredb add reported no book id for %s", ebookPath)
+	}
+	return ids[0], nil
+}
+
+// AddManyToLibrary adds several ebooks to a Calibre library in a single
+// calibredb call and returns the new book ids. If some books already exist,
+// the ids of those that were added are returned along with an error wrapping
+// ErrDuplicateInLibrary.
+func (c *Calibre) AddManyToLibrary(ctx context.Context, libraryPath string, ebookPaths []string) ([]int, error) {
+	if err := c.RequireTool("calibredb"); err != nil {
+		return nil, err
+	}
+	if len(ebookPaths) == 0 {
+		return nil, nil
+	}
+
+	args := append([]string{"add", "--library-path", libraryPath}, ebookPaths...)
+	output, err := c.runCommand(ctx, c.calibredb, args...)
+	if err != nil {
+		return nil, fmt.Errorf("calibredb add failed: %w", err)
+	}
+
+	ids, err := parseAddedIDs(output)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.Contains(string(output), "already exist") {
+		return ids, fmt.Errorf("%w: %d of %d books not added", ErrDuplicateInLibrary, len(ebookPaths)-len(ids), len(ebookPaths))
+	}
+
+	return ids, nil
+}
+
+// parseAddedIDs reads the book ids from calibredb add's output
+func parseAddedIDs(output []byte) ([]int, error) {
+	m := addedIDsLine.FindSubmatch(output)
+	if m == nil {
+		return nil, nil
+	}
+
+	var ids []int
+	for _, field := range strings.FieldsFunc(string(m[1]), func(r rune) bool { return r == ',' || r == ' ' }) {
+		id, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, fmt.Errorf("%w book id %q: %w", ErrParse, field, err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
@@ -0,0 +1,169 @@
+package calibre
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestListLibraryBooks(t *testing.T) {
+	var gotArgs []string
+	c := &Calibre{
+		Timeout:   DefaultTimeout,
+		calibredb: "calibredb",
+		Runner: func(ctx context.Context, name string, args ...string) ([]byte, error) {
+			gotArgs = args
+			return []byte(`Using library at /books
+[
+  {"authors": "Terry Pratchett & Neil Gaiman", "id": 1, "identifiers": {"isbn": "9780575048003"},
+   "series": "", "series_index": 1.0, "tags": ["Fantasy"], "title": "Good Omens"},
+  {"authors": "Ursula K. Le Guin", "id": 2, "identifiers": {},
+   "series": "Earthsea", "series_index": 2.0, "tags": [], "title": "The Tombs of Atuan"}
+]`), nil
+		},
+	}
+
+	books, err := c.ListLibraryBooks(context.Background(), "/books")
+	if err != nil {
+		t.Fatalf("ListLibraryBooks failed: %v", err)
+	}
+
+	wantArgs := []string{"list", "--for-machine", "--fields", libraryFields, "--library-path", "/books"}
+	if !reflect.DeepEqual(gotArgs, wantArgs) {
+		t.Errorf("args = %q", gotArgs)
+	}
+	if len(books) != 2 {
+		t.Fatalf("expected 2 books, got %d", len(books))
+	}
+	if !reflect.DeepEqual(books[0].Authors, []string{"Terry Pratchett", "Neil Gaiman"}) || books[0].ISBN != "9780575048003" {
+		t.Errorf("book 0 = %+v", books[0])
+	}
+	if books[1].Series != "Earthsea" || books[1].SeriesIndex != 2 {
+		t.Errorf("book 1 = %+v", books[1])
+	}
+}
+
+func TestListLibraryBooksEmpty(t *testing.T) {
+	c := &Calibre{
+		Timeout:   DefaultTimeout,
+		calibredb: "calibredb",
+		Runner: func(ctx context.Context, name string, args ...string) ([]byte, error) {
+			return []byte("[]\n"), nil
+		},
+	}
+
+	books, err := c.ListLibraryBooks(context.Background(), "/books")
+	if err != nil {
+		t.Fatalf("ListLibraryBooks failed: %v", err)
+	}
+	if books == nil || len(books) != 0 {
+		t.Errorf("expected an empty, non-nil list, got %#v", books)
+	}
+}
+
+func TestListLibraryBooksNoCalibredb(t *testing.T) {
+	c := &Calibre{Timeout: DefaultTimeout}
+
+	_, err := c.ListLibraryBooks(context.Background(), "/books")
+	var missing *MissingToolError
+	if !errors.As(err, &missing) || missing.Tool != "calibredb" {
+		t.Errorf("expected MissingToolError for calibredb, got %v", err)
+	}
+}
+
+func TestAddToLibrary(t *testing.T) {
+	var gotArgs []string
+	c := &Calibre{
+		Timeout:   DefaultTimeout,
+		calibredb: "calibredb",
+		Runner: func(ctx context.Context, name string, args ...string) ([]byte, error) {
+			gotArgs = args
+			if len(args) > 4 {
+				return []byte("Added book ids: 12, 13\n"), nil
+			}
+			return []byte("Added book ids: 7\n"), nil
+		},
+	}
+
+	id, err := c.AddToLibrary(context.Background(), "/books", "new.epub")
+	if err != nil || id != 7 {
+		t.Fatalf("AddToLibrary = %d, %v", id, err)
+	}
+	if !reflect.DeepEqual(gotArgs, []string{"add", "--library-path", "/books", "new.epub"}) {
+		t.Errorf("args = %q", gotArgs)
+	}
+
+	ids, err := c.AddManyToLibrary(context.Background(), "/books", []string{"a.epub", "b.epub"})
+	if err != nil || !reflect.DeepEqual(ids, []int{12, 13}) {
+		t.Errorf("AddManyToLibrary = %v, %v", ids, err)
+	}
+}
+
+func TestAddToLibraryDuplicate(t *testing.T) {
+	c := &Calibre{
+		Timeout:   DefaultTimeout,
+		calibredb: "calibredb",
+		Runner: func(ctx context.Context, name string, args ...string) ([]byte, error) {
+			return []byte("The following books were not added as they already exist in the database (see --duplicates option):\n  Good Omens\n    /tmp/a.epub\nAdded book ids: 14\n"), nil
+		},
+	}
+
+	ids, err := c.AddManyToLibrary(context.Background(), "/books", []string{"a.epub", "b.epub"})
+	if !errors.Is(err, ErrDuplicateInLibrary) {
+		t.Errorf("expected ErrDuplicateInLibrary, got %v", err)
+	}
+	if !reflect.DeepEqual(ids, []int{14}) {
+		t.Errorf("ids = %v, want the book that was added", ids)
+	}
+
+	c.Runner = func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		return []byte("The following books were not added as they already exist in the database (see --duplicates option):\n  Good Omens\n"), nil
+	}
+	if _, err := c.AddToLibrary(context.Background(), "/books", "a.epub"); !errors.Is(err, ErrDuplicateInLibrary) {
+		t.Errorf("AddToLibrary: expected ErrDuplicateInLibrary, got %v", err)
+	}
+}
+
+func TestListLibraryBooksWithColumns(t *testing.T) {
+	var gotArgs []string
+	c := &Calibre{
+		Timeout:   DefaultTimeout,
+		calibredb: "calibredb",
+		Runner: func(ctx context.Context, name string, args ...string) ([]byte, error) {
+			gotArgs = args
+			return []byte(`[
+  {"authors": "Ann Writer", "id": 1, "title": "Read One",
+   "*read_status": "finished", "*shelf": ["Office", "Favorites"], "*pages": 320},
+  {"authors": "Ann Writer", "id": 2, "title": "Unread", "*shelf": []},
+  {"authors": "Ann Writer", "id": 3, "title": "Partly", "*read_status": null, "*shelf": "", "*pages": 0}
+]`), nil
+		},
+	}
+
+	books, err := c.ListLibraryBooksWithColumns(context.Background(), "/books", []string{"#read_status", "#shelf", "#pages"})
+	if err != nil {
+		t.Fatalf("ListLibraryBooksWithColumns failed: %v", err)
+	}
+
+	if gotArgs[3] != libraryFields+",*read_status,*shelf,*pages" {
+		t.Errorf("--fields = %q", gotArgs[3])
+	}
+
+	want := []map[string]string{
+		{"#read_status": "finished", "#shelf": "Office, Favorites", "#pages": "320"},
+		nil,
+		{"#pages": "0"},
+	}
+	for i, book := range books {
+		if !reflect.DeepEqual(book.CustomColumns, want[i]) {
+			t.Errorf("book %d CustomColumns = %q, want %q", i, book.CustomColumns, want[i])
+		}
+	}
+
+	for _, bad := range []string{"read_status", "#", "#a,b"} {
+		if _, err := c.ListLibraryBooksWithColumns(context.Background(), "/books", []string{bad}); err == nil {
+			t.Errorf("expected an error for column %q", bad)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package calibre
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/anilpdv/go-calibre/models"
+)
+
+// DefaultMergeSeparator starts each book after the first on a new page
+const DefaultMergeSeparator = `<div style="page-break-before: always"></div>`
+
+// MergeOptions configures Merge
+type MergeOptions struct {
+	// BookHeadings inserts each source book's title, from its metadata, as
+	// a heading before its chapters
+	BookHeadings bool
+
+	// Separator is HTML inserted between books (defaults to
+	// DefaultMergeSeparator)
+	Separator string
+
+	// KeepFirstMetadata gives the merged book the first input's metadata
+	// and cover
+	KeepFirstMetadata bool
+
+	// Title is the merged book's title, overriding KeepFirstMetadata's
+	Title string
+}
+
+// Merge combines several ebooks into one, such as the installments of a
+// serialized novel. ebook-convert can't merge, so each input's chapters are
+// extracted and concatenated into a single HTML file, which is converted to
+// the format given by outputPath's extension. Only the chapter text is
+// carried over; images and styling are not.
+func (c *Calibre) Merge(ctx context.Context, inputs []string, outputPath string, opts MergeOptions) error {
+	if len(inputs) < 2 {
+		return fmt.Errorf("merge needs at least two inputs, got %d", len(inputs))
+	}
+	if c.ebookConvert == "" {
+		return toolNotFound("ebook-convert")
+	}
+
+	tmpDir, err := c.mkdirTemp("calibre-merge-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	separator := opts.Separator
+	if separator == "" {
+		separator = DefaultMergeSeparator
+	}
+
+	var body strings.Builder
+	var args []string
+	for i, input := range inputs {
+		chapters, err := c.ExtractChaptersContext(ctx, input)
+		if err != nil {
+			return fmt.Errorf("failed to extract chapters from %s: %w", filepath.Base(input), err)
+		}
+
+		var meta *models.Metadata
+		if opts.BookHeadings || (i == 0 && opts.KeepFirstMetadata) {
+			if meta, err = c.GetMetadataContext(ctx, input); err != nil {
+				return fmt.Errorf("failed to read metadata of %s: %w", filepath.Base(input), err)
+			}
+		}
+
+		if i > 0 {
+			body.WriteString(separator + "\n")
+		}
+		if opts.BookHeadings {
+			title := meta.Title
+			if title == "" {
+				title = strings.TrimSuffix(filepath.Base(input), filepath.Ext(input))
+			}
+			fmt.Fprintf(&body, "<h1>%s</h1>\n", html.EscapeString(title))
+		}
+		for _, ch := range chapters {
+			writeChapterHTML(&body, ch)
+		}
+
+		if i == 0 && opts.KeepFirstMetadata {
+			args = append(args, convertMetadataArgs(meta)...)
+			if cover, err := c.writeMergeCover(ctx, input, tmpDir); err != nil {
+				return err
+			} else if cover != "" {
+				args = append(args, "--cover", cover)
+			}
+		}
+	}
+
+	if opts.Title != "" {
+		args = append(args, "--title", opts.Title)
+	}
+
+	// Book headings, when present, form the top level of the TOC
+	if opts.BookHeadings {
+		args = append(args, "--level1-toc", "//h:h1", "--level2-toc", "//h:h2")
+	} else {
+		args = append(args, "--level1-toc", "//h:h2")
+	}
+
+	htmlPath := filepath.Join(tmpDir, "merged.html")
+	doc := "<!DOCTYPE html>\n<html><head><meta charset=\"utf-8\"/><title>" +
+		html.EscapeString(opts.Title) + "</title></head><body>\n" + body.String() + "</body></html>\n"
+	if err := os.WriteFile(htmlPath, []byte(doc), 0644); err != nil {
+		return fmt.Errorf("failed to write merged HTML: %w", err)
+	}
+
+	return c.Convert(ctx, htmlPath, outputPath, ConvertOptions{ExtraArgs: args})
+}
+
+// writeChapterHTML writes a chapter as a heading followed by a paragraph per
+// block of its plain text
+func writeChapterHTML(b *strings.Builder, ch models.Chapter) {
+	fmt.Fprintf(b, "<h2>%s</h2>\n", html.EscapeString(ch.Title))
+	for _, para := range strings.Split(ch.Content, "\n\n") {
+		if para = strings.TrimSpace(para); para != "" {
+			fmt.Fprintf(b, "<p>%s</p>\n", html.EscapeString(para))
+		}
+	}
+}
+
+// writeMergeCover saves a book's cover to dir for the merged book, returning
+// "" when the book has none
+func (c *Calibre) writeMergeCover(ctx context.Context, ebookPath, dir string) (string, error) {
+	data, mimeType, err := c.ExtractCoverData(ctx, ebookPath)
+	if errors.Is(err, ErrNoCover) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(dir, "cover."+strings.TrimPrefix(mimeType, "image/"))
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write cover: %w", err)
+	}
+	return path, nil
+}
+
+// convertMetadataArgs translates populated Metadata fields into the
+// ebook-convert flags that set the output's metadata
+func convertMetadataArgs(meta *models.Metadata) []string {
+	var args []string
+
+	add := func(flag, value string) {
+		if value != "" {
+			args = append(args, flag, value)
+		}
+	}
+
+	add("--title", meta.Title)
+	add("--authors", strings.Join(meta.Authors, " & "))
+	add("--author-sort", meta.AuthorSort)
+	add("--publisher", meta.Publisher)
+	add("--pubdate", meta.PublishDate)
+	add("--language", meta.Language)
+	add("--series", meta.Series)
+	if meta.SeriesIndex != 0 {
+		add("--series-index", strconv.FormatFloat(meta.SeriesIndex, 'f', -1, 64))
+	}
+	add("--comments", meta.Description)
+	add("--tags", strings.Join(meta.Tags, ","))
+	add("--isbn", meta.ISBN)
+
+	return args
+}
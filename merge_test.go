@@ -0,0 +1,122 @@
+package calibre
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// mergeInputEPUB returns an EPUB titled title with three chapters whose text
+// starts with word
+func mergeInputEPUB(t *testing.T, title, word string) string {
+	t.Helper()
+	return writeTestEPUB(t, map[string]string{
+		"META-INF/container.xml": testContainer("content.opf"),
+		"content.opf": testOPF(
+			`<dc:title>`+title+`</dc:title>`,
+			`<item id="ncx" href="toc.ncx" media-type="application/x-dtbncx+xml"/>`,
+			"",
+		),
+		"toc.ncx": testNCX(
+			[2]string{"Chapter 1", "ch1.xhtml"},
+			[2]string{"Chapter 2", "ch2.xhtml"},
+			[2]string{"Chapter 3", "ch3.xhtml"},
+		),
+		"ch1.xhtml": testXHTML("<p>" + loremWords(word+"1", 60) + "</p>"),
+		"ch2.xhtml": testXHTML("<p>" + loremWords(word+"2", 60) + "</p>"),
+		"ch3.xhtml": testXHTML("<p>" + loremWords(word+"3", 60) + "</p>"),
+	})
+}
+
+// mergeRunner records ebook-convert's arguments and the merged HTML it was
+// given, passing other commands to next
+func mergeRunner(args *[]string, merged *string, next CommandRunner) CommandRunner {
+	return func(ctx context.Context, name string, a ...string) ([]byte, error) {
+		if name != "ebook-convert" {
+			return next(ctx, name, a...)
+		}
+		*args = a
+		data, err := os.ReadFile(a[0])
+		*merged = string(data)
+		return nil, err
+	}
+}
+
+func TestMerge(t *testing.T) {
+	first := mergeInputEPUB(t, "Part One", "Alpha")
+	second := mergeInputEPUB(t, "Part Two", "Beta")
+
+	output := filepath.Join(t.TempDir(), "novel.epub")
+
+	var args []string
+	var merged string
+	c := &Calibre{Timeout: DefaultTimeout, ebookConvert: "ebook-convert"}
+	c.Runner = mergeRunner(&args, &merged, execCommand)
+
+	err := c.Merge(context.Background(), []string{first, second}, output, MergeOptions{
+		BookHeadings: true,
+		Separator:    "<hr/>",
+		Title:        "The Whole Novel",
+	})
+	if err != nil {
+		t.Fatalf("Merge failed: %v", err)
+	}
+
+	if !strings.HasSuffix(args[0], ".html") || args[1] != output {
+		t.Errorf("ebook-convert should convert the merged HTML to the output, got %q", args)
+	}
+	if !strings.Contains(strings.Join(args, " "), "--title The Whole Novel") {
+		t.Errorf("expected --title in %q", args)
+	}
+
+	// Both books' chapters appear in order, each book under its heading
+	var last int
+	for _, want := range []string{"<h1>Part One</h1>", "Alpha1", "Alpha3", "<hr/>", "<h1>Part Two</h1>", "Beta1", "Beta3"} {
+		i := strings.Index(merged, want)
+		if i < last {
+			t.Fatalf("merged HTML is missing %q after offset %d:\n%s", want, last, merged)
+		}
+		last = i
+	}
+	if n := strings.Count(merged, "<h2>"); n != 6 {
+		t.Errorf("expected 6 chapter headings, got %d", n)
+	}
+}
+
+func TestMergeKeepFirstMetadata(t *testing.T) {
+	first := mergeInputEPUB(t, "Part One", "Alpha")
+	second := mergeInputEPUB(t, "Part Two", "Beta")
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+	var args []string
+	var merged string
+	c := &Calibre{Timeout: DefaultTimeout, ebookConvert: "ebook-convert", ebookMeta: "ebook-meta"}
+	c.Runner = mergeRunner(&args, &merged, coverRunner(png))
+
+	if err := c.Merge(context.Background(), []string{first, second}, filepath.Join(t.TempDir(), "novel.azw3"), MergeOptions{KeepFirstMetadata: true}); err != nil {
+		t.Fatalf("Merge failed: %v", err)
+	}
+
+	joined := strings.Join(args, " ")
+	if !strings.Contains(joined, "--title Covered") {
+		t.Errorf("expected the first book's title in %q", args)
+	}
+	if !strings.Contains(joined, "--cover ") || !strings.Contains(joined, "cover.png") {
+		t.Errorf("expected the first book's cover in %q", args)
+	}
+	if strings.Contains(merged, "<h1>") {
+		t.Error("book headings should only be added with BookHeadings")
+	}
+	if !strings.Contains(merged, DefaultMergeSeparator) {
+		t.Error("books should be separated by DefaultMergeSeparator")
+	}
+}
+
+func TestMergeNeedsTwoInputs(t *testing.T) {
+	c := &Calibre{Timeout: DefaultTimeout, ebookConvert: "ebook-convert"}
+	if err := c.Merge(context.Background(), []string{"one.epub"}, "out.epub", MergeOptions{}); err == nil {
+		t.Error("expected an error merging a single book")
+	}
+}
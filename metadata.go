@@ -1,10 +1,16 @@
 package calibre
 
 import (
+	"archive/zip"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/anilpdv/go-calibre/models"
 	"github.com/anilpdv/go-calibre/opf"
@@ -15,44 +21,201 @@ func (c *Calibre) GetMetadata(ebookPath string) (*models.Metadata, error) {
 	return c.GetMetadataContext(context.Background(), ebookPath)
 }
 
-// GetMetadataContext extracts metadata with context for cancellation
+// GetMetadataContext extracts metadata with context for cancellation.
+// Without ebook-meta, EPUBs are read natively with GetMetadataNative.
+// With DetectMissingLanguage set, an empty or undetermined ("und") language
+// is filled in by DetectLanguage where it can be.
 func (c *Calibre) GetMetadataContext(ctx context.Context, ebookPath string) (*models.Metadata, error) {
+	meta, err := c.readMetadata(ctx, ebookPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.DetectMissingLanguage && (meta.Language == "" || meta.Language == "und") {
+		if lang, err := c.DetectLanguage(ctx, ebookPath); err == nil {
+			meta.Language = lang
+		}
+	}
+
+	return meta, nil
+}
+
+// readMetadata reads the metadata recorded in an ebook
+func (c *Calibre) readMetadata(ctx context.Context, ebookPath string) (*models.Metadata, error) {
+	if c.ebookMeta == "" {
+		if format, _ := DetectFormat(ebookPath); isEPUB(ebookPath) || format == "epub" {
+			return GetMetadataNative(ebookPath)
+		}
+		return nil, toolNotFound("ebook-meta")
+	}
+
+	raw, err := c.GetMetadataRaw(ctx, ebookPath)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := opf.ParseBytes(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w OPF: %w", ErrParse, err)
+	}
+
+	return metadataFromParsed(parsed), nil
+}
+
+// GetMetadataRaw returns the OPF document ebook-meta --to-opf produces for an
+// ebook, for fields GetMetadata doesn't model, such as custom Calibre
+// columns. Without ebook-meta, an EPUB's own OPF is returned via GetOPFBytes.
+func (c *Calibre) GetMetadataRaw(ctx context.Context, ebookPath string) ([]byte, error) {
+	if c.ebookMeta == "" {
+		if format, _ := DetectFormat(ebookPath); isEPUB(ebookPath) || format == "epub" {
+			return GetOPFBytes(ebookPath)
+		}
+		return nil, toolNotFound("ebook-meta")
+	}
+
 	// Create temp file for OPF output
-	tmpFile, err := os.CreateTemp("", "calibre-meta-*.opf")
+	tmpFile, err := c.createTemp("calibre-meta-*.opf")
 	if err != nil {
-		return nil, fmt.Errorf("failed to create temp file: %w", err)
+		return nil, err
 	}
 	tmpPath := tmpFile.Name()
 	tmpFile.Close()
 	defer os.Remove(tmpPath)
 
 	// Run ebook-meta to extract metadata to OPF
-	_, err = c.runCommand(ctx, c.ebookMeta, ebookPath, "--to-opf", tmpPath)
+	output, err := c.runCommand(ctx, c.ebookMeta, ebookPath, "--to-opf", tmpPath)
 	if err != nil {
+		if drmErr := checkDRM(ebookPath); drmErr != nil {
+			return nil, drmErr
+		}
 		return nil, fmt.Errorf("ebook-meta failed: %w", err)
 	}
 
-	// Parse the OPF file
-	parsed, err := opf.ParseFile(tmpPath)
+	data, err := os.ReadFile(tmpPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OPF output: %w", err)
+	}
+
+	// Some Calibre versions leave the file empty and print the OPF instead
+	if len(data) == 0 {
+		return opfOutput(output)
+	}
+	return data, nil
+}
+
+// GetOPFBytes returns an EPUB's package document exactly as stored in the
+// zip, without running Calibre
+func GetOPFBytes(epubPath string) ([]byte, error) {
+	r, err := zip.OpenReader(epubPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open EPUB: %w", err)
+	}
+	defer r.Close()
+
+	opfPath, err := opf.FindOPFPath(&r.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := r.Open(opfPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open OPF %s: %w", opfPath, err)
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OPF %s: %w", opfPath, err)
+	}
+	return data, nil
+}
+
+// GetMetadataFromReader extracts metadata from an ebook read from r, such as
+// an HTTP upload. The data is written to a temp file whose extension is taken
+// from format (e.g. "epub"), since Calibre picks the input format from it.
+func (c *Calibre) GetMetadataFromReader(ctx context.Context, r io.Reader, format string) (*models.Metadata, error) {
+	format, err := cleanFormat(format)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpFile, err := c.createTemp("calibre-upload-*." + format)
+	if err != nil {
+		return nil, err
+	}
+	tmpPath := tmpFile.Name()
+	defer os.Remove(tmpPath)
+
+	_, err = io.Copy(tmpFile, r)
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to write temp file: %w", err)
+	}
+
+	return c.GetMetadataContext(ctx, tmpPath)
+}
+
+// GetMetadataNative reads an EPUB's metadata straight from the OPF inside the
+// zip, located via META-INF/container.xml. No Calibre tools are needed.
+func GetMetadataNative(epubPath string) (*models.Metadata, error) {
+	data, err := GetOPFBytes(epubPath)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := opf.ParseBytes(data)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse OPF: %w", err)
+		return nil, fmt.Errorf("%w OPF: %w", ErrParse, err)
 	}
 
-	// Convert to our Metadata struct
+	return metadataFromParsed(parsed), nil
+}
+
+// metadataFromParsed converts parsed OPF metadata to our Metadata struct
+func metadataFromParsed(parsed *opf.ParsedMetadata) *models.Metadata {
 	return &models.Metadata{
-		Title:       parsed.Title,
-		Authors:     parsed.Authors,
-		AuthorSort:  parsed.AuthorSort,
-		Publisher:   parsed.Publisher,
-		PublishDate: parsed.PublishDate.Format("2006-01-02"),
-		Language:    parsed.Language,
-		ISBN:        parsed.ISBN,
-		Identifiers: parsed.Identifiers,
-		Tags:        parsed.Tags,
-		Series:      parsed.Series,
-		SeriesIndex: parsed.SeriesIndex,
-		Description: parsed.Description,
-	}, nil
+		Title:        parsed.Title,
+		Authors:      parsed.Authors,
+		AuthorSort:   parsed.AuthorSort,
+		Publisher:    parsed.Publisher,
+		PublishDate:  parsed.PublishDate.Format("2006-01-02"),
+		Language:     parsed.Language,
+		ISBN:         parsed.ISBN,
+		Identifiers:  parsed.Identifiers,
+		Tags:         parsed.Tags,
+		Series:       parsed.Series,
+		SeriesIndex:  parsed.SeriesIndex,
+		Description:  parsed.Description,
+		Rating:       parsed.Rating,
+		Comments:     parsed.Comments,
+		BookProducer: parsed.BookProducer,
+	}
+}
+
+// parseOPFOutput parses OPF XML printed to stdout, skipping any log lines before it
+func parseOPFOutput(output []byte) (*opf.ParsedMetadata, error) {
+	data, err := opfOutput(output)
+	if err != nil {
+		return nil, err
+	}
+	return opf.ParseBytes(data)
+}
+
+// opfOutput returns the OPF XML printed to stdout, without any log lines
+// before it
+func opfOutput(output []byte) ([]byte, error) {
+	text := string(output)
+	start := strings.Index(text, "<?xml")
+	if start == -1 {
+		start = strings.Index(text, "<package")
+	}
+	if start == -1 {
+		return nil, fmt.Errorf("OPF file is empty and no OPF found in command output")
+	}
+
+	return []byte(text[start:]), nil
 }
 
 // ExtractCover extracts the cover image from an ebook
@@ -60,8 +223,16 @@ func (c *Calibre) ExtractCover(ebookPath, outputPath string) error {
 	return c.ExtractCoverContext(context.Background(), ebookPath, outputPath)
 }
 
-// ExtractCoverContext extracts cover with context for cancellation
+// ExtractCoverContext extracts cover with context for cancellation. Without
+// ebook-meta, an EPUB's cover is extracted natively with ExtractCoverNative.
 func (c *Calibre) ExtractCoverContext(ctx context.Context, ebookPath, outputPath string) error {
+	if c.ebookMeta == "" {
+		if format, _ := DetectFormat(ebookPath); isEPUB(ebookPath) || format == "epub" {
+			return ExtractCoverNative(ebookPath, outputPath)
+		}
+		return toolNotFound("ebook-meta")
+	}
+
 	// Ensure output directory exists
 	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
 		return fmt.Errorf("failed to create output directory: %w", err)
@@ -74,8 +245,9 @@ func (c *Calibre) ExtractCoverContext(ctx context.Context, ebookPath, outputPath
 	}
 
 	// Verify cover was created
-	if _, err := os.Stat(outputPath); os.IsNotExist(err) {
-		return fmt.Errorf("cover extraction produced no output (book may have no cover)")
+	if info, err := os.Stat(outputPath); os.IsNotExist(err) || (err == nil && info.Size() == 0) {
+		os.Remove(outputPath)
+		return fmt.Errorf("%w: extraction produced no output", ErrNoCover)
 	}
 
 	return nil
@@ -86,8 +258,19 @@ func (c *Calibre) GetBook(ebookPath string) (*models.Book, error) {
 	return c.GetBookContext(context.Background(), ebookPath)
 }
 
+// BookOptions configures what GetBookWithOptions loads
+type BookOptions struct {
+	// IncludeCover loads the cover image into Book.CoverData
+	IncludeCover bool
+}
+
 // GetBookContext extracts book with context
 func (c *Calibre) GetBookContext(ctx context.Context, ebookPath string) (*models.Book, error) {
+	return c.GetBookWithOptions(ctx, ebookPath, BookOptions{})
+}
+
+// GetBookWithOptions extracts book info with custom options
+func (c *Calibre) GetBookWithOptions(ctx context.Context, ebookPath string, opts BookOptions) (*models.Book, error) {
 	// Get metadata first
 	meta, err := c.GetMetadataContext(ctx, ebookPath)
 	if err != nil {
@@ -109,5 +292,94 @@ func (c *Calibre) GetBookContext(ctx context.Context, ebookPath string) (*models
 		Format:      filepath.Ext(ebookPath),
 	}
 
+	// Trust the content over a missing or wrong extension
+	if format, err := DetectFormat(ebookPath); err == nil {
+		book.Format = "." + format
+	}
+
+	if opts.IncludeCover {
+		data, _, err := c.ExtractCoverData(ctx, ebookPath)
+		if err != nil && !errors.Is(err, ErrNoCover) {
+			return nil, err
+		}
+		book.CoverData = data
+	}
+
 	return book, nil
 }
+
+// SetMetadata writes metadata into an ebook file using ebook-meta.
+// Only non-empty fields are written, so a partially filled Metadata updates
+// just those fields and leaves the rest of the book's metadata intact.
+func (c *Calibre) SetMetadata(ctx context.Context, ebookPath string, meta *models.Metadata) error {
+	if meta == nil {
+		return fmt.Errorf("metadata is nil")
+	}
+
+	args := append([]string{ebookPath}, setMetadataArgs(meta)...)
+	if len(args) == 1 {
+		return nil
+	}
+	if c.ebookMeta == "" {
+		return toolNotFound("ebook-meta")
+	}
+
+	_, err := c.runCommand(ctx, c.ebookMeta, args...)
+	if err != nil {
+		return fmt.Errorf("ebook-meta failed: %w", err)
+	}
+
+	return nil
+}
+
+// setMetadataArgs translates populated Metadata fields into ebook-meta flags
+func setMetadataArgs(meta *models.Metadata) []string {
+	var args []string
+
+	add := func(flag, value string) {
+		if value != "" {
+			args = append(args, flag, value)
+		}
+	}
+
+	add("--title", meta.Title)
+	// Calibre separates multiple authors with "&"
+	add("--authors", strings.Join(meta.Authors, " & "))
+	add("--author-sort", meta.AuthorSort)
+	add("--publisher", meta.Publisher)
+	add("--date", meta.PublishDate)
+	add("--language", meta.Language)
+	add("--series", meta.Series)
+	if meta.SeriesIndex != 0 {
+		add("--index", strconv.FormatFloat(meta.SeriesIndex, 'f', -1, 64))
+	}
+	if meta.Rating != 0 {
+		add("--rating", strconv.Itoa(meta.Rating))
+	}
+	// Comments is what Calibre shows; the description stands in without it
+	if meta.Comments != "" {
+		add("--comments", meta.Comments)
+	} else {
+		add("--comments", meta.Description)
+	}
+	add("--book-producer", meta.BookProducer)
+
+	add("--tags", strings.Join(meta.Tags, ","))
+
+	// --identifier may be repeated, one type:value pair each
+	if meta.ISBN != "" {
+		add("--identifier", "isbn:"+meta.ISBN)
+	}
+	schemes := make([]string, 0, len(meta.Identifiers))
+	for scheme := range meta.Identifiers {
+		if scheme != "isbn" || meta.ISBN == "" {
+			schemes = append(schemes, scheme)
+		}
+	}
+	sort.Strings(schemes)
+	for _, scheme := range schemes {
+		add("--identifier", scheme+":"+meta.Identifiers[scheme])
+	}
+
+	return args
+}
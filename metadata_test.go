@@ -0,0 +1,262 @@
+package calibre
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/anilpdv/go-calibre/models"
+)
+
+func TestGetMetadataOPFOnStdout(t *testing.T) {
+	opfXML := testOPF(`<dc:title>Stdout Book</dc:title><dc:creator opf:role="aut">Jane Doe</dc:creator>`, "", "")
+
+	c := &Calibre{
+		Timeout:   DefaultTimeout,
+		ebookMeta: "ebook-meta",
+		Runner: func(ctx context.Context, name string, args ...string) ([]byte, error) {
+			// Leave the --to-opf file empty and print the OPF instead
+			return []byte("Reading metadata...\n" + opfXML), nil
+		},
+	}
+
+	meta, err := c.GetMetadataContext(context.Background(), "book.epub")
+	if err != nil {
+		t.Fatalf("GetMetadataContext failed: %v", err)
+	}
+	if meta.Title != "Stdout Book" {
+		t.Errorf("Title = %q", meta.Title)
+	}
+	if len(meta.Authors) != 1 || meta.Authors[0] != "Jane Doe" {
+		t.Errorf("Authors = %v", meta.Authors)
+	}
+}
+
+func TestGetMetadataEmptyOPFNoOutput(t *testing.T) {
+	c := &Calibre{
+		Timeout:   DefaultTimeout,
+		ebookMeta: "ebook-meta",
+		Runner: func(ctx context.Context, name string, args ...string) ([]byte, error) {
+			return nil, nil
+		},
+	}
+
+	if _, err := c.GetMetadataContext(context.Background(), "book.epub"); err == nil {
+		t.Error("expected an error when neither file nor output contain OPF")
+	}
+}
+
+func TestSetMetadataArgs(t *testing.T) {
+	var got []string
+	c := &Calibre{
+		Timeout:   DefaultTimeout,
+		ebookMeta: "ebook-meta",
+		Runner: func(ctx context.Context, name string, args ...string) ([]byte, error) {
+			got = args
+			return nil, nil
+		},
+	}
+
+	err := c.SetMetadata(context.Background(), "book.epub", &models.Metadata{
+		Title:       "New Title",
+		Authors:     []string{"Jane Doe", "John Roe"},
+		Tags:        []string{"fiction", "classic", "space opera"},
+		Description: "A short blurb",
+		Comments:    "<p>A longer review</p>",
+		Series:      "Saga",
+		SeriesIndex: 2.5,
+		ISBN:        "9780306406157",
+		Identifiers: map[string]string{"isbn": "9780306406157", "goodreads": "123"},
+	})
+	if err != nil {
+		t.Fatalf("SetMetadata failed: %v", err)
+	}
+
+	want := []string{
+		"book.epub",
+		"--title", "New Title",
+		"--authors", "Jane Doe & John Roe",
+		"--series", "Saga",
+		"--index", "2.5",
+		"--comments", "<p>A longer review</p>",
+		"--tags", "fiction,classic,space opera",
+		"--identifier", "isbn:9780306406157",
+		"--identifier", "goodreads:123",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("args = %q\nwant   %q", got, want)
+	}
+}
+
+func TestSetMetadataRoundTrip(t *testing.T) {
+	c, err := New()
+	if err != nil {
+		t.Skipf("Calibre not installed: %v", err)
+	}
+
+	if c.ebookMeta == "" {
+		t.Skip("ebook-meta not found")
+	}
+
+	src := "/Users/anilpdv/Desktop/pg19942-images-3.epub"
+	data, err := os.ReadFile(src)
+	if err != nil {
+		t.Skip("Test file not found")
+	}
+	book := filepath.Join(t.TempDir(), "book.epub")
+	if err := os.WriteFile(book, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	err = c.SetMetadata(ctx, book, &models.Metadata{
+		Title:   "Round Trip",
+		Authors: []string{"Test Author"},
+	})
+	if err != nil {
+		t.Fatalf("SetMetadata failed: %v", err)
+	}
+
+	meta, err := c.GetMetadataContext(ctx, book)
+	if err != nil {
+		t.Fatalf("GetMetadata failed: %v", err)
+	}
+	if meta.Title != "Round Trip" {
+		t.Errorf("Title = %q", meta.Title)
+	}
+	if len(meta.Authors) != 1 || meta.Authors[0] != "Test Author" {
+		t.Errorf("Authors = %v", meta.Authors)
+	}
+}
+
+func TestGetMetadataNativeFallback(t *testing.T) {
+	epub := writeTestEPUB(t, map[string]string{
+		"META-INF/container.xml": testContainer("OEBPS/content.opf"),
+		"OEBPS/content.opf": testOPF(`<dc:title>Native Book</dc:title>
+<dc:creator opf:role="aut" opf:file-as="Doe, Jane">Jane Doe</dc:creator>
+<dc:language>en</dc:language>
+<meta name="calibre:series" content="Natives"/>
+<meta name="calibre:series_index" content="3"/>`, "", ""),
+	})
+
+	// No ebook-meta: GetMetadataContext reads the EPUB directly
+	c := &Calibre{
+		Timeout: DefaultTimeout,
+		Runner: func(ctx context.Context, name string, args ...string) ([]byte, error) {
+			t.Fatal("native fallback should not run a command")
+			return nil, nil
+		},
+	}
+
+	meta, err := c.GetMetadataContext(context.Background(), epub)
+	if err != nil {
+		t.Fatalf("GetMetadataContext failed: %v", err)
+	}
+	if meta.Title != "Native Book" || meta.AuthorSort != "Doe, Jane" || meta.Language != "en" {
+		t.Errorf("unexpected metadata: %+v", meta)
+	}
+	if meta.Series != "Natives" || meta.SeriesIndex != 3 {
+		t.Errorf("Series = %q #%v", meta.Series, meta.SeriesIndex)
+	}
+
+	if _, err := c.GetMetadataContext(context.Background(), "book.mobi"); err == nil {
+		t.Error("expected an error for non-EPUB input without ebook-meta")
+	}
+}
+
+func TestGetMetadataFromReader(t *testing.T) {
+	epub := writeTestEPUB(t, map[string]string{
+		"META-INF/container.xml": testContainer("content.opf"),
+		"content.opf":            testOPF(`<dc:title>Uploaded</dc:title>`, "", ""),
+	})
+	data, err := os.ReadFile(epub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var tmpPath string
+	c := &Calibre{
+		Timeout:   DefaultTimeout,
+		ebookMeta: "ebook-meta",
+		Runner: func(ctx context.Context, name string, args ...string) ([]byte, error) {
+			tmpPath = args[0]
+			meta, err := GetMetadataNative(tmpPath)
+			if err != nil {
+				return nil, err
+			}
+			return nil, os.WriteFile(args[2], []byte(testOPF("<dc:title>"+meta.Title+"</dc:title>", "", "")), 0644)
+		},
+	}
+
+	meta, err := c.GetMetadataFromReader(context.Background(), bytes.NewReader(data), ".EPUB")
+	if err != nil {
+		t.Fatalf("GetMetadataFromReader failed: %v", err)
+	}
+	if meta.Title != "Uploaded" {
+		t.Errorf("Title = %q", meta.Title)
+	}
+	if filepath.Ext(tmpPath) != ".epub" {
+		t.Errorf("temp file %q should have the .epub extension", tmpPath)
+	}
+	if _, err := os.Stat(tmpPath); !os.IsNotExist(err) {
+		t.Error("temp file was not removed")
+	}
+
+	if _, err := c.GetMetadataFromReader(context.Background(), bytes.NewReader(data), "../epub"); err == nil {
+		t.Error("expected an error for a format containing a path separator")
+	}
+}
+
+func TestGetMetadataRaw(t *testing.T) {
+	opfXML := testOPF(`<dc:title>Raw Book</dc:title>
+<meta name="calibre:user_metadata:#mycolumn" content="{&quot;#value#&quot;: &quot;shelf 3&quot;}"/>`, "", "")
+
+	c := &Calibre{
+		Timeout:   DefaultTimeout,
+		ebookMeta: "ebook-meta",
+		Runner: func(ctx context.Context, name string, args ...string) ([]byte, error) {
+			return nil, os.WriteFile(args[2], []byte(opfXML), 0644)
+		},
+	}
+
+	raw, err := c.GetMetadataRaw(context.Background(), "book.mobi")
+	if err != nil {
+		t.Fatalf("GetMetadataRaw failed: %v", err)
+	}
+	if string(raw) != opfXML {
+		t.Errorf("GetMetadataRaw = %q, want the OPF ebook-meta wrote", raw)
+	}
+
+	// OPF printed to stdout is returned without the log lines before it
+	c.Runner = func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		return []byte("Reading metadata...\n" + opfXML), nil
+	}
+	if raw, err := c.GetMetadataRaw(context.Background(), "book.mobi"); err != nil || string(raw) != opfXML {
+		t.Errorf("GetMetadataRaw from stdout = %q, %v", raw, err)
+	}
+}
+
+func TestGetOPFBytes(t *testing.T) {
+	opfXML := testOPF(`<dc:title>Zipped</dc:title>`, "", "")
+	epub := writeTestEPUB(t, map[string]string{
+		"META-INF/container.xml": testContainer("OEBPS/content.opf"),
+		"OEBPS/content.opf":      opfXML,
+	})
+
+	data, err := GetOPFBytes(epub)
+	if err != nil {
+		t.Fatalf("GetOPFBytes failed: %v", err)
+	}
+	if string(data) != opfXML {
+		t.Errorf("GetOPFBytes = %q", data)
+	}
+
+	// Without ebook-meta, GetMetadataRaw reads the EPUB's own OPF
+	c := &Calibre{Timeout: DefaultTimeout}
+	if raw, err := c.GetMetadataRaw(context.Background(), epub); err != nil || !bytes.Equal(raw, data) {
+		t.Errorf("native GetMetadataRaw = %q, %v", raw, err)
+	}
+}
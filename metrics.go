@@ -0,0 +1,31 @@
+package calibre
+
+import "time"
+
+// MetricsCollector receives timing information for each extraction phase.
+// Set Calibre.Metrics to collect it; when unset nothing is recorded.
+type MetricsCollector interface {
+	// ObserveConversion is called after each ebook-convert run
+	ObserveConversion(d time.Duration)
+
+	// ObserveNCXParse is called after an NCX is read and parsed
+	ObserveNCXParse(d time.Duration)
+
+	// ObserveChapterExtract is called after chapter content is extracted
+	ObserveChapterExtract(d time.Duration)
+}
+
+// noopMetrics is the default collector that discards all observations
+type noopMetrics struct{}
+
+func (noopMetrics) ObserveConversion(time.Duration)     {}
+func (noopMetrics) ObserveNCXParse(time.Duration)       {}
+func (noopMetrics) ObserveChapterExtract(time.Duration) {}
+
+// metrics returns the configured collector or a no-op one
+func (c *Calibre) metrics() MetricsCollector {
+	if c.Metrics == nil {
+		return noopMetrics{}
+	}
+	return c.Metrics
+}
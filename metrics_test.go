@@ -0,0 +1,88 @@
+package calibre
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingMetrics records every observed duration per phase
+type recordingMetrics struct {
+	mu          sync.Mutex
+	conversions []time.Duration
+	ncxParses   []time.Duration
+	extracts    []time.Duration
+}
+
+func (m *recordingMetrics) ObserveConversion(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.conversions = append(m.conversions, d)
+}
+
+func (m *recordingMetrics) ObserveNCXParse(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.ncxParses = append(m.ncxParses, d)
+}
+
+func (m *recordingMetrics) ObserveChapterExtract(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.extracts = append(m.extracts, d)
+}
+
+// convertingRunner returns a runner that "converts" by copying srcEPUB to
+// the output path ebook-convert was given
+func convertingRunner(t *testing.T, srcEPUB string) CommandRunner {
+	return func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		data, err := os.ReadFile(srcEPUB)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return nil, os.WriteFile(args[1], data, 0644)
+	}
+}
+
+func TestMetricsCollector(t *testing.T) {
+	epub := writeTestEPUB(t, map[string]string{
+		"toc.ncx":   testNCX([2]string{"Chapter 1", "ch1.xhtml"}, [2]string{"Chapter 2", "ch2.xhtml"}),
+		"ch1.xhtml": testXHTML("<p>" + loremWords("First", 100) + "</p>"),
+		"ch2.xhtml": testXHTML("<p>" + loremWords("Second", 100) + "</p>"),
+	})
+
+	metrics := &recordingMetrics{}
+	c := &Calibre{
+		Timeout:      DefaultTimeout,
+		ebookConvert: "ebook-convert",
+		Runner:       convertingRunner(t, epub),
+		Metrics:      metrics,
+	}
+
+	chapters, err := c.ExtractChapters("book.mobi")
+	if err != nil {
+		t.Fatalf("ExtractChapters failed: %v", err)
+	}
+	if len(chapters) != 2 {
+		t.Fatalf("expected 2 chapters, got %d", len(chapters))
+	}
+
+	phases := map[string][]time.Duration{
+		"conversion":      metrics.conversions,
+		"ncx parse":       metrics.ncxParses,
+		"chapter extract": metrics.extracts,
+	}
+	for phase, durations := range phases {
+		if len(durations) == 0 {
+			t.Errorf("%s: no observations", phase)
+			continue
+		}
+		for _, d := range durations {
+			if d <= 0 {
+				t.Errorf("%s: non-positive duration %v", phase, d)
+			}
+		}
+	}
+}
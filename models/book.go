@@ -1,35 +1,42 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 // Book represents a complete ebook with metadata and chapters
 type Book struct {
 	// Core metadata
-	Title       string
-	Authors     []string
-	Language    string
-	Publisher   string
-	PublishDate time.Time
-	Description string
+	Title       string    `json:"title"`
+	Authors     []string  `json:"authors"`
+	Language    string    `json:"language"`
+	Publisher   string    `json:"publisher"`
+	PublishDate time.Time `json:"publish_date"`
+	Description string    `json:"description"`
 
 	// Identifiers
-	ISBN       string
-	Identifiers map[string]string // asin, goodreads, etc.
+	ISBN        string            `json:"isbn"`
+	Identifiers map[string]string `json:"identifiers"` // asin, goodreads, etc.
 
 	// Classification
-	Tags   []string
-	Series string
-	SeriesIndex float64
+	Tags        []string `json:"tags"`
+	Series      string   `json:"series"`
+	SeriesIndex float64  `json:"series_index"`
+
+	// CustomColumns holds Calibre library custom column values keyed by
+	// lookup name, e.g. "#shelf" (only when read from a library)
+	CustomColumns map[string]string `json:"custom_columns,omitempty"`
 
 	// Content
-	Chapters []Chapter
-	TOC      []TOCEntry
+	Chapters []Chapter  `json:"chapters"`
+	TOC      []TOCEntry `json:"toc"`
 
 	// Files
-	FilePath   string
-	Format     string
-	CoverPath  string
-	CoverData  []byte
+	FilePath  string `json:"file_path"`
+	Format    string `json:"format"`
+	CoverPath string `json:"cover_path"`
+	CoverData []byte `json:"cover_data,omitempty"` // base64 in JSON
 }
 
 // Metadata represents just the metadata portion of a book
@@ -53,10 +60,10 @@ type Metadata struct {
 
 // TOCEntry represents an entry in the table of contents
 type TOCEntry struct {
-	Title    string
-	Level    int    // Nesting level (1 = top level)
-	Href     string // Link to content
-	Children []TOCEntry
+	Title    string     `json:"title"`
+	Level    int        `json:"level"` // Nesting level (1 = top level)
+	Href     string     `json:"href"`  // Link to content
+	Children []TOCEntry `json:"children,omitempty"`
 }
 
 // PrimaryAuthor returns the first author or empty string
@@ -76,3 +83,8 @@ func (b *Book) HasChapters() bool {
 func (b *Book) ChapterCount() int {
 	return len(b.Chapters)
 }
+
+// ToJSON encodes the book as JSON with snake_case keys
+func (b *Book) ToJSON() ([]byte, error) {
+	return json.Marshal(b)
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func jsonKeys(t *testing.T, data []byte) []string {
+	t.Helper()
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(data, &m); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func TestBookToJSON(t *testing.T) {
+	book := &Book{
+		Title:    "Example",
+		Authors:  []string{"A. Writer"},
+		Chapters: []Chapter{NewChapter(0, "One", "Some words here")},
+		TOC:      []TOCEntry{{Title: "One", Level: 1, Href: "ch1.xhtml"}},
+	}
+
+	data, err := book.ToJSON()
+	if err != nil {
+		t.Fatalf("ToJSON failed: %v", err)
+	}
+
+	want := "authors,chapters,cover_path,description,file_path,format,identifiers,isbn,language," +
+		"publish_date,publisher,series,series_index,tags,title,toc"
+	if got := strings.Join(jsonKeys(t, data), ","); got != want {
+		t.Errorf("book keys = %s\nwant %s", got, want)
+	}
+
+	var decoded struct {
+		Chapters []json.RawMessage `json:"chapters"`
+		TOC      []json.RawMessage `json:"toc"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(jsonKeys(t, decoded.Chapters[0]), ","); got != "char_count,content,index,title,word_count" {
+		t.Errorf("chapter keys = %s", got)
+	}
+	if got := strings.Join(jsonKeys(t, decoded.TOC[0]), ","); got != "href,level,title" {
+		t.Errorf("toc keys = %s", got)
+	}
+
+	// A cover is emitted base64-encoded when present
+	book.CoverData = []byte{0xff, 0xd8}
+	data, _ = book.ToJSON()
+	if !strings.Contains(string(data), `"cover_data":"/9g="`) {
+		t.Errorf("expected base64 cover_data, got %s", data)
+	}
+}
@@ -1,24 +1,42 @@
 package models
 
+import "unicode"
+
 // Chapter represents a single chapter extracted from an ebook
 type Chapter struct {
 	// Index is the chapter number (0-based)
-	Index int
+	Index int `json:"index"`
 
 	// Title is the chapter title from TOC or detected heading
-	Title string
+	Title string `json:"title"`
 
 	// Content is the plain text content of the chapter
-	Content string
+	Content string `json:"content"`
 
 	// HTMLContent is the original HTML content (if available)
-	HTMLContent string
+	HTMLContent string `json:"html_content,omitempty"`
 
 	// WordCount is the approximate word count
-	WordCount int
+	WordCount int `json:"word_count"`
 
 	// CharCount is the character count
-	CharCount int
+	CharCount int `json:"char_count"`
+
+	// Footnotes are the notes referenced from the chapter, found in its HTML
+	// when extraction keeps HTML
+	Footnotes []Footnote `json:"footnotes,omitempty"`
+}
+
+// Footnote is a note referenced from chapter text
+type Footnote struct {
+	// ID is the id of the note's element in the HTML
+	ID string `json:"id"`
+
+	// Marker is the reference's text in the body, such as "1" or "*"
+	Marker string `json:"marker"`
+
+	// Text is the note's plain text
+	Text string `json:"text"`
 }
 
 // NewChapter creates a new chapter with the given index and title
@@ -32,7 +50,10 @@ func NewChapter(index int, title, content string) Chapter {
 	}
 }
 
-// countWords provides a simple word count
+// countWords provides a simple word count. Whitespace-delimited runs count
+// as one word each, while Chinese and Japanese characters, which aren't
+// separated by spaces, count as a word per character. Korean separates words
+// with spaces, so Hangul is counted like Latin text.
 func countWords(text string) int {
 	if text == "" {
 		return 0
@@ -42,10 +63,13 @@ func countWords(text string) int {
 	inWord := false
 
 	for _, r := range text {
-		isSpace := r == ' ' || r == '\t' || r == '\n' || r == '\r'
-		if isSpace {
+		switch {
+		case isCJK(r):
 			inWord = false
-		} else if !inWord {
+			count++
+		case unicode.IsSpace(r) || isCJKPunct(r):
+			inWord = false
+		case !inWord:
 			inWord = true
 			count++
 		}
@@ -54,6 +78,16 @@ func countWords(text string) int {
 	return count
 }
 
+// isCJK reports whether r is a Chinese or Japanese character
+func isCJK(r rune) bool {
+	return unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) || unicode.Is(unicode.Katakana, r)
+}
+
+// isCJKPunct reports whether r is CJK or full-width punctuation, such as 。 or ，
+func isCJKPunct(r rune) bool {
+	return unicode.IsPunct(r) && (r >= 0x3000 && r <= 0x303F || r >= 0xFF00 && r <= 0xFFEF)
+}
+
 // IsEmpty returns true if the chapter has no content
 func (c *Chapter) IsEmpty() bool {
 	return len(c.Content) == 0
@@ -0,0 +1,23 @@
+package models
+
+import "testing"
+
+func TestCountWords(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want int
+	}{
+		{"empty", "", 0},
+		{"english", "The quick brown fox\njumps over  the lazy dog.", 9},
+		{"chinese", "我们今天去公园。", 7},
+		{"japanese", "これはペンです", 7},
+		{"mixed", "我爱 Go programming 语言", 6},
+		{"korean", "안녕하세요 세계", 2},
+	}
+	for _, tt := range tests {
+		if got := countWords(tt.text); got != tt.want {
+			t.Errorf("%s: countWords(%q) = %d, want %d", tt.name, tt.text, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,14 @@
+package models
+
+// DialogueLine is a quoted passage found in chapter text
+type DialogueLine struct {
+	// Text is the quoted text without the surrounding quote marks
+	Text string
+
+	// Start is the byte offset of the opening quote in the chapter text
+	Start int
+
+	// End is the byte offset just past the closing quote (or the end of the
+	// paragraph when the quote is never closed)
+	End int
+}
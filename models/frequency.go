@@ -0,0 +1,74 @@
+package models
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// FreqOptions configures Book.WordFrequencies
+type FreqOptions struct {
+	// TopN limits the result to the N most common words (0 for all)
+	TopN int
+
+	// MinLength skips words shorter than this many characters
+	MinLength int
+
+	// Stopwords are lowercase words to leave out, such as "the" and "and"
+	Stopwords map[string]bool
+}
+
+// WordCount is a word and the number of times it occurs
+type WordCount struct {
+	Word  string
+	Count int
+}
+
+// WordFrequencies counts the words in the chapters' plain text content.
+// Words are lowercased and stripped of surrounding punctuation, keeping
+// inner apostrophes and hyphens ("don't", "well-known"). The result is
+// sorted by descending count, with ties in alphabetical order.
+func (b *Book) WordFrequencies(opts FreqOptions) []WordCount {
+	counts := make(map[string]int)
+	for _, ch := range b.Chapters {
+		for _, field := range strings.FieldsFunc(ch.Content, isWordBreak) {
+			word := normalizeWord(field)
+			if word == "" || utf8.RuneCountInString(word) < opts.MinLength || opts.Stopwords[word] {
+				continue
+			}
+			counts[word]++
+		}
+	}
+
+	freqs := make([]WordCount, 0, len(counts))
+	for word, n := range counts {
+		freqs = append(freqs, WordCount{Word: word, Count: n})
+	}
+	sort.Slice(freqs, func(i, j int) bool {
+		if freqs[i].Count != freqs[j].Count {
+			return freqs[i].Count > freqs[j].Count
+		}
+		return freqs[i].Word < freqs[j].Word
+	})
+
+	if opts.TopN > 0 && len(freqs) > opts.TopN {
+		freqs = freqs[:opts.TopN]
+	}
+	return freqs
+}
+
+// isWordBreak reports whether r separates words: whitespace, and the em and
+// en dashes often written between words without spaces
+func isWordBreak(r rune) bool {
+	return unicode.IsSpace(r) || r == '—' || r == '–'
+}
+
+// normalizeWord lowercases a word and trims the punctuation around it
+func normalizeWord(s string) string {
+	s = strings.ReplaceAll(s, "’", "'")
+	s = strings.TrimFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.ToLower(s)
+}
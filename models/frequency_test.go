@@ -0,0 +1,32 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWordFrequencies(t *testing.T) {
+	book := &Book{Chapters: []Chapter{
+		NewChapter(0, "One", "The whale! The sea, the ship. Don't fear the whale."),
+		NewChapter(1, "Two", "A ship—and a sea—and the WHALE’s wake."),
+	}}
+
+	got := book.WordFrequencies(FreqOptions{})
+	want := []WordCount{
+		{"the", 5}, {"a", 2}, {"and", 2}, {"sea", 2}, {"ship", 2}, {"whale", 2},
+		{"don't", 1}, {"fear", 1}, {"wake", 1}, {"whale's", 1},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("WordFrequencies() = %v, want %v", got, want)
+	}
+
+	got = book.WordFrequencies(FreqOptions{
+		TopN:      3,
+		MinLength: 3,
+		Stopwords: map[string]bool{"the": true, "and": true},
+	})
+	want = []WordCount{{"sea", 2}, {"ship", 2}, {"whale", 2}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("WordFrequencies() with options = %v, want %v", got, want)
+	}
+}
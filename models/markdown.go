@@ -0,0 +1,45 @@
+package models
+
+import (
+	"regexp"
+	"strings"
+)
+
+// paragraphBreak matches the blank lines that separate paragraphs
+var paragraphBreak = regexp.MustCompile(`\n\s*\n`)
+
+// markdownEscaper escapes characters with meaning in Markdown inline text
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", `*`, `\*`, `_`, `\_`, `[`, `\[`, `]`, `\]`,
+	`#`, `\#`, `<`, `\<`, `>`, `\>`, `|`, `\|`,
+)
+
+// ToMarkdown renders the book as a Markdown document: the title as an H1,
+// each chapter title as an H2, and chapter text as paragraphs
+func (b *Book) ToMarkdown() string {
+	var sb strings.Builder
+	if b.Title != "" {
+		sb.WriteString("# " + escapeMarkdown(b.Title) + "\n\n")
+	}
+	sb.WriteString(ChaptersToMarkdown(b.Chapters))
+	return strings.TrimRight(sb.String(), "\n") + "\n"
+}
+
+// ChaptersToMarkdown renders chapters as Markdown sections, one H2 per chapter
+func ChaptersToMarkdown(chapters []Chapter) string {
+	var sb strings.Builder
+	for _, ch := range chapters {
+		sb.WriteString("## " + escapeMarkdown(ch.Title) + "\n\n")
+		for _, para := range paragraphBreak.Split(ch.Content, -1) {
+			if para = strings.TrimSpace(para); para != "" {
+				sb.WriteString(para + "\n\n")
+			}
+		}
+	}
+	return sb.String()
+}
+
+// escapeMarkdown escapes Markdown-significant characters in a single line
+func escapeMarkdown(s string) string {
+	return markdownEscaper.Replace(strings.Join(strings.Fields(s), " "))
+}
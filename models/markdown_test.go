@@ -0,0 +1,24 @@
+package models
+
+import (
+	"os"
+	"testing"
+)
+
+func TestBookToMarkdown(t *testing.T) {
+	book := &Book{
+		Title: "The *Starry* Night",
+		Chapters: []Chapter{
+			NewChapter(0, "Chapter 1: [Dawn]", "The sun rose slowly.\n\nBirds began to sing.\n\n\n"),
+			NewChapter(1, "Chapter 2 # The_End", "  Night fell.\nIt was quiet.\n\n  The end.  "),
+		},
+	}
+
+	want, err := os.ReadFile("testdata/book.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := book.ToMarkdown(); got != string(want) {
+		t.Errorf("ToMarkdown mismatch:\n--- got ---\n%s\n--- want ---\n%s", got, want)
+	}
+}
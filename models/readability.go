@@ -0,0 +1,123 @@
+package models
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Readability holds Flesch reading scores for a piece of text
+type Readability struct {
+	// ReadingEase is the Flesch Reading Ease score; higher is easier
+	// (90-100 very easy, 60-70 plain English, below 30 very difficult)
+	ReadingEase float64
+
+	// GradeLevel is the Flesch-Kincaid Grade Level, roughly the US school
+	// grade needed to understand the text
+	GradeLevel float64
+
+	Sentences int
+	Words     int
+	Syllables int
+}
+
+// Readability scores the chapter's content. Sentences are counted by runs of
+// terminal punctuation (. ! ?) and words with the chapter word counter.
+// Syllables are estimated per word by counting groups of consecutive vowels
+// (a, e, i, o, u, y), dropping a silent final "e" and counting at least one
+// per word, so scores are approximate and only meaningful for English.
+func (c *Chapter) Readability() Readability {
+	return readability(c.Content)
+}
+
+// AverageReadability combines the chapters' scores, weighted by word count
+func (b *Book) AverageReadability() Readability {
+	var avg Readability
+	for i := range b.Chapters {
+		r := b.Chapters[i].Readability()
+		if r.Words == 0 {
+			continue
+		}
+		w := float64(r.Words)
+		avg.ReadingEase += r.ReadingEase * w
+		avg.GradeLevel += r.GradeLevel * w
+		avg.Sentences += r.Sentences
+		avg.Words += r.Words
+		avg.Syllables += r.Syllables
+	}
+
+	if avg.Words > 0 {
+		avg.ReadingEase /= float64(avg.Words)
+		avg.GradeLevel /= float64(avg.Words)
+	}
+	return avg
+}
+
+// readability computes Flesch scores for text
+func readability(text string) Readability {
+	r := Readability{
+		Sentences: countSentences(text),
+		Words:     countWords(text),
+	}
+	if r.Words == 0 {
+		return r
+	}
+	if r.Sentences == 0 {
+		r.Sentences = 1
+	}
+
+	for _, word := range strings.FieldsFunc(text, isNotWordLetter) {
+		r.Syllables += countSyllables(word)
+	}
+
+	wordsPerSentence := float64(r.Words) / float64(r.Sentences)
+	syllablesPerWord := float64(r.Syllables) / float64(r.Words)
+	r.ReadingEase = 206.835 - 1.015*wordsPerSentence - 84.6*syllablesPerWord
+	r.GradeLevel = 0.39*wordsPerSentence + 11.8*syllablesPerWord - 15.59
+
+	return r
+}
+
+// countSentences counts runs of terminal punctuation
+func countSentences(text string) int {
+	count := 0
+	inTerminal := false
+	for _, r := range text {
+		terminal := r == '.' || r == '!' || r == '?'
+		if terminal && !inTerminal {
+			count++
+		}
+		inTerminal = terminal
+	}
+	return count
+}
+
+// countSyllables estimates syllables as vowel groups, less a silent final "e"
+func countSyllables(word string) int {
+	word = strings.ToLower(strings.Trim(word, "'"))
+	if word == "" {
+		return 0
+	}
+
+	count := 0
+	prevVowel := false
+	for _, r := range word {
+		vowel := strings.ContainsRune("aeiouy", r)
+		if vowel && !prevVowel {
+			count++
+		}
+		prevVowel = vowel
+	}
+
+	if count > 1 && strings.HasSuffix(word, "e") && !strings.HasSuffix(word, "le") {
+		count--
+	}
+	if count == 0 {
+		count = 1
+	}
+	return count
+}
+
+// isNotWordLetter separates words for syllable counting, keeping apostrophes
+func isNotWordLetter(r rune) bool {
+	return !unicode.IsLetter(r) && r != '\''
+}
@@ -0,0 +1,42 @@
+package models
+
+import "testing"
+
+func TestCountSyllables(t *testing.T) {
+	tests := map[string]int{"cat": 1, "make": 1, "table": 2, "reading": 2, "beautiful": 3, "rhythm": 1}
+	for word, want := range tests {
+		if got := countSyllables(word); got != want {
+			t.Errorf("countSyllables(%q) = %d, want %d", word, got, want)
+		}
+	}
+}
+
+func TestChapterReadability(t *testing.T) {
+	easy := NewChapter(0, "Easy", "The cat sat on the mat. It was a good day. The sun was warm.")
+	hard := NewChapter(1, "Hard", "Notwithstanding considerable institutional opposition, the "+
+		"administration systematically implemented comprehensive organizational restructuring, "+
+		"fundamentally transforming interdepartmental communication methodologies.")
+
+	e, h := easy.Readability(), hard.Readability()
+	if e.Sentences != 3 || e.Words != 15 {
+		t.Errorf("easy: %d sentences, %d words", e.Sentences, e.Words)
+	}
+	if e.ReadingEase < 90 {
+		t.Errorf("easy text should score as very easy, got %.1f", e.ReadingEase)
+	}
+	if h.ReadingEase >= e.ReadingEase || h.ReadingEase > 30 {
+		t.Errorf("hard text should score much lower: easy %.1f, hard %.1f", e.ReadingEase, h.ReadingEase)
+	}
+	if h.GradeLevel <= e.GradeLevel {
+		t.Errorf("hard text should have a higher grade: easy %.1f, hard %.1f", e.GradeLevel, h.GradeLevel)
+	}
+
+	book := &Book{Chapters: []Chapter{easy, hard}}
+	avg := book.AverageReadability()
+	if avg.Words != e.Words+h.Words {
+		t.Errorf("average Words = %d", avg.Words)
+	}
+	if avg.ReadingEase >= e.ReadingEase || avg.ReadingEase <= h.ReadingEase {
+		t.Errorf("average %.1f should lie between %.1f and %.1f", avg.ReadingEase, h.ReadingEase, e.ReadingEase)
+	}
+}
@@ -0,0 +1,109 @@
+package models
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DefaultSnippetContext is the default number of bytes of context shown on
+// each side of a search match
+const DefaultSnippetContext = 40
+
+// SearchOptions configures Book.Search
+type SearchOptions struct {
+	// CaseInsensitive matches regardless of letter case
+	CaseInsensitive bool
+
+	// WholeWord only matches the query when it isn't part of a longer word
+	WholeWord bool
+
+	// ContextChars is the number of bytes of context on each side of a
+	// match in the snippet (defaults to DefaultSnippetContext)
+	ContextChars int
+}
+
+// SearchHit is a single match in a chapter's content
+type SearchHit struct {
+	// ChapterIndex is the Index of the chapter containing the match
+	ChapterIndex int
+
+	// Offset is the byte offset of the match in the chapter's Content
+	Offset int
+
+	// Snippet is the match with surrounding context, trimmed to whole runes
+	Snippet string
+}
+
+// Search finds every occurrence of query in the chapters' plain text content
+func (b *Book) Search(query string, opts SearchOptions) []SearchHit {
+	if query == "" {
+		return nil
+	}
+
+	pattern := regexp.QuoteMeta(query)
+	if opts.CaseInsensitive {
+		pattern = "(?i)" + pattern
+	}
+	re := regexp.MustCompile(pattern)
+
+	span := opts.ContextChars
+	if span <= 0 {
+		span = DefaultSnippetContext
+	}
+
+	var hits []SearchHit
+	for _, ch := range b.Chapters {
+		for _, m := range re.FindAllStringIndex(ch.Content, -1) {
+			if opts.WholeWord && !isWholeWord(ch.Content, m[0], m[1]) {
+				continue
+			}
+			hits = append(hits, SearchHit{
+				ChapterIndex: ch.Index,
+				Offset:       m[0],
+				Snippet:      snippet(ch.Content, m[0], m[1], span),
+			})
+		}
+	}
+
+	return hits
+}
+
+// isWholeWord reports whether text[start:end] isn't adjoined by word characters
+func isWholeWord(text string, start, end int) bool {
+	if r, _ := utf8.DecodeLastRuneInString(text[:start]); start > 0 && isWordRune(r) {
+		return false
+	}
+	if r, _ := utf8.DecodeRuneInString(text[end:]); end < len(text) && isWordRune(r) {
+		return false
+	}
+	return true
+}
+
+// isWordRune reports whether r can be part of a word
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+// snippet returns text[start:end] with up to span bytes on each side,
+// widened to rune boundaries and with whitespace collapsed
+func snippet(text string, start, end, span int) string {
+	from := start - span
+	if from < 0 {
+		from = 0
+	}
+	for from > 0 && !utf8.RuneStart(text[from]) {
+		from--
+	}
+
+	to := end + span
+	if to > len(text) {
+		to = len(text)
+	}
+	for to < len(text) && !utf8.RuneStart(text[to]) {
+		to++
+	}
+
+	return strings.Join(strings.Fields(text[from:to]), " ")
+}
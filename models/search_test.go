@@ -0,0 +1,35 @@
+package models
+
+import "testing"
+
+func TestBookSearch(t *testing.T) {
+	book := &Book{Chapters: []Chapter{
+		NewChapter(0, "One", "The whale surfaced. Whalers watched the whale."),
+		NewChapter(1, "Two", "Nobody believed in the White Whale until it came."),
+	}}
+
+	hits := book.Search("whale", SearchOptions{CaseInsensitive: true, WholeWord: true, ContextChars: 4})
+	want := []SearchHit{
+		{ChapterIndex: 0, Offset: 4, Snippet: "The whale sur"},
+		{ChapterIndex: 0, Offset: 40, Snippet: "the whale."},
+		{ChapterIndex: 1, Offset: 29, Snippet: "ite Whale unt"},
+	}
+	if len(hits) != len(want) {
+		t.Fatalf("got %d hits: %+v", len(hits), hits)
+	}
+	for i := range want {
+		if hits[i] != want[i] {
+			t.Errorf("hit %d = %+v, want %+v", i, hits[i], want[i])
+		}
+	}
+
+	// Without WholeWord "Whalers" matches too
+	if hits := book.Search("whale", SearchOptions{CaseInsensitive: true}); len(hits) != 4 {
+		t.Errorf("substring search: got %d hits, want 4", len(hits))
+	}
+
+	// Case-sensitive search only finds the capitalized forms
+	if hits := book.Search("Whale", SearchOptions{}); len(hits) != 2 {
+		t.Errorf("case-sensitive search: got %d hits, want 2", len(hits))
+	}
+}
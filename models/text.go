@@ -0,0 +1,60 @@
+package models
+
+import "strings"
+
+// DefaultChapterSeparator marks chapter boundaries in ToPlainText output with
+// a form feed on its own line
+const DefaultChapterSeparator = "\n\f\n"
+
+// DefaultParagraphSeparator separates paragraphs in ToPlainText output
+const DefaultParagraphSeparator = "\n\n"
+
+// TextExportOptions configures Book.ToPlainText
+type TextExportOptions struct {
+	// ChapterSeparator is written between chapters (defaults to
+	// DefaultChapterSeparator)
+	ChapterSeparator string
+
+	// ParagraphSeparator is written between paragraphs (defaults to
+	// DefaultParagraphSeparator)
+	ParagraphSeparator string
+
+	// IncludeTitles starts each chapter with its title as a paragraph
+	IncludeTitles bool
+}
+
+// ToPlainText renders the book's chapters as clean text, e.g. for
+// text-to-speech. Whitespace within each paragraph is collapsed to single
+// spaces, so lines wrapped in the source read as one sentence, and chapters
+// are split by a separator a chunker can find.
+func (b *Book) ToPlainText(opts TextExportOptions) string {
+	chapterSep := opts.ChapterSeparator
+	if chapterSep == "" {
+		chapterSep = DefaultChapterSeparator
+	}
+	paraSep := opts.ParagraphSeparator
+	if paraSep == "" {
+		paraSep = DefaultParagraphSeparator
+	}
+
+	chapters := make([]string, 0, len(b.Chapters))
+	for _, ch := range b.Chapters {
+		var paras []string
+		if title := strings.Join(strings.Fields(ch.Title), " "); opts.IncludeTitles && title != "" {
+			paras = append(paras, title)
+		}
+		for _, para := range paragraphBreak.Split(ch.Content, -1) {
+			if para = strings.Join(strings.Fields(para), " "); para != "" {
+				paras = append(paras, para)
+			}
+		}
+		if len(paras) > 0 {
+			chapters = append(chapters, strings.Join(paras, paraSep))
+		}
+	}
+
+	if len(chapters) == 0 {
+		return ""
+	}
+	return strings.Join(chapters, chapterSep) + "\n"
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"os"
+	"testing"
+)
+
+func TestBookToPlainText(t *testing.T) {
+	book := &Book{
+		Title: "The Starry Night",
+		Chapters: []Chapter{
+			NewChapter(0, "Chapter 1:  Dawn", "The sun rose\nslowly.\n\nBirds   began to sing.\n\n\n"),
+			NewChapter(1, "Chapter 2", "  Night fell.\nIt was quiet.\n \n  The end.  "),
+		},
+	}
+
+	want, err := os.ReadFile("testdata/book.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := book.ToPlainText(TextExportOptions{IncludeTitles: true}); got != string(want) {
+		t.Errorf("ToPlainText mismatch:\n--- got ---\n%q\n--- want ---\n%q", got, want)
+	}
+
+	got := book.ToPlainText(TextExportOptions{ChapterSeparator: "\n\n\n", ParagraphSeparator: "\n"})
+	wantPlain := "The sun rose slowly.\nBirds began to sing.\n\n\nNight fell. It was quiet.\nThe end.\n"
+	if got != wantPlain {
+		t.Errorf("ToPlainText with separators = %q, want %q", got, wantPlain)
+	}
+}
@@ -0,0 +1,115 @@
+package ncx
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// windows1252 maps the 0x80-0x9F range of Windows-1252 to Unicode.
+// All other bytes map directly to the same code point as ISO-8859-1.
+var windows1252 = [32]rune{
+	'€', '\u0081', '‚', 'ƒ', '„', '…', '†', '‡',
+	'ˆ', '‰', 'Š', '‹', 'Œ', '\u008D', 'Ž', '\u008F',
+	'\u0090', '‘', '’', '“', '”', '•', '–', '—',
+	'˜', '™', 'š', '›', 'œ', '\u009D', 'ž', 'Ÿ',
+}
+
+// charsetReader converts common single-byte encodings to UTF-8.
+// It is used as the CharsetReader of xml.Decoder so non-UTF-8 files parse.
+func charsetReader(label string, input io.Reader) (io.Reader, error) {
+	switch normalizeCharset(label) {
+	case "utf-8", "us-ascii":
+		return input, nil
+	case "iso-8859-1", "windows-1252":
+		data, err := io.ReadAll(input)
+		if err != nil {
+			return nil, err
+		}
+		return strings.NewReader(decodeSingleByte(data, normalizeCharset(label))), nil
+	}
+	return nil, fmt.Errorf("unsupported charset: %s", label)
+}
+
+// normalizeCharset maps charset aliases to a canonical name
+func normalizeCharset(label string) string {
+	switch strings.ToLower(strings.TrimSpace(label)) {
+	case "utf-8", "utf8":
+		return "utf-8"
+	case "us-ascii", "ascii":
+		return "us-ascii"
+	case "iso-8859-1", "iso8859-1", "iso_8859-1", "latin1", "latin-1", "l1":
+		return "iso-8859-1"
+	case "windows-1252", "cp1252", "x-cp1252":
+		return "windows-1252"
+	}
+	return strings.ToLower(label)
+}
+
+// decodeSingleByte decodes ISO-8859-1 or Windows-1252 bytes into a UTF-8 string
+func decodeSingleByte(data []byte, charset string) string {
+	var b strings.Builder
+	b.Grow(len(data))
+	for _, c := range data {
+		if charset == "windows-1252" && c >= 0x80 && c <= 0x9F {
+			b.WriteRune(windows1252[c-0x80])
+			continue
+		}
+		b.WriteRune(rune(c))
+	}
+	return b.String()
+}
+
+// Patterns for charset declarations in the head of an XHTML file
+var (
+	xmlEncodingRe = regexp.MustCompile(`^\s*<\?xml[^>]*\sencoding\s*=\s*["']([^"']+)["']`)
+	metaCharsetRe = regexp.MustCompile(`(?i)<meta[^>]+charset\s*=\s*["']?([\w-]+)`)
+)
+
+// detectCharset returns the charset declared by an XHTML file's XML
+// declaration or meta tag, or "" when none is declared
+func detectCharset(data []byte) string {
+	head := data
+	if len(head) > 1024 {
+		head = head[:1024]
+	}
+
+	if m := xmlEncodingRe.FindSubmatch(head); m != nil {
+		return normalizeCharset(string(m[1]))
+	}
+	if m := metaCharsetRe.FindSubmatch(head); m != nil {
+		return normalizeCharset(string(m[1]))
+	}
+	return ""
+}
+
+// decodeContent converts file content to a UTF-8 string using its declared
+// charset. Undeclared content that isn't valid UTF-8 is treated as
+// Windows-1252, a superset of Latin-1 common in older books.
+func decodeContent(data []byte) string {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	switch charset := detectCharset(data); charset {
+	case "iso-8859-1", "windows-1252":
+		return decodeSingleByte(data, charset)
+	case "":
+		if !utf8.Valid(data) {
+			return decodeSingleByte(data, "windows-1252")
+		}
+	}
+	return string(data)
+}
+
+// newXMLDecoder returns a decoder that tolerates HTML entities and
+// non-UTF-8 charsets declared in the XML prolog
+func newXMLDecoder(r io.Reader) *xml.Decoder {
+	decoder := xml.NewDecoder(r)
+	decoder.CharsetReader = charsetReader
+	decoder.Strict = false
+	decoder.Entity = xml.HTMLEntity
+	return decoder
+}
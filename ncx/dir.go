@@ -0,0 +1,97 @@
+package ncx
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+// Chapter is a table of contents entry with its content, as read by
+// ExtractChaptersFromDir
+type Chapter struct {
+	Title string
+	Level int
+	Href  string
+
+	// Content is the chapter's plain text
+	Content string
+
+	// HTML is the chapter's raw HTML, set with DirOptions.KeepHTML
+	HTML string
+}
+
+// DirOptions configures ExtractChaptersFromDir
+type DirOptions struct {
+	// KeepHTML sets Chapter.HTML
+	KeepHTML bool
+
+	// MinWords drops entries with fewer words of text, such as title pages
+	MinWords int
+}
+
+// ExtractChaptersFromDir reads the chapters of an unpacked EPUB, one per
+// table of contents entry, without zipping it up again. The NCX is used if
+// there is one, else the EPUB 3 nav document, and content is sliced at
+// fragments and converted to text exactly as for a zipped EPUB. Entries
+// whose content can't be found are skipped.
+func ExtractChaptersFromDir(dir string, opts ...DirOptions) ([]Chapter, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open EPUB directory: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", dir)
+	}
+
+	var o DirOptions
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	return extractChapters(os.DirFS(dir), o)
+}
+
+// extractChapters reads the chapters listed in an EPUB tree's table of contents
+func extractChapters(fsys fs.FS, opts DirOptions) ([]Chapter, error) {
+	var entries []TOCEntry
+	if doc, err := extractNCX(fsys); err == nil {
+		entries = doc.GetTOC()
+		SortByPlayOrder(entries)
+	}
+	if len(entries) == 0 {
+		nav, err := extractNav(fsys)
+		if err != nil {
+			return nil, fmt.Errorf("no table of contents found: %w", err)
+		}
+		entries = nav
+	}
+
+	var chapters []Chapter
+	for i, entry := range entries {
+		nextHref := ""
+		if i+1 < len(entries) {
+			nextHref = entries[i+1].Href
+		}
+
+		html, err := chapterHTMLRange(fsys, entry.Href, nextHref)
+		if err != nil {
+			continue
+		}
+		content := htmlToText(html)
+		if len(strings.Fields(content)) < opts.MinWords {
+			continue
+		}
+
+		chapter := Chapter{Title: entry.Title, Level: entry.Level, Href: entry.Href, Content: content}
+		if opts.KeepHTML {
+			chapter.HTML = html
+		}
+		chapters = append(chapters, chapter)
+	}
+
+	if len(chapters) == 0 {
+		return nil, fmt.Errorf("failed to extract any chapter content")
+	}
+
+	return chapters, nil
+}
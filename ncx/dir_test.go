@@ -0,0 +1,73 @@
+package ncx
+
+import (
+	"archive/zip"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExtractChaptersFromDir(t *testing.T) {
+	files := map[string]string{
+		"mimetype": "application/epub+zip",
+		"META-INF/container.xml": `<?xml version="1.0"?>
+<container xmlns="urn:oasis:names:tc:opendocument:xmlns:container" version="1.0">
+<rootfiles><rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/></rootfiles>
+</container>`,
+		"OEBPS/content.opf": `<?xml version="1.0"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="2.0">
+<metadata/>
+<manifest>
+<item id="ncx" href="toc/toc.ncx" media-type="application/x-dtbncx+xml"/>
+<item id="tales" href="text/tales.xhtml" media-type="application/xhtml+xml"/>
+</manifest>
+<spine toc="ncx"><itemref idref="tales"/></spine>
+</package>`,
+		"OEBPS/toc/toc.ncx": `<?xml version="1.0"?>
+<ncx xmlns="http://www.daisy.org/z3986/2005/ncx/" version="2005-1"><navMap>
+<navPoint id="n1" playOrder="1"><navLabel><text>One</text></navLabel><content src="../text/tales.xhtml#c1"/></navPoint>
+<navPoint id="n2" playOrder="2"><navLabel><text>Two</text></navLabel><content src="../text/tales.xhtml#c2"/></navPoint>
+<navPoint id="n3" playOrder="3"><navLabel><text>Three</text></navLabel><content src="../text/tales.xhtml#c3"/></navPoint>
+</navMap></ncx>`,
+		"OEBPS/text/tales.xhtml": singleFileBook,
+	}
+	dir := writeTestDir(t, files)
+
+	chapters, err := ExtractChaptersFromDir(dir, DirOptions{KeepHTML: true})
+	if err != nil {
+		t.Fatalf("ExtractChaptersFromDir failed: %v", err)
+	}
+
+	var titles []string
+	for _, ch := range chapters {
+		titles = append(titles, ch.Title)
+	}
+	if want := []string{"One", "Two", "Three"}; !reflect.DeepEqual(titles, want) {
+		t.Fatalf("titles = %q, want %q", titles, want)
+	}
+	if !strings.Contains(chapters[0].Content, "More alpha text.") || strings.Contains(chapters[0].Content, "Beta") {
+		t.Errorf("chapter 1 content = %q", chapters[0].Content)
+	}
+	if !strings.HasPrefix(chapters[1].HTML, `<h2 id="c2">`) {
+		t.Errorf("chapter 2 HTML = %.40q", chapters[1].HTML)
+	}
+
+	// The zipped book gives the same chapters
+	zr, err := zip.OpenReader(writeTestEPUB(t, files))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+	zipped, err := extractChapters(&zr.Reader, DirOptions{KeepHTML: true})
+	if err != nil {
+		t.Fatalf("extractChapters on zip failed: %v", err)
+	}
+	if !reflect.DeepEqual(zipped, chapters) {
+		t.Errorf("zip chapters = %+v\nwant %+v", zipped, chapters)
+	}
+
+	if _, err := ExtractChaptersFromDir(filepath.Join(dir, "missing")); err == nil {
+		t.Error("expected an error for a missing directory")
+	}
+}
@@ -0,0 +1,61 @@
+package ncx
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// Font obfuscation algorithms as named in META-INF/encryption.xml
+const (
+	// IDPFFontAlgorithm XORs the first 1040 bytes with the SHA-1 of the
+	// package's unique identifier
+	IDPFFontAlgorithm = "http://www.idpf.org/2008/embedding"
+
+	// AdobeFontAlgorithm XORs the first 1024 bytes with the 16 bytes of the
+	// book's urn:uuid identifier
+	AdobeFontAlgorithm = "http://ns.adobe.com/pdf/enc#RC"
+)
+
+// DeobfuscateFont reverses IDPF font obfuscation, returning a clear copy of
+// data. uniqueIdentifier is the value of the OPF's unique identifier.
+// Obfuscation is an XOR, so the same call also obfuscates a clear font.
+func DeobfuscateFont(data []byte, uniqueIdentifier string) []byte {
+	key := sha1.Sum([]byte(strings.Map(func(r rune) rune {
+		// The key ignores whitespace in the identifier
+		if r == ' ' || r == '\t' || r == '\r' || r == '\n' {
+			return -1
+		}
+		return r
+	}, uniqueIdentifier)))
+	return xorPrefix(data, key[:], 1040)
+}
+
+// DeobfuscateFontAlgorithm reverses font obfuscation with the algorithm an
+// encryption.xml entry names: IDPFFontAlgorithm or AdobeFontAlgorithm
+func DeobfuscateFontAlgorithm(data []byte, uniqueIdentifier, algorithm string) ([]byte, error) {
+	switch algorithm {
+	case IDPFFontAlgorithm:
+		return DeobfuscateFont(data, uniqueIdentifier), nil
+	case AdobeFontAlgorithm:
+		uuid := strings.ToLower(strings.TrimSpace(uniqueIdentifier))
+		uuid = strings.TrimPrefix(uuid, "urn:uuid:")
+		key, err := hex.DecodeString(strings.ReplaceAll(uuid, "-", ""))
+		if err != nil || len(key) != 16 {
+			return nil, fmt.Errorf("adobe font obfuscation needs a UUID identifier, got %q", uniqueIdentifier)
+		}
+		return xorPrefix(data, key, 1024), nil
+	}
+	return nil, fmt.Errorf("unsupported font obfuscation algorithm: %s", algorithm)
+}
+
+// xorPrefix returns a copy of data with its first n bytes XORed with key
+func xorPrefix(data, key []byte, n int) []byte {
+	out := make([]byte, len(data))
+	copy(out, data)
+	for i := 0; i < n && i < len(out); i++ {
+		out[i] ^= key[i%len(key)]
+	}
+	return out
+}
@@ -0,0 +1,45 @@
+package ncx
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDeobfuscateFontRoundTrip(t *testing.T) {
+	font := make([]byte, 2000)
+	for i := range font {
+		font[i] = byte(i % 251)
+	}
+	uid := "urn:uuid:12345678-1234-1234-1234-123456789abc"
+
+	mangled := DeobfuscateFont(font, uid)
+	if bytes.Equal(mangled[:1040], font[:1040]) {
+		t.Fatal("obfuscation left the header unchanged")
+	}
+	if !bytes.Equal(mangled[1040:], font[1040:]) {
+		t.Error("obfuscation changed bytes past 1040")
+	}
+
+	// Whitespace in the identifier doesn't change the key
+	if got := DeobfuscateFont(mangled, " urn:uuid:12345678-1234-1234-1234-123456789abc\n"); !bytes.Equal(got, font) {
+		t.Error("IDPF round trip did not restore the font")
+	}
+
+	adobe, err := DeobfuscateFontAlgorithm(font, uid, AdobeFontAlgorithm)
+	if err != nil {
+		t.Fatalf("Adobe obfuscation failed: %v", err)
+	}
+	if !bytes.Equal(adobe[1024:], font[1024:]) || bytes.Equal(adobe[:1024], font[:1024]) {
+		t.Error("Adobe obfuscation should change exactly the first 1024 bytes")
+	}
+	if got, _ := DeobfuscateFontAlgorithm(adobe, uid, AdobeFontAlgorithm); !bytes.Equal(got, font) {
+		t.Error("Adobe round trip did not restore the font")
+	}
+
+	if _, err := DeobfuscateFontAlgorithm(font, "isbn:9780306406157", AdobeFontAlgorithm); err == nil {
+		t.Error("expected an error for an Adobe key that isn't a UUID")
+	}
+	if _, err := DeobfuscateFontAlgorithm(font, uid, "http://example.com/rot13"); err == nil {
+		t.Error("expected an error for an unknown algorithm")
+	}
+}
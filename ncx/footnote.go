@@ -0,0 +1,185 @@
+package ncx
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Footnote is a note referenced from chapter text
+type Footnote struct {
+	// ID is the id of the note's element, which the reference links to
+	ID string
+
+	// Marker is the reference's text in the body, such as "1" or "*"
+	Marker string
+
+	// Text is the note's plain text, without its leading marker. It is
+	// empty when the note isn't in the same HTML as its reference, such as
+	// notes collected in a separate file.
+	Text string
+}
+
+var (
+	tagRe  = regexp.MustCompile(`<(/?)([a-zA-Z][\w:.-]*)([^>]*)>`)
+	attrRe = regexp.MustCompile(`([\w:.-]+)\s*=\s*(?:"([^"]*)"|'([^']*)')`)
+)
+
+// noteEndTags end the text of a note whose id is on an anchor inside it
+var noteEndTags = map[string]bool{
+	"p": true, "div": true, "li": true, "aside": true, "dd": true, "section": true,
+}
+
+// ExtractFootnotes finds the footnote references in an HTML fragment and
+// pairs each with its note. References are <a epub:type="noteref"> (or
+// role="doc-noteref") links, or links inside or around a <sup>; notes are
+// the elements they link to by id, typically <aside epub:type="footnote">.
+// Notes are returned once each, in order of first reference.
+func ExtractFootnotes(html string) []Footnote {
+	var notes []Footnote
+	seen := make(map[string]bool)
+	supDepth := 0
+
+	for _, m := range tagRe.FindAllStringSubmatchIndex(html, -1) {
+		closing := m[3] > m[2]
+		name := strings.ToLower(html[m[4]:m[5]])
+
+		if name == "sup" {
+			if closing {
+				supDepth = max(supDepth-1, 0)
+			} else {
+				supDepth++
+			}
+			continue
+		}
+		if closing || name != "a" {
+			continue
+		}
+
+		attrs := parseAttrs(html[m[6]:m[7]])
+		file, id, _ := strings.Cut(attrs["href"], "#")
+		if id == "" {
+			continue
+		}
+
+		innerEnd, _ := elementEnd(html, "a", m[1])
+		inner := html[m[1]:innerEnd]
+		explicit := hasProperty(attrs["epub:type"], "noteref") || hasProperty(attrs["role"], "doc-noteref")
+		if !explicit && supDepth == 0 && !strings.Contains(strings.ToLower(inner), "<sup") {
+			continue
+		}
+		if seen[id] {
+			continue
+		}
+
+		// Only notes in this HTML have their text at hand
+		marker := strings.TrimSpace(HTMLToText(inner, TextOptions{CollapseWhitespace: true}))
+		text, found := "", false
+		if file == "" {
+			text, found = noteText(html, id)
+		}
+		if !found && !explicit {
+			continue
+		}
+
+		seen[id] = true
+		notes = append(notes, Footnote{ID: id, Marker: marker, Text: trimMarker(text, marker)})
+	}
+
+	return notes
+}
+
+// noteText returns the plain text of the element with the given id. When the
+// id is on an anchor, as in Project Gutenberg's notes, the text following it
+// up to the end of the enclosing block is used instead.
+func noteText(html, id string) (string, bool) {
+	start := findAnchor(html, id, 0)
+	if start == -1 {
+		return "", false
+	}
+	m := tagRe.FindStringSubmatchIndex(html[start:])
+	if m == nil || m[0] != 0 {
+		return "", false
+	}
+	name := strings.ToLower(html[start+m[4] : start+m[5]])
+	contentStart := start + m[1]
+
+	innerEnd, outerEnd := elementEnd(html, name, contentStart)
+	content := html[contentStart:innerEnd]
+	if name == "a" {
+		content = html[outerEnd:blockEnd(html, outerEnd)]
+	}
+
+	return strings.TrimSpace(HTMLToText(content, TextOptions{CollapseWhitespace: true})), true
+}
+
+// elementEnd returns the offsets of the closing tag matching an element of the
+// given name whose content starts at from, allowing for nested elements of the
+// same name. An unclosed element runs to the end of the HTML.
+func elementEnd(html, name string, from int) (innerEnd, outerEnd int) {
+	depth := 1
+	for _, m := range tagRe.FindAllStringSubmatchIndex(html[from:], -1) {
+		if !strings.EqualFold(html[from+m[4]:from+m[5]], name) {
+			continue
+		}
+		if m[3] == m[2] {
+			if !strings.HasSuffix(html[from+m[6]:from+m[7]], "/") {
+				depth++
+			}
+			continue
+		}
+		if depth--; depth == 0 {
+			return from + m[0], from + m[1]
+		}
+	}
+	return len(html), len(html)
+}
+
+// blockEnd returns the offset of the first closing block tag at or after from
+func blockEnd(html string, from int) int {
+	for _, m := range tagRe.FindAllStringSubmatchIndex(html[from:], -1) {
+		if m[3] > m[2] && noteEndTags[strings.ToLower(html[from+m[4]:from+m[5]])] {
+			return from + m[0]
+		}
+	}
+	return len(html)
+}
+
+// parseAttrs returns a tag's attributes keyed by lowercased name
+func parseAttrs(s string) map[string]string {
+	attrs := make(map[string]string)
+	for _, m := range attrRe.FindAllStringSubmatch(s, -1) {
+		value := m[2]
+		if value == "" {
+			value = m[3]
+		}
+		attrs[strings.ToLower(m[1])] = value
+	}
+	return attrs
+}
+
+// trimMarker removes a note's own copy of its marker, such as the "1." or
+// "[1]" backlink that usually starts it
+func trimMarker(text, marker string) string {
+	if marker == "" {
+		return text
+	}
+	rest := text
+	if !strings.HasPrefix(rest, marker) {
+		if rest = strings.TrimLeft(text, "[("); !strings.HasPrefix(rest, marker) {
+			return text
+		}
+	}
+	rest = rest[len(marker):]
+	if r, _ := utf8.DecodeRuneInString(rest); unicode.IsLetter(r) || unicode.IsDigit(r) {
+		return text
+	}
+	rest = strings.TrimLeftFunc(rest, func(r rune) bool {
+		return unicode.IsSpace(r) || strings.ContainsRune(".:)]", r)
+	})
+	if rest == "" {
+		return text
+	}
+	return rest
+}
@@ -0,0 +1,43 @@
+package ncx
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractFootnotesNoteref(t *testing.T) {
+	html := `<p>The ship sailed at dawn.<a epub:type="noteref" href="#fn1" id="r1">1</a>
+It was lost by noon<a epub:type="noteref" href="#fn2">*</a>, and see <a href="#ch2">chapter 2</a>.</p>
+<aside epub:type="footnote" id="fn1"><p><a href="#r1">1.</a> The <em>Pequod</em>, a whaler.</p></aside>
+<aside epub:type="footnote" id="fn2"><p>Some say by evening.</p></aside>`
+
+	want := []Footnote{
+		{ID: "fn1", Marker: "1", Text: "The Pequod, a whaler."},
+		{ID: "fn2", Marker: "*", Text: "Some say by evening."},
+	}
+	if got := ExtractFootnotes(html); !reflect.DeepEqual(got, want) {
+		t.Errorf("ExtractFootnotes() = %+v, want %+v", got, want)
+	}
+}
+
+func TestExtractFootnotesSupLinks(t *testing.T) {
+	// Project Gutenberg style: superscript links to an anchor inside the note
+	html := `<p>He was born in 1812.<sup><a href="#note-3" id="ref-3">3</a></sup>
+Again<sup><a href="#note-3">3</a></sup> and a plain <a href="#note-9">link</a>.</p>
+<p class="footnote"><a id="note-3" href="#ref-3">[3]</a> In Portsmouth.</p>`
+
+	want := []Footnote{{ID: "note-3", Marker: "3", Text: "In Portsmouth."}}
+	if got := ExtractFootnotes(html); !reflect.DeepEqual(got, want) {
+		t.Errorf("ExtractFootnotes() = %+v, want %+v", got, want)
+	}
+}
+
+func TestExtractFootnotesMissingNote(t *testing.T) {
+	// Notes kept in a separate file still count, without their text
+	html := `<p>Text<a epub:type="noteref" href="notes.xhtml#n1">1</a> and more<a epub:type="noteref" href="#n2">2</a>.</p>`
+
+	want := []Footnote{{ID: "n1", Marker: "1"}, {ID: "n2", Marker: "2"}}
+	if got := ExtractFootnotes(html); !reflect.DeepEqual(got, want) {
+		t.Errorf("ExtractFootnotes() = %+v, want %+v", got, want)
+	}
+}
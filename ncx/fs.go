@@ -0,0 +1,140 @@
+package ncx
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// openEPUBFS opens the EPUB zip called name in fsys, such as an embed.FS or
+// an object store. Files that support ReadAt are read in place; others are
+// read into memory first. The returned file must be closed when done.
+func openEPUBFS(fsys fs.FS, name string) (*zip.Reader, fs.File, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open EPUB: %w", err)
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, nil, fmt.Errorf("failed to stat EPUB: %w", err)
+	}
+
+	ra, ok := f.(io.ReaderAt)
+	size := info.Size()
+	if !ok {
+		data, err := io.ReadAll(f)
+		if err != nil {
+			f.Close()
+			return nil, nil, fmt.Errorf("failed to read EPUB: %w", err)
+		}
+		ra, size = bytes.NewReader(data), int64(len(data))
+	}
+
+	zr, err := zip.NewReader(ra, size)
+	if err != nil {
+		f.Close()
+		return nil, nil, fmt.Errorf("failed to open EPUB: %w", err)
+	}
+	return zr, f, nil
+}
+
+// ExtractNCXFromFS extracts and parses the NCX file from the EPUB called name
+// in fsys, for books that aren't on local disk
+func ExtractNCXFromFS(fsys fs.FS, name string) (*NCX, error) {
+	zr, f, err := openEPUBFS(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return extractNCX(zr)
+}
+
+// ExtractNavFromFS is ExtractNavFromEPUB for the EPUB called name in fsys
+func ExtractNavFromFS(fsys fs.FS, name string) ([]TOCEntry, error) {
+	zr, f, err := openEPUBFS(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return extractNav(zr)
+}
+
+// GetChapterHTMLRangeFS is GetChapterHTMLRange for the EPUB called name in fsys
+func GetChapterHTMLRangeFS(fsys fs.FS, name, href, nextHref string) (string, error) {
+	zr, f, err := openEPUBFS(fsys, name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	return chapterHTMLRange(zr, href, nextHref)
+}
+
+// GetChapterContentRangeFS is GetChapterContentRange for the EPUB called name
+// in fsys
+func GetChapterContentRangeFS(fsys fs.FS, name, href, nextHref string) (string, error) {
+	html, err := GetChapterHTMLRangeFS(fsys, name, href, nextHref)
+	if err != nil {
+		return "", err
+	}
+	return htmlToText(html), nil
+}
+
+// ExtractChaptersFromFS is ExtractChaptersFromDir for the zipped EPUB called
+// name in fsys
+func ExtractChaptersFromFS(fsys fs.FS, name string, opts ...DirOptions) ([]Chapter, error) {
+	zr, f, err := openEPUBFS(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var o DirOptions
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	return extractChapters(zr, o)
+}
+
+// fileNames lists the files in an EPUB's tree: a zip's entries in archive
+// order, or any other tree's files in lexical order
+func fileNames(fsys fs.FS) []string {
+	var names []string
+	if zr, ok := fsys.(*zip.Reader); ok {
+		for _, f := range zr.File {
+			if !strings.HasSuffix(f.Name, "/") {
+				names = append(names, f.Name)
+			}
+		}
+		return names
+	}
+
+	fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			names = append(names, name)
+		}
+		return nil
+	})
+	return names
+}
+
+// findFile returns the cleaned name if the tree has a file by that name,
+// or "" if it doesn't
+func findFile(fsys fs.FS, name string) string {
+	name = path.Clean(name)
+	if !fs.ValidPath(name) {
+		return ""
+	}
+	if info, err := fs.Stat(fsys, name); err != nil || info.IsDir() {
+		return ""
+	}
+	return name
+}
@@ -0,0 +1,63 @@
+package ncx
+
+import (
+	"io/fs"
+	"os"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// streamFS serves files without io.ReaderAt, like a network object store
+type streamFS struct{ fs.FS }
+
+func (s streamFS) Open(name string) (fs.File, error) {
+	f, err := s.FS.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return struct{ fs.File }{f}, nil
+}
+
+func TestExtractFromFS(t *testing.T) {
+	data, err := os.ReadFile(writeTestEPUB(t, map[string]string{
+		"OEBPS/toc.ncx": `<?xml version="1.0"?>
+<ncx xmlns="http://www.daisy.org/z3986/2005/ncx/" version="2005-1"><navMap>
+<navPoint id="n1" playOrder="1"><navLabel><text>One</text></navLabel><content src="tales.xhtml#c1"/></navPoint>
+<navPoint id="n2" playOrder="2"><navLabel><text>Two</text></navLabel><content src="tales.xhtml#c2"/></navPoint>
+</navMap></ncx>`,
+		"OEBPS/tales.xhtml": singleFileBook,
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mapFS := fstest.MapFS{"books/tales.epub": {Data: data}}
+
+	for name, fsys := range map[string]fs.FS{"ReaderAt": mapFS, "stream": streamFS{mapFS}} {
+		doc, err := ExtractNCXFromFS(fsys, "books/tales.epub")
+		if err != nil {
+			t.Fatalf("%s: ExtractNCXFromFS failed: %v", name, err)
+		}
+		toc := doc.GetTOC()
+		if len(toc) != 2 || toc[1].Title != "Two" {
+			t.Fatalf("%s: toc = %+v", name, toc)
+		}
+
+		content, err := GetChapterContentRangeFS(fsys, "books/tales.epub", toc[0].Href, toc[1].Href)
+		if err != nil {
+			t.Fatalf("%s: GetChapterContentRangeFS failed: %v", name, err)
+		}
+		if !strings.Contains(content, "Alpha text") || strings.Contains(content, "Beta text") {
+			t.Errorf("%s: content = %q", name, content)
+		}
+
+		chapters, err := ExtractChaptersFromFS(fsys, "books/tales.epub")
+		if err != nil || len(chapters) != 2 {
+			t.Errorf("%s: ExtractChaptersFromFS = %d chapters, %v", name, len(chapters), err)
+		}
+	}
+
+	if _, err := ExtractNCXFromFS(mapFS, "books/missing.epub"); err == nil {
+		t.Error("expected an error for a missing EPUB")
+	}
+}
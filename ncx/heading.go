@@ -0,0 +1,166 @@
+package ncx
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ChapterStart is an element FindChapterStarts takes for a chapter heading
+type ChapterStart struct {
+	// Offset is the byte offset of the element's start tag in the HTML
+	Offset int
+
+	// Title is the heading's plain text. Adjacent headings with nothing
+	// between them, such as a chapter number followed by its name, are
+	// joined with ": ".
+	Title string
+}
+
+const (
+	// minHeadingScore is the score an element needs to count as a heading
+	minHeadingScore = 2
+
+	// maxHeadingWords is the most words an element can hold and still be
+	// a heading rather than body text
+	maxHeadingWords = 12
+)
+
+// headingTags are the elements considered as headings, with the score each
+// starts from. Only <h1> counts as a heading by itself.
+var headingTags = map[string]int{
+	"p": 0, "div": 0,
+	"h1": 2, "h2": 1, "h3": 0, "h4": 0, "h5": 0, "h6": 0,
+}
+
+var (
+	fontSizeRe   = regexp.MustCompile(`(?i)font-size\s*:\s*(?:([\d.]+)\s*(em|rem|%|pt|px)|(x-large|xx-large|xxx-large))`)
+	fontWeightRe = regexp.MustCompile(`(?i)font-weight\s*:\s*(?:bold|bolder|[6-9]00)`)
+)
+
+// FindChapterStarts looks for chapter headings that aren't marked up as
+// headings, as in books whose chapters open with a styled paragraph. Short
+// block elements are scored by class names containing "chap" (two points)
+// or "title"/"heading" (one point), by an explicitly large font size (two
+// points), by bold text (one point) and by being an <h1> or <h2>; styling on
+// inline elements inside the block counts too. Elements scoring two or more
+// are returned in document order.
+func FindChapterStarts(html string) []ChapterStart {
+	var starts []ChapterStart
+	lastEnd := 0
+
+	for _, m := range tagRe.FindAllStringSubmatchIndex(html, -1) {
+		// Closing tags, and elements inside the last heading found
+		if m[3] > m[2] || m[0] < lastEnd {
+			continue
+		}
+		name := strings.ToLower(html[m[4]:m[5]])
+		if _, ok := headingTags[name]; !ok {
+			continue
+		}
+		attrs := html[m[6]:m[7]]
+		if strings.HasSuffix(attrs, "/") {
+			continue
+		}
+
+		innerEnd, outerEnd := elementEnd(html, name, m[1])
+		inner := html[m[1]:innerEnd]
+		words := strings.Fields(HTMLToText(inner, TextOptions{CollapseWhitespace: true}))
+		if len(words) == 0 || len(words) > maxHeadingWords {
+			continue
+		}
+		if headingScore(name, attrs, inner) < minHeadingScore {
+			continue
+		}
+
+		title := strings.Join(words, " ")
+		if n := len(starts); n > 0 && !hasText(html[lastEnd:m[0]]) {
+			starts[n-1].Title += ": " + title
+		} else {
+			starts = append(starts, ChapterStart{Offset: m[0], Title: title})
+		}
+		lastEnd = outerEnd
+	}
+
+	return starts
+}
+
+// headingScore scores an element's likelihood of being a chapter heading
+// from its tag and the classes and styles of it and its descendants
+func headingScore(name, attrs, inner string) int {
+	all := []map[string]string{parseAttrs(attrs)}
+	bold := false
+	for _, m := range tagRe.FindAllStringSubmatchIndex(inner, -1) {
+		if m[3] > m[2] {
+			continue
+		}
+		switch strings.ToLower(inner[m[4]:m[5]]) {
+		case "b", "strong":
+			bold = true
+		}
+		all = append(all, parseAttrs(inner[m[6]:m[7]]))
+	}
+
+	chapterClass, titleClass, large := false, false, false
+	for _, a := range all {
+		for _, class := range strings.Fields(strings.ToLower(a["class"])) {
+			chapterClass = chapterClass || strings.Contains(class, "chap")
+			titleClass = titleClass || strings.Contains(class, "title") || strings.Contains(class, "heading")
+		}
+		large = large || largeFont(a)
+		bold = bold || fontWeightRe.MatchString(a["style"])
+	}
+
+	score := headingTags[name]
+	if chapterClass {
+		score += 2
+	}
+	if titleClass {
+		score++
+	}
+	if large {
+		score += 2
+	}
+	if bold {
+		score++
+	}
+	return score
+}
+
+// largeFont reports whether an element's inline style, or the size of a
+// legacy <font>, sets a font clearly larger than body text
+func largeFont(attrs map[string]string) bool {
+	if m := fontSizeRe.FindStringSubmatch(attrs["style"]); m != nil {
+		if m[3] != "" {
+			return true
+		}
+		size, err := strconv.ParseFloat(m[1], 64)
+		if err != nil {
+			return false
+		}
+		switch strings.ToLower(m[2]) {
+		case "em", "rem":
+			return size >= 1.4
+		case "%":
+			return size >= 140
+		case "pt":
+			return size >= 16
+		case "px":
+			return size >= 21
+		}
+	}
+
+	// <font size="5"> or <font size="+2">
+	if size := attrs["size"]; size != "" {
+		n, err := strconv.Atoi(strings.TrimPrefix(size, "+"))
+		if err != nil {
+			return false
+		}
+		if strings.HasPrefix(size, "+") {
+			return n >= 2
+		}
+		return n >= 5
+	}
+
+	return false
+}
@@ -0,0 +1,41 @@
+package ncx
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFindChapterStarts(t *testing.T) {
+	html := `<p class="title" style="font-size: 2em">The Silent Sea</p>
+<p>By A. Writer</p>
+<p class="chapnum">Chapter 1</p>
+<p class="chaptitle"><span style="font-size:150%">The Harbour</span></p>
+<p>The boats came in at dusk, <b>heavy</b> with fish.</p>
+<div class="chapter"><p style="font-size: 24px; font-weight: bold">CHAPTER II</p></div>
+<p class="chapter-body">The chapter class on a long paragraph of body text does not make it a heading at all, whatever it says.</p>
+<p><strong>Note:</strong> bold alone is not enough.</p>
+<h2>A Plain Subheading</h2>
+<p><font size="+2">III</font></p>
+<p>The end.</p>`
+
+	var got []string
+	for _, s := range FindChapterStarts(html) {
+		if !strings.HasPrefix(html[s.Offset:], "<") {
+			t.Errorf("offset %d of %q is not at a tag", s.Offset, s.Title)
+		}
+		got = append(got, s.Title)
+	}
+
+	want := []string{"The Silent Sea", "Chapter 1: The Harbour", "CHAPTER II", "III"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FindChapterStarts() titles = %q, want %q", got, want)
+	}
+}
+
+func TestFindChapterStartsNone(t *testing.T) {
+	html := `<p>Just text.</p><p style="font-size: 1.1em">Barely larger.</p><h3>Minor</h3>`
+	if got := FindChapterStarts(html); len(got) != 0 {
+		t.Errorf("FindChapterStarts() = %+v, want none", got)
+	}
+}
@@ -0,0 +1,160 @@
+package ncx
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/fs"
+	"strings"
+
+	"github.com/anilpdv/go-calibre/opf"
+)
+
+// ExtractNavFromEPUB extracts the table of contents from an EPUB 3 navigation
+// document (<nav epub:type="toc">). The nav document is located through the
+// OPF manifest, falling back to scanning the EPUB's XHTML files. Nested lists
+// increment Level the same way nested navPoints do in an NCX.
+func ExtractNavFromEPUB(epubPath string) ([]TOCEntry, error) {
+	r, err := zip.OpenReader(epubPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open EPUB: %w", err)
+	}
+	defer r.Close()
+
+	return extractNav(&r.Reader)
+}
+
+// extractNav finds and parses the nav document in an EPUB's file tree
+func extractNav(fsys fs.FS) ([]TOCEntry, error) {
+	var candidates []string
+
+	// Prefer the manifest item marked properties="nav"
+	if pkg, opfPath, err := opf.ReadPackage(fsys); err == nil {
+		for _, item := range pkg.Manifest.Items {
+			if hasProperty(item.Properties, "nav") {
+				if name := findFile(fsys, opf.ResolveHref(opfPath, item.Href)); name != "" {
+					candidates = append(candidates, name)
+				}
+			}
+		}
+	}
+
+	// Otherwise scan every XHTML file for a toc nav
+	if len(candidates) == 0 {
+		for _, name := range fileNames(fsys) {
+			lower := strings.ToLower(name)
+			if strings.HasSuffix(lower, ".xhtml") || strings.HasSuffix(lower, ".html") || strings.HasSuffix(lower, ".htm") {
+				candidates = append(candidates, name)
+			}
+		}
+	}
+
+	for _, name := range candidates {
+		f, err := fsys.Open(name)
+		if err != nil {
+			continue
+		}
+		entries, err := ParseNav(f)
+		f.Close()
+		if err == nil && len(entries) > 0 {
+			return entries, nil
+		}
+	}
+
+	return nil, fmt.Errorf("nav document not found in EPUB")
+}
+
+// ParseNav parses an XHTML navigation document and returns the entries of its
+// <nav epub:type="toc"> element
+func ParseNav(r io.Reader) ([]TOCEntry, error) {
+	decoder := newXMLDecoder(r)
+	decoder.AutoClose = xml.HTMLAutoClose
+
+	var (
+		entries  []TOCEntry
+		navDepth int // element depth inside the toc nav, 0 when outside
+		olDepth  int
+		current  *TOCEntry
+		text     strings.Builder
+	)
+
+	for {
+		tok, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse nav document: %w", err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if navDepth == 0 {
+				if t.Name.Local == "nav" && hasProperty(attr(t, "type"), "toc") {
+					navDepth = 1
+				}
+				continue
+			}
+			navDepth++
+
+			switch t.Name.Local {
+			case "ol":
+				olDepth++
+			case "a":
+				current = &TOCEntry{Href: attr(t, "href"), Level: olDepth}
+				text.Reset()
+			}
+
+		case xml.CharData:
+			if current != nil {
+				text.Write(t)
+			}
+
+		case xml.EndElement:
+			if navDepth == 0 {
+				continue
+			}
+			navDepth--
+
+			switch t.Name.Local {
+			case "ol":
+				olDepth--
+			case "a":
+				if current != nil && current.Href != "" {
+					current.Title = strings.Join(strings.Fields(text.String()), " ")
+					current.Order = len(entries) + 1
+					entries = append(entries, *current)
+				}
+				current = nil
+			}
+
+			// Only the first toc nav is used
+			if navDepth == 0 {
+				return entries, nil
+			}
+		}
+	}
+
+	return entries, nil
+}
+
+// attr returns the value of the named attribute, ignoring its namespace
+func attr(el xml.StartElement, name string) string {
+	for _, a := range el.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// hasProperty reports whether a space-separated property list contains prop
+func hasProperty(list, prop string) bool {
+	for _, p := range strings.Fields(list) {
+		if p == prop {
+			return true
+		}
+	}
+	return false
+}
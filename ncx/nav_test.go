@@ -0,0 +1,81 @@
+package ncx
+
+import (
+	"strings"
+	"testing"
+)
+
+const testNavDoc = `<?xml version="1.0" encoding="UTF-8"?>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops">
+<body>
+<nav epub:type="landmarks"><ol><li><a href="cover.xhtml">Cover</a></li></ol></nav>
+<nav epub:type="toc" id="toc">
+  <h1>Contents</h1>
+  <ol>
+    <li><a href="text/part1.xhtml">Part One</a>
+      <ol>
+        <li><a href="text/ch1.xhtml">Chapter 1:
+          <em>Arrival</em></a></li>
+        <li><a href="text/ch2.xhtml#s2">Chapter 2</a></li>
+      </ol>
+    </li>
+    <li><a href="text/part2.xhtml">Part&nbsp;Two</a></li>
+  </ol>
+</nav>
+</body>
+</html>`
+
+func TestParseNav(t *testing.T) {
+	entries, err := ParseNav(strings.NewReader(testNavDoc))
+	if err != nil {
+		t.Fatalf("ParseNav failed: %v", err)
+	}
+
+	want := []TOCEntry{
+		{Title: "Part One", Level: 1, Href: "text/part1.xhtml", Order: 1},
+		{Title: "Chapter 1: Arrival", Level: 2, Href: "text/ch1.xhtml", Order: 2},
+		{Title: "Chapter 2", Level: 2, Href: "text/ch2.xhtml#s2", Order: 3},
+		{Title: "Part Two", Level: 1, Href: "text/part2.xhtml", Order: 4},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("expected %d entries, got %d: %+v", len(want), len(entries), entries)
+	}
+	for i := range want {
+		if entries[i].Title != want[i].Title || entries[i].Level != want[i].Level ||
+			entries[i].Href != want[i].Href || entries[i].Order != want[i].Order {
+			t.Errorf("entry %d = %+v, want %+v", i, entries[i], want[i])
+		}
+	}
+}
+
+func TestExtractNavFromEPUB(t *testing.T) {
+	epub := writeTestEPUB(t, map[string]string{
+		"META-INF/container.xml": `<container><rootfiles><rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/></rootfiles></container>`,
+		"OEBPS/content.opf":      `<package><manifest><item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/></manifest></package>`,
+		"OEBPS/nav.xhtml":        testNavDoc,
+	})
+
+	entries, err := ExtractNavFromEPUB(epub)
+	if err != nil {
+		t.Fatalf("ExtractNavFromEPUB failed: %v", err)
+	}
+	if len(entries) != 4 {
+		t.Errorf("expected 4 entries, got %d", len(entries))
+	}
+}
+
+func TestExtractNavFromEPUBScan(t *testing.T) {
+	// No container.xml: the nav is found by scanning XHTML files
+	epub := writeTestEPUB(t, map[string]string{
+		"OEBPS/ch1.xhtml": "<html><body><p>Text</p></body></html>",
+		"OEBPS/toc.xhtml": testNavDoc,
+	})
+
+	entries, err := ExtractNavFromEPUB(epub)
+	if err != nil {
+		t.Fatalf("ExtractNavFromEPUB failed: %v", err)
+	}
+	if len(entries) != 4 {
+		t.Errorf("expected 4 entries, got %d", len(entries))
+	}
+}
@@ -7,8 +7,14 @@ import (
 	"encoding/xml"
 	"fmt"
 	"io"
-	"path/filepath"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
 	"strings"
+	"unicode"
+
+	"github.com/anilpdv/go-calibre/opf"
 )
 
 // NCX represents the root NCX document
@@ -57,10 +63,12 @@ type TOCEntry struct {
 	Children []TOCEntry
 }
 
-// ParseNCX parses NCX XML content
+// ParseNCX parses NCX XML content.
+// Element names are matched by local name, so namespace-prefixed NCX files
+// (e.g. <ncx:navPoint>) parse the same as default-namespace ones.
 func ParseNCX(r io.Reader) (*NCX, error) {
 	var ncx NCX
-	decoder := xml.NewDecoder(r)
+	decoder := newXMLDecoder(r)
 	if err := decoder.Decode(&ncx); err != nil {
 		return nil, fmt.Errorf("failed to parse NCX: %w", err)
 	}
@@ -81,6 +89,45 @@ func (ncx *NCX) GetTOC() []TOCEntry {
 	return entries
 }
 
+// SortByPlayOrder stably sorts flattened TOC entries by their NCX
+// playOrder, the authoritative reading sequence. If any entry lacks a
+// playOrder the entries are left in document order.
+func SortByPlayOrder(entries []TOCEntry) {
+	for _, entry := range entries {
+		if entry.Order <= 0 {
+			return
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Order < entries[j].Order
+	})
+}
+
+// GetNestedTOC returns the TOC as a tree, with each entry's Children populated
+func (ncx *NCX) GetNestedTOC() []TOCEntry {
+	var entries []TOCEntry
+	for _, np := range ncx.NavMap.NavPoints {
+		entries = append(entries, nestNavPoint(np, 1))
+	}
+	return entries
+}
+
+// nestNavPoint recursively converts a NavPoint and its children to a TOCEntry tree
+func nestNavPoint(np NavPoint, level int) TOCEntry {
+	entry := TOCEntry{
+		Title: strings.TrimSpace(np.Label.Text),
+		Level: level,
+		Href:  np.Content.Src,
+		Order: np.PlayOrder,
+	}
+
+	for _, child := range np.Children {
+		entry.Children = append(entry.Children, nestNavPoint(child, level+1))
+	}
+
+	return entry
+}
+
 // flattenNavPoint recursively flattens a NavPoint and its children
 func flattenNavPoint(np NavPoint, level int) []TOCEntry {
 	entry := TOCEntry{
@@ -108,26 +155,23 @@ func ExtractNCXFromEPUB(epubPath string) (*NCX, error) {
 	}
 	defer r.Close()
 
-	// Look for NCX file
-	var ncxFile *zip.File
-	for _, f := range r.File {
-		if strings.HasSuffix(strings.ToLower(f.Name), ".ncx") {
-			ncxFile = f
-			break
-		}
-	}
+	return extractNCX(&r.Reader)
+}
 
-	if ncxFile == nil {
+// extractNCX finds and parses the NCX file in an EPUB's file tree
+func extractNCX(fsys fs.FS) (*NCX, error) {
+	name := findNCXFile(fsys)
+	if name == "" {
 		return nil, fmt.Errorf("NCX file not found in EPUB")
 	}
 
-	rc, err := ncxFile.Open()
+	f, err := fsys.Open(name)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open NCX file: %w", err)
 	}
-	defer rc.Close()
+	defer f.Close()
 
-	return ParseNCX(rc)
+	return ParseNCX(f)
 }
 
 // GetChapterContent extracts the content of a specific chapter from an EPUB
@@ -139,164 +183,238 @@ func GetChapterContent(epubPath, href string) (string, error) {
 
 // GetChapterContentRange extracts content between two fragment identifiers
 func GetChapterContentRange(epubPath, href, nextHref string) (string, error) {
+	html, err := GetChapterHTMLRange(epubPath, href, nextHref)
+	if err != nil {
+		return "", err
+	}
+	return htmlToText(html), nil
+}
+
+// GetChapterHTMLRange returns the raw HTML between two fragment identifiers.
+// Without a start fragment the whole content file is returned.
+func GetChapterHTMLRange(epubPath, href, nextHref string) (string, error) {
 	r, err := zip.OpenReader(epubPath)
 	if err != nil {
 		return "", fmt.Errorf("failed to open EPUB: %w", err)
 	}
 	defer r.Close()
 
-	// Parse the href and fragment
-	hrefParts := strings.SplitN(href, "#", 2)
-	filePath := hrefParts[0]
-	startFragment := ""
-	if len(hrefParts) > 1 {
-		startFragment = hrefParts[1]
-	}
+	return chapterHTMLRange(&r.Reader, href, nextHref)
+}
+
+// chapterHTMLRange is GetChapterHTMLRange over an EPUB's file tree
+func chapterHTMLRange(fsys fs.FS, href, nextHref string) (string, error) {
+	// Parse the href and fragment. Hrefs are URLs, so "Chapter%201.xhtml"
+	// names the file "Chapter 1.xhtml".
+	rawPath, startFragment, _ := strings.Cut(href, "#")
+	filePath := opf.UnescapeHref(rawPath)
+	startFragment = opf.UnescapeHref(startFragment)
 
 	// Parse the next href fragment if provided
 	endFragment := ""
 	if nextHref != "" {
-		nextParts := strings.SplitN(nextHref, "#", 2)
+		nextPath, nextFragment, ok := strings.Cut(nextHref, "#")
+		nextPath = opf.UnescapeHref(nextPath)
 		// Only use end fragment if it's the same file
-		if len(nextParts) > 1 && (nextParts[0] == filePath || nextParts[0] == "" || strings.HasSuffix(filePath, nextParts[0])) {
-			endFragment = nextParts[1]
+		if ok && (nextPath == filePath || nextPath == "" || strings.HasSuffix(filePath, nextPath)) {
+			endFragment = opf.UnescapeHref(nextFragment)
 		}
 	}
 
-	// Normalize path
-	filePath = filepath.Clean(filePath)
+	name := findContentFile(fsys, filePath)
+	if name == "" && rawPath != filePath {
+		// A file name that really contains a '%'
+		name = findContentFile(fsys, rawPath)
+	}
+	if name == "" {
+		return "", fmt.Errorf("chapter file not found: %s", filePath)
+	}
 
-	// Try to find the file
-	for _, f := range r.File {
-		// Try exact match and with OEBPS prefix
-		if f.Name == filePath || strings.HasSuffix(f.Name, filePath) {
-			rc, err := f.Open()
-			if err != nil {
-				return "", err
-			}
-			defer rc.Close()
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return "", err
+	}
 
-			data, err := io.ReadAll(rc)
-			if err != nil {
-				return "", err
-			}
+	html := decodeContent(data)
 
-			html := string(data)
+	// If we have fragment identifiers, extract just that portion
+	if startFragment != "" {
+		html = extractFragmentContent(html, startFragment, endFragment)
+	}
 
-			// If we have fragment identifiers, extract just that portion
-			if startFragment != "" {
-				html = extractFragmentContent(html, startFragment, endFragment)
-			}
+	return html, nil
+}
 
-			return htmlToText(html), nil
+// findNCXFile returns the path of the EPUB's NCX document: the manifest item
+// with the NCX media type, or else the first .ncx file in the tree. It
+// returns "" if there is none.
+func findNCXFile(fsys fs.FS) string {
+	if pkg, opfPath, err := opf.ReadPackage(fsys); err == nil {
+		for _, item := range pkg.Manifest.Items {
+			if item.MediaType == "application/x-dtbncx+xml" {
+				if name := findFile(fsys, opf.ResolveHref(opfPath, item.Href)); name != "" {
+					return name
+				}
+			}
 		}
 	}
 
-	return "", fmt.Errorf("chapter file not found: %s", filePath)
+	for _, name := range fileNames(fsys) {
+		if strings.HasSuffix(strings.ToLower(name), ".ncx") {
+			return name
+		}
+	}
+	return ""
 }
 
-// extractFragmentContent extracts HTML content between two fragment identifiers
-func extractFragmentContent(html, startFragment, endFragment string) string {
-	// Find the start element with the given id
-	startPatterns := []string{
-		fmt.Sprintf(`id="%s"`, startFragment),
-		fmt.Sprintf(`id='%s'`, startFragment),
-		fmt.Sprintf(`name="%s"`, startFragment),
-		fmt.Sprintf(`name='%s'`, startFragment),
-	}
-
-	startIdx := -1
-	for _, pattern := range startPatterns {
-		idx := strings.Index(html, pattern)
-		if idx != -1 {
-			startIdx = idx
-			break
+// findContentFile returns the path of the file a TOC href points to, or "".
+// Hrefs are relative to the NCX, so they are resolved against its directory
+// first, then the OPF's and the root. Failing an exact match, a file whose
+// path ends with the href's (without leading "../" segments) is used,
+// preferring the shortest such path.
+func findContentFile(fsys fs.FS, href string) string {
+	var bases []string
+	if name := findNCXFile(fsys); name != "" {
+		bases = append(bases, path.Dir(name))
+	}
+	if opfPath, err := opf.FindOPFPath(fsys); err == nil {
+		bases = append(bases, path.Dir(opfPath))
+	}
+	bases = append(bases, ".")
+
+	for _, base := range bases {
+		if name := findFile(fsys, path.Join(base, href)); name != "" {
+			return name
 		}
 	}
 
+	rel := path.Clean(href)
+	for strings.HasPrefix(rel, "../") {
+		rel = rel[len("../"):]
+	}
+
+	match := ""
+	for _, name := range fileNames(fsys) {
+		if (name == rel || strings.HasSuffix(name, "/"+rel)) && (match == "" || len(name) < len(match)) {
+			match = name
+		}
+	}
+	return match
+}
+
+// extractFragmentContent extracts HTML content between two fragment identifiers.
+// The slice starts at the element carrying the start anchor and ends just before
+// the element carrying the end anchor. Without an end anchor it ends at the next
+// sibling heading or section, so single-file books don't bleed into later chapters.
+func extractFragmentContent(html, startFragment, endFragment string) string {
+	startIdx := findAnchor(html, startFragment, 0)
 	if startIdx == -1 {
 		// Fragment not found, return all content
 		return html
 	}
 
-	// Find the end fragment if specified
-	endIdx := len(html)
 	if endFragment != "" {
-		endPatterns := []string{
-			fmt.Sprintf(`id="%s"`, endFragment),
-			fmt.Sprintf(`id='%s'`, endFragment),
-			fmt.Sprintf(`name="%s"`, endFragment),
-			fmt.Sprintf(`name='%s'`, endFragment),
-		}
-
-		for _, pattern := range endPatterns {
-			idx := strings.Index(html[startIdx+1:], pattern)
-			if idx != -1 {
-				endIdx = startIdx + 1 + idx
-				break
-			}
+		if endIdx := findAnchor(html, endFragment, startIdx+1); endIdx != -1 {
+			return html[startIdx:endIdx]
 		}
 	}
 
-	// Extract the content between start and end
-	content := html[startIdx:endIdx]
+	return html[startIdx:nextSectionBoundary(html, startIdx)]
+}
 
-	// Try to find the closing tag of the element containing the start fragment
-	// and include content until we hit another major section
-	return content
+// findAnchor returns the offset of the opening '<' of the first element at or
+// after from whose id or name attribute equals fragment, or -1
+func findAnchor(html, fragment string, from int) int {
+	re := regexp.MustCompile(`\s(?:id|name)\s*=\s*["']` + regexp.QuoteMeta(fragment) + `["']`)
+	loc := re.FindStringIndex(html[from:])
+	if loc == nil {
+		return -1
+	}
+
+	attrIdx := from + loc[0]
+	if lt := strings.LastIndex(html[:attrIdx], "<"); lt >= from {
+		return lt
+	}
+	return attrIdx
 }
 
-// htmlToText converts HTML to plain text (simple version)
-func htmlToText(html string) string {
-	// Remove script and style tags
-	html = removeTag(html, "script")
-	html = removeTag(html, "style")
+// boundaryTagRe matches the start of heading and section elements
+var boundaryTagRe = regexp.MustCompile(`(?i)<(h[1-6]|section)[\s>/]`)
+
+// nextSectionBoundary finds where the chapter starting at startIdx ends: the
+// next heading of the same or higher rank as the chapter's own heading (h1-h3
+// when unknown), or the next section when the chapter is itself a section.
+// Headings before any chapter text are the chapter's own title and are skipped.
+func nextSectionBoundary(html string, startIdx int) int {
+	bodyStart := strings.Index(html[startIdx:], ">")
+	if bodyStart == -1 {
+		return len(html)
+	}
+	bodyStart += startIdx + 1
 
-	// Convert block elements to newlines
-	for _, tag := range []string{"p", "div", "br", "h1", "h2", "h3", "h4", "h5", "h6", "li", "tr"} {
-		html = strings.ReplaceAll(html, "<"+tag, "\n<"+tag)
-		html = strings.ReplaceAll(html, "</"+tag+">", "\n")
+	startTag := strings.ToLower(tagName(html[startIdx:]))
+	maxRank := headingRank(startTag)
+	ownHeading := maxRank > 0
+	if !ownHeading {
+		maxRank = 3
 	}
 
-	// Remove all remaining HTML tags
-	result := strings.Builder{}
-	inTag := false
-	for _, r := range html {
-		if r == '<' {
-			inTag = true
-		} else if r == '>' {
-			inTag = false
-		} else if !inTag {
-			result.WriteRune(r)
+	for _, m := range boundaryTagRe.FindAllStringSubmatchIndex(html[bodyStart:], -1) {
+		at := bodyStart + m[0]
+		name := strings.ToLower(html[bodyStart+m[2] : bodyStart+m[3]])
+
+		if !hasText(html[bodyStart:at]) {
+			// The chapter's own title sets the rank of its siblings
+			if rank := headingRank(name); rank > 0 && !ownHeading {
+				maxRank = rank
+				ownHeading = true
+			}
+			continue
 		}
-	}
 
-	// Clean up whitespace
-	text := result.String()
-	lines := strings.Split(text, "\n")
-	var cleanLines []string
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line != "" {
-			cleanLines = append(cleanLines, line)
+		if name == "section" {
+			if startTag == "section" {
+				return at
+			}
+			continue
+		}
+		if rank := headingRank(name); rank > 0 && rank <= maxRank {
+			return at
 		}
 	}
 
-	return strings.Join(cleanLines, "\n\n")
+	return len(html)
 }
 
-func removeTag(html, tag string) string {
-	// Simple tag removal - not perfect but works for most cases
-	for {
-		start := strings.Index(strings.ToLower(html), "<"+tag)
-		if start == -1 {
-			break
-		}
-		end := strings.Index(html[start:], "</"+tag+">")
-		if end == -1 {
-			break
+// tagName returns the element name of a tag starting at s[0] == '<'
+func tagName(s string) string {
+	end := strings.IndexAny(s[1:], " \t\r\n/>")
+	if end == -1 {
+		return ""
+	}
+	return s[1 : end+1]
+}
+
+// headingRank returns N for an hN element name, or 0
+func headingRank(name string) int {
+	if len(name) == 2 && name[0] == 'h' && name[1] >= '1' && name[1] <= '6' {
+		return int(name[1] - '0')
+	}
+	return 0
+}
+
+// hasText reports whether HTML contains any non-whitespace text outside tags
+func hasText(html string) bool {
+	inTag := false
+	for _, r := range html {
+		switch {
+		case r == '<':
+			inTag = true
+		case r == '>':
+			inTag = false
+		case !inTag && !unicode.IsSpace(r):
+			return true
 		}
-		html = html[:start] + html[start+end+len("</"+tag+">"):]
 	}
-	return html
+	return false
 }
@@ -0,0 +1,340 @@
+package ncx
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// writeTestEPUB writes an EPUB-like zip from a map of entry names to
+// contents and returns its path
+func writeTestEPUB(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "test.epub")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	zw := zip.NewWriter(f)
+	for _, name := range names {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(files[name])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+// writeTestDir writes an unpacked EPUB from a map of paths to contents and
+// returns its directory
+func writeTestDir(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestParseNCXPrefixedNamespace(t *testing.T) {
+	f, err := os.Open("testdata/prefixed.ncx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	doc, err := ParseNCX(f)
+	if err != nil {
+		t.Fatalf("ParseNCX failed: %v", err)
+	}
+
+	if doc.DocTitle.Text != "Prefixed & Proud" {
+		t.Errorf("DocTitle = %q", doc.DocTitle.Text)
+	}
+
+	toc := doc.GetTOC()
+	if len(toc) != 3 {
+		t.Fatalf("expected 3 TOC entries, got %d", len(toc))
+	}
+	if toc[0].Title != "Chapter\u00a0One" {
+		t.Errorf("toc[0].Title = %q", toc[0].Title)
+	}
+	if toc[1].Level != 2 || toc[1].Href != "text/ch1.xhtml#s1" {
+		t.Errorf("toc[1] = %+v", toc[1])
+	}
+	if toc[2].Title != "Chapter Two" {
+		t.Errorf("toc[2].Title = %q", toc[2].Title)
+	}
+}
+
+func TestParseNCXLatin1(t *testing.T) {
+	data, err := os.ReadFile("testdata/latin1.ncx")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	doc, err := ParseNCXBytes(data)
+	if err != nil {
+		t.Fatalf("ParseNCXBytes failed: %v", err)
+	}
+
+	if doc.DocTitle.Text != "Les Misérables" {
+		t.Errorf("DocTitle = %q", doc.DocTitle.Text)
+	}
+
+	toc := doc.GetTOC()
+	if len(toc) != 2 {
+		t.Fatalf("expected 2 TOC entries, got %d", len(toc))
+	}
+	if toc[0].Title != "Chapitre premier - Fantôme" {
+		t.Errorf("toc[0].Title = %q", toc[0].Title)
+	}
+	if toc[1].Title != "Épilogue" {
+		t.Errorf("toc[1].Title = %q", toc[1].Title)
+	}
+}
+
+func TestGetChapterContentLatin1(t *testing.T) {
+	// "Café, naïve, Señor" encoded as ISO-8859-1
+	latin1 := "<?xml version=\"1.0\" encoding=\"ISO-8859-1\"?>\n" +
+		"<html xmlns=\"http://www.w3.org/1999/xhtml\"><body><p>Caf\xe9, na\xefve, Se\xf1or</p></body></html>"
+	meta := "<html><head><meta http-equiv=\"Content-Type\" content=\"text/html; charset=windows-1252\"/></head>" +
+		"<body><p>\x93Quoted\x94 \x96 d\xe9j\xe0 vu</p></body></html>"
+	utf8Doc := "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<html><body><p>Crème brûlée</p></body></html>"
+
+	epub := writeTestEPUB(t, map[string]string{
+		"OEBPS/latin1.xhtml": latin1,
+		"OEBPS/meta.xhtml":   meta,
+		"OEBPS/utf8.xhtml":   utf8Doc,
+	})
+
+	tests := []struct {
+		href string
+		want string
+	}{
+		{"latin1.xhtml", "Café, naïve, Señor"},
+		{"meta.xhtml", "“Quoted” – déjà vu"},
+		{"utf8.xhtml", "Crème brûlée"},
+	}
+	for _, tt := range tests {
+		content, err := GetChapterContent(epub, tt.href)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.href, err)
+		}
+		if !strings.Contains(content, tt.want) {
+			t.Errorf("%s: content = %q, want it to contain %q", tt.href, content, tt.want)
+		}
+	}
+}
+
+func TestGetNestedTOC(t *testing.T) {
+	data, err := os.ReadFile("testdata/prefixed.ncx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, err := ParseNCXBytes(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	toc := doc.GetNestedTOC()
+	if len(toc) != 2 {
+		t.Fatalf("expected 2 top-level entries, got %d", len(toc))
+	}
+	if len(toc[0].Children) != 1 {
+		t.Fatalf("expected 1 child under the first entry, got %d", len(toc[0].Children))
+	}
+	child := toc[0].Children[0]
+	if child.Level != 2 || child.Href != "text/ch1.xhtml#s1" {
+		t.Errorf("child = %+v", child)
+	}
+	if len(toc[1].Children) != 0 {
+		t.Errorf("second entry should have no children")
+	}
+}
+
+// singleFileBook has three anchored chapters in one XHTML file, each with a
+// sub-heading that must not end the chapter early
+const singleFileBook = `<html><body>
+<h1>The Collected Tales</h1>
+<h2 id="c1">Chapter One</h2>
+<p>Alpha text of the first tale.</p>
+<h3>An aside</h3>
+<p>More alpha text.</p>
+<h2 id="c2">Chapter Two</h2>
+<p>Beta text of the second tale.</p>
+<div><a id="c3"></a><h2>Chapter Three</h2></div>
+<p>Gamma text of the third tale.</p>
+</body></html>`
+
+func TestExtractFragmentContentSingleFile(t *testing.T) {
+	epub := writeTestEPUB(t, map[string]string{"OEBPS/tales.xhtml": singleFileBook})
+
+	tests := []struct {
+		href, next string
+		want       []string
+		notWant    []string
+	}{
+		{"tales.xhtml#c1", "tales.xhtml#c2", []string{"Chapter One", "Alpha", "An aside", "More alpha"}, []string{"Beta", "Collected"}},
+		{"tales.xhtml#c2", "tales.xhtml#c3", []string{"Chapter Two", "Beta"}, []string{"Alpha", "Gamma", "Chapter Three"}},
+		{"tales.xhtml#c3", "", []string{"Chapter Three", "Gamma"}, []string{"Alpha", "Beta"}},
+		// No end anchor: stop at the next sibling heading, not at the sub-heading
+		{"tales.xhtml#c1", "", []string{"Alpha", "An aside", "More alpha"}, []string{"Beta", "Gamma"}},
+		{"tales.xhtml#c2", "other.xhtml", []string{"Beta"}, []string{"Gamma"}},
+	}
+
+	for _, tt := range tests {
+		content, err := GetChapterContentRange(epub, tt.href, tt.next)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.href, err)
+		}
+		for _, w := range tt.want {
+			if !strings.Contains(content, w) {
+				t.Errorf("%s -> %q: missing %q in %q", tt.href, tt.next, w, content)
+			}
+		}
+		for _, nw := range tt.notWant {
+			if strings.Contains(content, nw) {
+				t.Errorf("%s -> %q: unexpected %q in %q", tt.href, tt.next, nw, content)
+			}
+		}
+	}
+}
+
+func TestExtractFragmentContentSections(t *testing.T) {
+	html := `<body><section id="s1"><h2>One</h2><p>First.</p><h3>Sub</h3><p>Still first.</p></section>` +
+		`<section id="s2"><h2>Two</h2><p>Second.</p></section></body>`
+
+	got := htmlToText(extractFragmentContent(html, "s1", ""))
+	if !strings.Contains(got, "Still first.") || strings.Contains(got, "Second.") {
+		t.Errorf("section s1 = %q", got)
+	}
+
+	// Fragment not found returns everything
+	if got := extractFragmentContent(html, "missing", ""); got != html {
+		t.Errorf("missing fragment should return all content")
+	}
+}
+
+func TestSortByPlayOrder(t *testing.T) {
+	entries := []TOCEntry{{Title: "C", Order: 3}, {Title: "A", Order: 1}, {Title: "B", Order: 2}, {Title: "B2", Order: 2}}
+	SortByPlayOrder(entries)
+	var got []string
+	for _, e := range entries {
+		got = append(got, e.Title)
+	}
+	if strings.Join(got, ",") != "A,B,B2,C" {
+		t.Errorf("sorted = %v", got)
+	}
+
+	// Without playOrder on every entry, document order is kept
+	entries = []TOCEntry{{Title: "C", Order: 3}, {Title: "A"}, {Title: "B", Order: 2}}
+	SortByPlayOrder(entries)
+	if entries[0].Title != "C" || entries[1].Title != "A" {
+		t.Errorf("entries without playOrder were reordered: %+v", entries)
+	}
+}
+
+func TestGetChapterContentResolvesHref(t *testing.T) {
+	chapter := func(text string) string { return "<html><body><p>" + text + "</p></body></html>" }
+
+	tests := []struct {
+		name    string
+		ncxPath string
+		href    string
+	}{
+		{"relative to the NCX", "OEBPS/toc.ncx", "Text/ch1.xhtml"},
+		{"parent segments", "OEBPS/Nav/toc.ncx", "../Text/ch1.xhtml"},
+	}
+	for _, tt := range tests {
+		epub := writeTestEPUB(t, map[string]string{
+			"META-INF/container.xml": `<container><rootfiles><rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/></rootfiles></container>`,
+			"OEBPS/content.opf": `<package><manifest><item id="ncx" href="` + strings.TrimPrefix(tt.ncxPath, "OEBPS/") +
+				`" media-type="application/x-dtbncx+xml"/></manifest></package>`,
+			tt.ncxPath: `<ncx><navMap/></ncx>`,
+			// Decoys that a plain suffix match could pick instead
+			"Text/ch1.xhtml":              chapter("decoy at the root"),
+			"Backup/OEBPS/Text/ch1.xhtml": chapter("decoy backup"),
+			"OEBPS/Text/ch1.xhtml":        chapter("the real chapter"),
+		})
+
+		content, err := GetChapterContent(epub, tt.href)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if content != "the real chapter" {
+			t.Errorf("%s: got %q, want the real chapter", tt.name, content)
+		}
+	}
+}
+
+func TestGetChapterContentSuffixFallback(t *testing.T) {
+	// Without an NCX or OPF the shortest path ending in the href wins
+	epub := writeTestEPUB(t, map[string]string{
+		"OEBPS/Text/ch1.xhtml":       "<p>real</p>",
+		"Extra/OEBPS/Text/ch1.xhtml": "<p>decoy</p>",
+		"OEBPS/Text/xch1.xhtml":      "<p>wrong name</p>",
+	})
+
+	content, err := GetChapterContent(epub, "../Text/ch1.xhtml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content != "real" {
+		t.Errorf("got %q, want real", content)
+	}
+}
+
+func TestGetChapterContentPercentEncoded(t *testing.T) {
+	epub := writeTestEPUB(t, map[string]string{
+		"OEBPS/Chapter 1.xhtml": `<html><body><h2 id="intro">Intro</h2><p>Opening.</p>` +
+			`<h2 id="sec-1">Section</h2><p>The first section.</p></body></html>`,
+		"OEBPS/100%.xhtml": `<p>Literal percent.</p>`,
+	})
+
+	tests := []struct {
+		href, next string
+		want       string
+	}{
+		{"Chapter%201.xhtml#sec%2D1", "", "Section\n\nThe first section."},
+		{"Chapter%201.xhtml#intro", "Chapter%201.xhtml#sec%2D1", "Intro\n\nOpening."},
+		// Already decoded hrefs still work
+		{"Chapter 1.xhtml#sec-1", "", "Section\n\nThe first section."},
+		{"100%.xhtml", "", "Literal percent."},
+	}
+	for _, tt := range tests {
+		content, err := GetChapterContentRange(epub, tt.href, tt.next)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.href, err)
+		}
+		if content != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.href, content, tt.want)
+		}
+	}
+}
@@ -0,0 +1,231 @@
+package ncx
+
+import (
+	"html"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// TextOptions controls how HTMLToText lays out plain text
+type TextOptions struct {
+	// ListBullets prefixes list items with "• ", or "1. " in ordered lists
+	ListBullets bool
+
+	// BlankLines separates block elements (paragraphs, headings, list
+	// items) with a blank line instead of a single newline
+	BlankLines bool
+
+	// CollapseWhitespace turns runs of spaces and source line breaks into a
+	// single space, so wrapped HTML reads as flowing text. Content inside
+	// <pre> is always kept as is.
+	CollapseWhitespace bool
+}
+
+// defaultTextOptions is the layout used for chapter content
+var defaultTextOptions = TextOptions{BlankLines: true, CollapseWhitespace: true}
+
+// blockTags start a new block of text when opened or closed
+var blockTags = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true,
+	"dd": true, "div": true, "dl": true, "dt": true, "figcaption": true,
+	"figure": true, "footer": true, "h1": true, "h2": true, "h3": true,
+	"h4": true, "h5": true, "h6": true, "header": true, "hr": true,
+	"li": true, "ol": true, "p": true, "pre": true, "section": true,
+	"table": true, "tr": true, "ul": true,
+}
+
+// HTMLToText converts an HTML fragment to plain text. Tags are dropped,
+// entities decoded, script and style content removed, and block elements
+// put on their own lines; <br> becomes a line break within a block.
+func HTMLToText(s string, opts TextOptions) string {
+	t := textWriter{opts: opts}
+
+	for i := 0; i < len(s); {
+		if s[i] != '<' {
+			end := strings.IndexByte(s[i:], '<')
+			if end == -1 {
+				end = len(s) - i
+			}
+			t.text(html.UnescapeString(s[i : i+end]))
+			i += end
+			continue
+		}
+
+		// Comments and doctypes
+		if strings.HasPrefix(s[i:], "<!--") {
+			end := strings.Index(s[i:], "-->")
+			if end == -1 {
+				break
+			}
+			i += end + len("-->")
+			continue
+		}
+
+		end := strings.IndexByte(s[i:], '>')
+		if end == -1 {
+			t.text(s[i:])
+			break
+		}
+		closing := s[i+1] == '/'
+		var name string
+		if closing {
+			name = strings.ToLower(tagName(s[i+1:]))
+		} else {
+			name = strings.ToLower(tagName(s[i:]))
+		}
+		i += end + 1
+
+		// Skip everything up to the closing tag
+		if !closing && (name == "script" || name == "style") {
+			close := strings.Index(strings.ToLower(s[i:]), "</"+name)
+			if close == -1 {
+				break
+			}
+			i += close
+			if gt := strings.IndexByte(s[i:], '>'); gt != -1 {
+				i += gt + 1
+			}
+			continue
+		}
+
+		t.tag(name, closing)
+	}
+
+	return t.finish()
+}
+
+// htmlToText converts HTML to plain text with the chapter content layout
+func htmlToText(s string) string {
+	return HTMLToText(s, defaultTextOptions)
+}
+
+// textWriter accumulates HTMLToText output one block at a time
+type textWriter struct {
+	opts   TextOptions
+	blocks []string
+	cur    strings.Builder
+	pre    int    // depth of open <pre> elements
+	lists  []int  // item counters of open lists, -1 for unordered
+	prefix string // list marker waiting for the item's text
+	indent string // nesting indent for the current block's first line
+}
+
+// tag applies an element's effect on the layout
+func (t *textWriter) tag(name string, closing bool) {
+	switch {
+	case name == "br":
+		t.cur.WriteByte('\n')
+		return
+	case !blockTags[name]:
+		return
+	}
+
+	t.flush()
+
+	switch name {
+	case "pre":
+		if closing {
+			if t.pre > 0 {
+				t.pre--
+			}
+		} else {
+			t.pre++
+		}
+	case "ul", "ol":
+		if closing {
+			if len(t.lists) > 0 {
+				t.lists = t.lists[:len(t.lists)-1]
+			}
+		} else if name == "ol" {
+			t.lists = append(t.lists, 0)
+		} else {
+			t.lists = append(t.lists, -1)
+		}
+	case "li":
+		if !closing && t.opts.ListBullets {
+			t.prefix = t.bullet()
+			t.indent = strings.Repeat("  ", max(len(t.lists)-1, 0))
+		}
+	}
+}
+
+// bullet returns the marker for the next item of the innermost list
+func (t *textWriter) bullet() string {
+	last := len(t.lists) - 1
+	if last < 0 || t.lists[last] == -1 {
+		return "• "
+	}
+	t.lists[last]++
+	return strconv.Itoa(t.lists[last]) + ". "
+}
+
+// text adds character data to the current block
+func (t *textWriter) text(s string) {
+	if t.pre == 0 && t.opts.CollapseWhitespace {
+		s = collapseSpaces(s)
+	}
+	if t.prefix != "" && strings.TrimSpace(s) != "" {
+		t.cur.WriteString(t.prefix)
+		t.prefix = ""
+		s = strings.TrimLeftFunc(s, unicode.IsSpace)
+	}
+	t.cur.WriteString(s)
+}
+
+// flush ends the current block, keeping it if it has any text
+func (t *textWriter) flush() {
+	block := t.cur.String()
+	t.cur.Reset()
+
+	if t.pre > 0 {
+		// Preformatted text keeps its spacing, minus surrounding blank lines
+		block = strings.Trim(block, "\r\n")
+		if strings.TrimSpace(block) != "" {
+			t.blocks = append(t.blocks, block)
+		}
+		return
+	}
+
+	var lines []string
+	for _, line := range strings.Split(block, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) > 0 {
+		t.blocks = append(t.blocks, t.indent+strings.Join(lines, "\n"))
+		t.indent = ""
+	}
+}
+
+// finish flushes the last block and joins them all
+func (t *textWriter) finish() string {
+	t.flush()
+	sep := "\n"
+	if t.opts.BlankLines {
+		sep = "\n\n"
+	}
+	return strings.Join(t.blocks, sep)
+}
+
+// collapseSpaces replaces each run of whitespace with a single space
+func collapseSpaces(s string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range s {
+		if unicode.IsSpace(r) {
+			space = true
+			continue
+		}
+		if space {
+			b.WriteByte(' ')
+			space = false
+		}
+		b.WriteRune(r)
+	}
+	if space {
+		b.WriteByte(' ')
+	}
+	return b.String()
+}
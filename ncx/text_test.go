@@ -0,0 +1,39 @@
+package ncx
+
+import "testing"
+
+const textFragment = `<h2>Setup</h2>
+<p>Install   the <em>tools</em>,
+then run:</p>
+<pre>go build ./...
+  go test ./...</pre>
+<ul>
+  <li>First &amp; foremost</li>
+  <li><p>Second</p>
+    <ol><li>nested one</li><li>nested two</li></ol>
+  </li>
+</ul>
+<p>Line one<br/>Line two<br>Line three</p>
+<script>var x = "<p>hidden</p>";</script>`
+
+func TestHTMLToText(t *testing.T) {
+	got := HTMLToText(textFragment, TextOptions{ListBullets: true, BlankLines: true, CollapseWhitespace: true})
+	want := "Setup\n\n" +
+		"Install the tools, then run:\n\n" +
+		"go build ./...\n  go test ./...\n\n" +
+		"• First & foremost\n\n" +
+		"• Second\n\n" +
+		"  1. nested one\n\n" +
+		"  2. nested two\n\n" +
+		"Line one\nLine two\nLine three"
+	if got != want {
+		t.Errorf("HTMLToText =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestHTMLToTextCompact(t *testing.T) {
+	got := HTMLToText(`<ul><li>a</li><li>b</li></ul><p>c<br/>d</p>`, TextOptions{})
+	if want := "a\nb\nc\nd"; got != want {
+		t.Errorf("HTMLToText = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,79 @@
+package opf
+
+import (
+	"regexp"
+	"strings"
+)
+
+// nameSuffixes are generational and honorific suffixes that follow a comma
+// in a name without it being in "Last, First" form
+var nameSuffixes = map[string]bool{
+	"jr": true, "sr": true, "ii": true, "iii": true, "iv": true,
+	"phd": true, "md": true, "esq": true,
+}
+
+// authorSeparatorRe splits creator text that packs several authors together
+var authorSeparatorRe = regexp.MustCompile(`\s*[&;]\s*`)
+
+// NormalizeAuthor turns a name in "Last, First" form into "First Last",
+// leaving a trailing suffix in place, so "King, Martin Luther, Jr." becomes
+// "Martin Luther King, Jr.". A name that is already in display order, such
+// as "Martin Luther King, Jr.", is returned with its spacing tidied.
+func NormalizeAuthor(name string) string {
+	name = strings.Join(strings.Fields(name), " ")
+
+	parts := strings.Split(name, ",")
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+
+	suffix := ""
+	if n := len(parts); n > 1 && isNameSuffix(parts[n-1]) {
+		suffix, parts = parts[n-1], parts[:n-1]
+	}
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return name
+	}
+
+	display := parts[1] + " " + parts[0]
+	if suffix != "" {
+		display += ", " + suffix
+	}
+	return display
+}
+
+// isNameSuffix reports whether s is a suffix such as "Jr." or "III"
+func isNameSuffix(s string) bool {
+	return nameSuffixes[strings.ToLower(strings.ReplaceAll(s, ".", ""))]
+}
+
+// splitAuthors splits creator text holding several authors, as in "A & B"
+// or "A; B". " and " also separates them, but only between full names, so
+// a publisher-style name like "Simon and Schuster" stays whole.
+func splitAuthors(name string) []string {
+	var authors []string
+	for _, part := range authorSeparatorRe.Split(strings.TrimSpace(name), -1) {
+		pieces := strings.Split(part, " and ")
+		if len(pieces) > 1 && allFullNames(pieces) {
+			for _, p := range pieces {
+				authors = append(authors, strings.TrimSpace(p))
+			}
+			continue
+		}
+		if part != "" {
+			authors = append(authors, part)
+		}
+	}
+	return authors
+}
+
+// allFullNames reports whether every piece looks like a full name: at least
+// two words, or a "Last, First" pair
+func allFullNames(pieces []string) bool {
+	for _, p := range pieces {
+		if len(strings.Fields(p)) < 2 && !strings.Contains(p, ",") {
+			return false
+		}
+	}
+	return true
+}
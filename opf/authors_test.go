@@ -0,0 +1,48 @@
+package opf
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeAuthor(t *testing.T) {
+	tests := map[string]string{
+		"Doe, John":                "John Doe",
+		"  Doe,   John Q. ":        "John Q. Doe",
+		"John Doe":                 "John Doe",
+		"Martin Luther King, Jr.":  "Martin Luther King, Jr.",
+		"King, Martin Luther, Jr.": "Martin Luther King, Jr.",
+		"Plato":                    "Plato",
+		"Doe, John, Smith, Jane":   "Doe, John, Smith, Jane",
+	}
+	for in, want := range tests {
+		if got := NormalizeAuthor(in); got != want {
+			t.Errorf("NormalizeAuthor(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestParseAuthorsNormalized(t *testing.T) {
+	opf := `<package xmlns="http://www.idpf.org/2007/opf" version="2.0">
+<metadata xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:opf="http://www.idpf.org/2007/opf">
+<dc:creator opf:role="aut">Doe, John</dc:creator>
+<dc:creator opf:role="aut">Ann Writer &amp; Roe, Jane</dc:creator>
+<dc:creator>Martin Luther King, Jr.</dc:creator>
+<dc:creator>Max Smith and Eve Jones</dc:creator>
+<dc:creator>Simon and Schuster</dc:creator>
+<dc:creator opf:file-as="Inverted, Kept">Inverted, Kept</dc:creator>
+</metadata></package>`
+
+	meta, err := ParseBytes([]byte(opf))
+	if err != nil {
+		t.Fatalf("ParseBytes failed: %v", err)
+	}
+
+	want := []string{
+		"John Doe", "Ann Writer", "Jane Roe", "Martin Luther King, Jr.",
+		"Max Smith", "Eve Jones", "Simon and Schuster", "Inverted, Kept",
+	}
+	if !reflect.DeepEqual(meta.Authors, want) {
+		t.Errorf("Authors = %q, want %q", meta.Authors, want)
+	}
+}
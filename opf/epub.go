@@ -0,0 +1,101 @@
+package opf
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"path"
+)
+
+// containerPath is the fixed location of the EPUB container document
+const containerPath = "META-INF/container.xml"
+
+// Container represents META-INF/container.xml
+type Container struct {
+	XMLName   xml.Name   `xml:"container"`
+	Rootfiles []Rootfile `xml:"rootfiles>rootfile"`
+}
+
+// Rootfile points at a package document inside the EPUB
+type Rootfile struct {
+	FullPath  string `xml:"full-path,attr"`
+	MediaType string `xml:"media-type,attr"`
+}
+
+// FindOPFPath locates the package document inside an EPUB via
+// META-INF/container.xml. fsys is the EPUB's file tree, such as an opened
+// *zip.Reader or an os.DirFS of an unpacked EPUB.
+func FindOPFPath(fsys fs.FS) (string, error) {
+	f, err := fsys.Open(containerPath)
+	if err != nil {
+		return "", fmt.Errorf("container.xml not found in EPUB: %w", err)
+	}
+	defer f.Close()
+
+	var container Container
+	if err := xml.NewDecoder(f).Decode(&container); err != nil {
+		return "", fmt.Errorf("failed to parse container.xml: %w", err)
+	}
+
+	for _, rf := range container.Rootfiles {
+		if rf.MediaType == "" || rf.MediaType == "application/oebps-package+xml" {
+			if rf.FullPath != "" {
+				return rf.FullPath, nil
+			}
+		}
+	}
+
+	return "", fmt.Errorf("container.xml has no OPF rootfile")
+}
+
+// ReadPackage parses the package document of an opened EPUB, given as for
+// FindOPFPath, and returns it along with its path inside the EPUB
+func ReadPackage(fsys fs.FS) (*Package, string, error) {
+	opfPath, err := FindOPFPath(fsys)
+	if err != nil {
+		return nil, "", err
+	}
+
+	f, err := fsys.Open(opfPath)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to open OPF %s: %w", opfPath, err)
+	}
+	defer f.Close()
+
+	pkg, err := ParsePackage(f)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return pkg, opfPath, nil
+}
+
+// ReadPackageFromEPUB opens an EPUB file and parses its package document
+func ReadPackageFromEPUB(epubPath string) (*Package, string, error) {
+	r, err := zip.OpenReader(epubPath)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to open EPUB: %w", err)
+	}
+	defer r.Close()
+
+	return ReadPackage(&r.Reader)
+}
+
+// ResolveHref resolves a manifest href relative to the OPF's directory,
+// giving the entry name inside the zip
+func ResolveHref(opfPath, href string) string {
+	return path.Join(path.Dir(opfPath), href)
+}
+
+// UnescapeHref percent-decodes an href, or part of one, into the file name
+// it refers to, since zip entries are stored decoded. It is returned
+// unchanged if it isn't valid percent-encoding (such as an already decoded
+// "100%.xhtml").
+func UnescapeHref(s string) string {
+	if decoded, err := url.PathUnescape(s); err == nil {
+		return decoded
+	}
+	return s
+}
@@ -0,0 +1,41 @@
+package opf
+
+import "strings"
+
+// GuideMap returns the package's guide references as a map of reference type
+// (lowercased, e.g. "cover", "toc", "text") to manifest href. The first
+// reference of each type wins.
+func (p *Package) GuideMap() map[string]string {
+	guide := make(map[string]string)
+	for _, ref := range p.Guide {
+		typ := strings.ToLower(strings.TrimSpace(ref.Type))
+		if typ == "" || ref.Href == "" {
+			continue
+		}
+		if _, ok := guide[typ]; !ok {
+			guide[typ] = ref.Href
+		}
+	}
+	return guide
+}
+
+// ParseGuideFromEPUB returns an EPUB's guide as a map of reference type to
+// the referenced path inside the zip, keeping any #fragment. EPUB 3 books
+// often have no guide, which gives an empty map.
+func ParseGuideFromEPUB(epubPath string) (map[string]string, error) {
+	pkg, opfPath, err := ReadPackageFromEPUB(epubPath)
+	if err != nil {
+		return nil, err
+	}
+
+	guide := pkg.GuideMap()
+	for typ, href := range guide {
+		file, fragment, hasFragment := strings.Cut(href, "#")
+		resolved := ResolveHref(opfPath, file)
+		if hasFragment {
+			resolved += "#" + fragment
+		}
+		guide[typ] = resolved
+	}
+	return guide, nil
+}
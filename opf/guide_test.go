@@ -0,0 +1,39 @@
+package opf
+
+import (
+	"reflect"
+	"testing"
+)
+
+const guideOPF = `<?xml version="1.0"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="2.0">
+<metadata/>
+<manifest/>
+<spine/>
+<guide>
+  <reference type="cover" title="Couverture" href="text/cover.xhtml"/>
+  <reference type="TOC" title="Table des matières" href="text/front.xhtml#toc"/>
+  <reference type="text" title="Début" href="text/ch1.xhtml"/>
+  <reference type="text" title="Second" href="text/ch2.xhtml"/>
+</guide>
+</package>`
+
+func TestParseGuideFromEPUB(t *testing.T) {
+	path := writeTestEPUB(t, map[string]string{
+		"META-INF/container.xml": `<container><rootfiles><rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/></rootfiles></container>`,
+		"OEBPS/content.opf":      guideOPF,
+	})
+
+	got, err := ParseGuideFromEPUB(path)
+	if err != nil {
+		t.Fatalf("ParseGuideFromEPUB failed: %v", err)
+	}
+	want := map[string]string{
+		"cover": "OEBPS/text/cover.xhtml",
+		"toc":   "OEBPS/text/front.xhtml#toc",
+		"text":  "OEBPS/text/ch1.xhtml",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,105 @@
+package opf
+
+import "strings"
+
+// identifierAliases maps known identifier scheme spellings to stable keys
+var identifierAliases = map[string]string{
+	"asin":         "asin",
+	"mobi-asin":    "asin",
+	"mobi_asin":    "asin",
+	"amazon":       "asin",
+	"google":       "google",
+	"googlebooks":  "google",
+	"google_books": "google",
+	"google-books": "google",
+	"goodreads":    "goodreads",
+	"goodreads_id": "goodreads",
+	"isbn":         "isbn",
+	"isbn10":       "isbn",
+	"isbn-10":      "isbn",
+	"isbn13":       "isbn",
+	"isbn-13":      "isbn",
+	"doi":          "doi",
+}
+
+// NormalizeIdentifierKey canonicalizes an identifier scheme name, so aliases
+// such as "mobi-asin", "amazon" and "amazon_uk" all become "asin". Unknown
+// schemes are returned lowercased but otherwise untouched.
+func NormalizeIdentifierKey(scheme string) string {
+	key := strings.ToLower(strings.TrimSpace(scheme))
+	if alias, ok := identifierAliases[key]; ok {
+		return alias
+	}
+
+	// Calibre keys regional Amazon stores as amazon_uk, amazon_de, ...
+	if strings.HasPrefix(key, "amazon_") {
+		return "asin"
+	}
+
+	return key
+}
+
+// NormalizeISBN validates an ISBN-10 or ISBN-13 and returns it as 13 digits.
+// Hyphens, spaces and a "urn:isbn:" or "ISBN" prefix are ignored. Reports
+// false if the value isn't an ISBN or its check digit is wrong.
+func NormalizeISBN(raw string) (string, bool) {
+	s := strings.ToUpper(strings.TrimSpace(raw))
+	s = strings.TrimPrefix(s, "URN:ISBN:")
+	s = strings.TrimPrefix(s, "ISBN")
+	s = strings.TrimLeft(s, ":- ")
+
+	digits := make([]byte, 0, 13)
+	for i := 0; i < len(s); i++ {
+		switch ch := s[i]; {
+		case ch >= '0' && ch <= '9':
+			digits = append(digits, ch)
+		case ch == 'X' && len(digits) == 9 && i == len(s)-1:
+			// X is only valid as an ISBN-10 check digit
+			digits = append(digits, ch)
+		case ch == '-' || ch == ' ':
+		default:
+			return "", false
+		}
+	}
+
+	switch len(digits) {
+	case 10:
+		if !validISBN10(digits) {
+			return "", false
+		}
+		isbn13 := append([]byte("978"), digits[:9]...)
+		return string(append(isbn13, isbn13CheckDigit(isbn13))), true
+	case 13:
+		if isbn13CheckDigit(digits[:12]) != digits[12] {
+			return "", false
+		}
+		return string(digits), true
+	}
+	return "", false
+}
+
+// validISBN10 checks an ISBN-10's mod 11 check digit
+func validISBN10(digits []byte) bool {
+	sum := 0
+	for i, d := range digits {
+		v := int(d - '0')
+		if d == 'X' {
+			v = 10
+		}
+		sum += (10 - i) * v
+	}
+	return sum%11 == 0
+}
+
+// isbn13CheckDigit computes the check digit for the first 12 digits of an ISBN-13
+func isbn13CheckDigit(digits []byte) byte {
+	sum := 0
+	for i, d := range digits[:12] {
+		weight := 1
+		if i%2 == 1 {
+			weight = 3
+		}
+		sum += weight * int(d-'0')
+	}
+	return byte('0' + (10-sum%10)%10)
+}
@@ -0,0 +1,104 @@
+package opf
+
+import "testing"
+
+func TestNormalizeIdentifierKey(t *testing.T) {
+	tests := map[string]string{
+		"mobi-asin":   "asin",
+		"AMAZON":      "asin",
+		"amazon_uk":   "asin",
+		"googlebooks": "google",
+		"google":      "google",
+		"Goodreads":   "goodreads",
+		"ISBN-13":     "isbn",
+		"doi":         "doi",
+		"uuid":        "uuid",
+		"calibre":     "calibre",
+	}
+	for in, want := range tests {
+		if got := NormalizeIdentifierKey(in); got != want {
+			t.Errorf("NormalizeIdentifierKey(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestParseNormalizesIdentifiers(t *testing.T) {
+	meta, err := ParseBytes([]byte(`<package xmlns="http://www.idpf.org/2007/opf" xmlns:opf="http://www.idpf.org/2007/opf" version="2.0">
+<metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+<dc:identifier opf:scheme="MOBI-ASIN">B000FC1PJI</dc:identifier>
+<dc:identifier opf:scheme="amazon_uk">B000UK0001</dc:identifier>
+<dc:identifier opf:scheme="googlebooks">abcDEF</dc:identifier>
+<dc:identifier opf:scheme="ISBN-13">9780000000002</dc:identifier>
+</metadata></package>`))
+	if err != nil {
+		t.Fatalf("ParseBytes failed: %v", err)
+	}
+
+	want := map[string]string{
+		"asin":      "B000FC1PJI",
+		"amazon_uk": "B000UK0001",
+		"google":    "abcDEF",
+		"isbn":      "9780000000002",
+	}
+	for key, value := range want {
+		if meta.Identifiers[key] != value {
+			t.Errorf("Identifiers[%q] = %q, want %q", key, meta.Identifiers[key], value)
+		}
+	}
+	if len(meta.Identifiers) != len(want) {
+		t.Errorf("Identifiers = %v", meta.Identifiers)
+	}
+	if meta.ISBN != "9780000000002" {
+		t.Errorf("ISBN = %q", meta.ISBN)
+	}
+}
+
+func TestNormalizeISBN(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+		ok   bool
+	}{
+		{"0-306-40615-2", "9780306406157", true},
+		{"978-0-306-40615-7", "9780306406157", true},
+		{"urn:isbn:9780306406157", "9780306406157", true},
+		{"ISBN 0 8044 2957 X", "9780804429573", true},
+		{"0-306-40615-3", "", false},
+		{"978-0-306-40615-8", "", false},
+		{"12345", "", false},
+		{"X306406152", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := NormalizeISBN(tt.raw)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("NormalizeISBN(%q) = %q, %v; want %q, %v", tt.raw, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestParseNormalizesISBN(t *testing.T) {
+	meta, err := ParseBytes([]byte(`<package xmlns="http://www.idpf.org/2007/opf" xmlns:opf="http://www.idpf.org/2007/opf" version="2.0">
+<metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+<dc:identifier opf:scheme="ISBN">0-306-40615-2</dc:identifier>
+</metadata></package>`))
+	if err != nil {
+		t.Fatalf("ParseBytes failed: %v", err)
+	}
+	if meta.ISBN != "9780306406157" || meta.Identifiers["isbn"] != "9780306406157" {
+		t.Errorf("ISBN = %q, Identifiers = %v", meta.ISBN, meta.Identifiers)
+	}
+
+	meta, err = ParseBytes([]byte(`<package xmlns="http://www.idpf.org/2007/opf" xmlns:opf="http://www.idpf.org/2007/opf" version="2.0">
+<metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+<dc:identifier opf:scheme="ISBN">978-0-306-40615-8</dc:identifier>
+</metadata></package>`))
+	if err != nil {
+		t.Fatalf("ParseBytes failed: %v", err)
+	}
+	if meta.ISBN != "" || meta.Identifiers["isbn"] != "" {
+		t.Errorf("invalid ISBN kept: ISBN = %q, Identifiers = %v", meta.ISBN, meta.Identifiers)
+	}
+	if meta.Identifiers["isbn_raw"] != "978-0-306-40615-8" {
+		t.Errorf("isbn_raw = %q", meta.Identifiers["isbn_raw"])
+	}
+}
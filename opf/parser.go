@@ -6,7 +6,9 @@ import (
 	"encoding/xml"
 	"fmt"
 	"io"
+	"math"
 	"os"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
@@ -16,6 +18,31 @@ import (
 type Package struct {
 	XMLName  xml.Name `xml:"package"`
 	Metadata Metadata `xml:"metadata"`
+	Manifest Manifest `xml:"manifest"`
+
+	UniqueIdentifier string      `xml:"unique-identifier,attr"`
+	Spine            []SpineItem `xml:"spine>itemref"`
+	Guide            []Reference `xml:"guide>reference"`
+}
+
+// Reference represents an EPUB 2 guide reference element
+type Reference struct {
+	Type  string `xml:"type,attr"`
+	Title string `xml:"title,attr"`
+	Href  string `xml:"href,attr"`
+}
+
+// Manifest lists every resource in the publication
+type Manifest struct {
+	Items []ManifestItem `xml:"item"`
+}
+
+// ManifestItem represents a manifest item element
+type ManifestItem struct {
+	ID         string `xml:"id,attr"`
+	Href       string `xml:"href,attr"`
+	MediaType  string `xml:"media-type,attr"`
+	Properties string `xml:"properties,attr"`
 }
 
 // Metadata contains Dublin Core metadata elements
@@ -29,10 +56,13 @@ type Metadata struct {
 	Description string      `xml:"description"`
 	Identifiers []Identifier `xml:"identifier"`
 	Meta        []Meta      `xml:"meta"`
+
+	Contributors []Creator `xml:"contributor"`
 }
 
 // Creator represents a dc:creator element (author)
 type Creator struct {
+	ID     string `xml:"id,attr"`
 	Name   string `xml:",chardata"`
 	Role   string `xml:"role,attr"`
 	FileAs string `xml:"file-as,attr"`
@@ -45,10 +75,23 @@ type Identifier struct {
 	Value  string `xml:",chardata"`
 }
 
-// Meta represents a calibre or opf meta element
+// Meta represents a meta element. OPF 2 meta (including Calibre's) use the
+// name and content attributes; EPUB 3 meta name a property and hold their
+// value as text, optionally refining another element by id.
 type Meta struct {
 	Name    string `xml:"name,attr"`
 	Content string `xml:"content,attr"`
+
+	ID       string `xml:"id,attr"`
+	Property string `xml:"property,attr"`
+	Refines  string `xml:"refines,attr"`
+	Value    string `xml:",chardata"`
+}
+
+// SpineItem represents a spine itemref element
+type SpineItem struct {
+	IDRef  string `xml:"idref,attr"`
+	Linear string `xml:"linear,attr"`
 }
 
 // ParsedMetadata is the clean Go struct with parsed metadata
@@ -65,6 +108,15 @@ type ParsedMetadata struct {
 	Identifiers   map[string]string
 	Series        string
 	SeriesIndex   float64
+
+	// Rating is on a 1-5 scale (0 when unrated); Calibre stores 0-10
+	Rating int
+
+	// Comments is Calibre's comments meta, or the description if absent
+	Comments string
+
+	// BookProducer is the tool or person that produced the ebook file
+	BookProducer string
 }
 
 // ParseFile parses an OPF file from disk
@@ -80,13 +132,101 @@ func ParseFile(path string) (*ParsedMetadata, error) {
 
 // Parse parses OPF XML from a reader
 func Parse(r io.Reader) (*ParsedMetadata, error) {
+	pkg, err := ParsePackage(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseMetadata(&pkg.Metadata), nil
+}
+
+// ParsePackage parses the raw OPF package document, including the manifest
+func ParsePackage(r io.Reader) (*Package, error) {
 	var pkg Package
 	decoder := xml.NewDecoder(r)
 	if err := decoder.Decode(&pkg); err != nil {
 		return nil, fmt.Errorf("failed to parse OPF XML: %w", err)
 	}
 
-	return parseMetadata(&pkg.Metadata), nil
+	return &pkg, nil
+}
+
+// ItemByID returns the manifest item with the given id, or nil
+func (p *Package) ItemByID(id string) *ManifestItem {
+	for i := range p.Manifest.Items {
+		if p.Manifest.Items[i].ID == id {
+			return &p.Manifest.Items[i]
+		}
+	}
+	return nil
+}
+
+// UniqueIdentifierValue returns the value of the dc:identifier the package's
+// unique-identifier attribute points to, or "" if there is none
+func (p *Package) UniqueIdentifierValue() string {
+	for _, id := range p.Metadata.Identifiers {
+		if id.ID != "" && id.ID == p.UniqueIdentifier {
+			return strings.TrimSpace(id.Value)
+		}
+	}
+	return ""
+}
+
+// CoverItem returns the manifest item for the cover image, or nil if none is
+// declared. EPUB 3 marks it with properties="cover-image"; EPUB 2 uses
+// <meta name="cover" content="item-id"/>.
+func (p *Package) CoverItem() *ManifestItem {
+	for i, item := range p.Manifest.Items {
+		for _, prop := range strings.Fields(item.Properties) {
+			if prop == "cover-image" {
+				return &p.Manifest.Items[i]
+			}
+		}
+	}
+
+	for _, meta := range p.Metadata.Meta {
+		if meta.Name == "cover" {
+			if item := p.ItemByID(meta.Content); item != nil {
+				return item
+			}
+		}
+	}
+
+	return nil
+}
+
+// Refinement returns the value of the EPUB 3 meta with the given property
+// that refines the element with the given id, or "" if there is none
+func (m *Metadata) Refinement(id, property string) string {
+	if id == "" {
+		return ""
+	}
+	for _, meta := range m.Meta {
+		if meta.Property == property && strings.TrimPrefix(meta.Refines, "#") == id {
+			return strings.TrimSpace(meta.Value)
+		}
+	}
+	return ""
+}
+
+// collection returns the EPUB 3 collection a book belongs to and its position
+// in it, preferring one whose collection-type is "series"
+func (m *Metadata) collection() (name, position string) {
+	found := false
+	for _, meta := range m.Meta {
+		if meta.Property != "belongs-to-collection" {
+			continue
+		}
+		isSeries := m.Refinement(meta.ID, "collection-type") == "series"
+		if !found || isSeries {
+			name, position = strings.TrimSpace(meta.Value), m.Refinement(meta.ID, "group-position")
+			found = true
+		}
+		if isSeries {
+			break
+		}
+	}
+	return name, position
 }
 
 // ParseBytes parses OPF XML from bytes
@@ -105,13 +245,36 @@ func parseMetadata(m *Metadata) *ParsedMetadata {
 		Identifiers: make(map[string]string),
 	}
 
-	// Parse authors
+	// Parse authors, whose role and sort name EPUB 3 gives in refining meta
 	for _, creator := range m.Creators {
-		if creator.Role == "" || creator.Role == "aut" {
-			result.Authors = append(result.Authors, creator.Name)
-			if result.AuthorSort == "" && creator.FileAs != "" {
-				result.AuthorSort = creator.FileAs
+		role, fileAs := creator.Role, creator.FileAs
+		if role == "" {
+			role = m.Refinement(creator.ID, "role")
+		}
+		if fileAs == "" {
+			fileAs = m.Refinement(creator.ID, "file-as")
+		}
+		if role != "" && role != "aut" {
+			continue
+		}
+		if result.AuthorSort == "" && fileAs != "" {
+			result.AuthorSort = fileAs
+		}
+
+		// Several authors may share one creator as "A & B", and without a
+		// file-as their names may be in "Last, First" form
+		for _, name := range splitAuthors(creator.Name) {
+			if fileAs == "" {
+				name = NormalizeAuthor(name)
 			}
+			result.Authors = append(result.Authors, name)
+		}
+	}
+
+	// Book producer is a contributor with the bkp role
+	for _, contributor := range m.Contributors {
+		if contributor.Role == "bkp" && result.BookProducer == "" {
+			result.BookProducer = strings.TrimSpace(contributor.Name)
 		}
 	}
 
@@ -121,11 +284,28 @@ func parseMetadata(m *Metadata) *ParsedMetadata {
 		if scheme == "" {
 			scheme = strings.ToLower(id.ID)
 		}
-		result.Identifiers[scheme] = id.Value
+
+		// Canonicalize aliases, but keep a conflicting value under its
+		// original scheme rather than overwrite the first one
+		key := NormalizeIdentifierKey(scheme)
+		value := id.Value
+		if key == "isbn" {
+			// Store ISBNs as ISBN-13, setting aside values that aren't valid
+			isbn, ok := NormalizeISBN(value)
+			if !ok {
+				result.Identifiers["isbn_raw"] = value
+				continue
+			}
+			value = isbn
+		}
+		if existing, ok := result.Identifiers[key]; ok && existing != value && key != scheme {
+			key = scheme
+		}
+		result.Identifiers[key] = value
 
 		// Extract ISBN specifically
-		if scheme == "isbn" {
-			result.ISBN = id.Value
+		if key == "isbn" {
+			result.ISBN = value
 		}
 	}
 
@@ -152,13 +332,64 @@ func parseMetadata(m *Metadata) *ParsedMetadata {
 		case "calibre:series":
 			result.Series = meta.Content
 		case "calibre:series_index":
-			if idx, err := strconv.ParseFloat(meta.Content, 64); err == nil {
+			if idx, ok := parseSeriesIndex(meta.Content); ok {
 				result.SeriesIndex = idx
 			}
+		case "calibre:rating":
+			if rating, err := strconv.ParseFloat(meta.Content, 64); err == nil {
+				result.Rating = convertRating(rating)
+			}
+		case "calibre:comments":
+			result.Comments = meta.Content
+		case "calibre:book_producer":
+			result.BookProducer = meta.Content
 		case "calibre:author_link_map":
 			// Could parse author links if needed
 		}
 	}
 
+	// Fall back to an EPUB 3 belongs-to-collection series
+	if result.Series == "" {
+		name, position := m.collection()
+		result.Series = name
+		if idx, ok := parseSeriesIndex(position); ok && name != "" {
+			result.SeriesIndex = idx
+		}
+	}
+
+	if result.Comments == "" {
+		result.Comments = result.Description
+	}
+
 	return result
 }
+
+// seriesIndexRe matches the first number in a series index, with a point or
+// comma decimal separator
+var seriesIndexRe = regexp.MustCompile(`\d+(?:[.,]\d+)?`)
+
+// parseSeriesIndex reads a series index leniently: "1,5" is 1.5 and a range
+// such as "2-3" gives its first number. It reports false if there's no number.
+func parseSeriesIndex(s string) (float64, bool) {
+	m := seriesIndexRe.FindString(s)
+	if m == "" {
+		return 0, false
+	}
+	idx, err := strconv.ParseFloat(strings.Replace(m, ",", ".", 1), 64)
+	if err != nil {
+		return 0, false
+	}
+	return idx, true
+}
+
+// convertRating maps Calibre's 0-10 rating to a 1-5 scale, keeping 0 as unrated
+func convertRating(rating float64) int {
+	stars := int(math.Round(rating / 2))
+	if stars < 0 {
+		return 0
+	}
+	if stars > 5 {
+		return 5
+	}
+	return stars
+}
@@ -0,0 +1,155 @@
+package opf
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// writeTestEPUB writes an EPUB-like zip from a map of entry names to
+// contents and returns its path
+func writeTestEPUB(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "book.epub")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	zw := zip.NewWriter(f)
+	for _, name := range names {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(files[name])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+const calibreOPF = `<?xml version="1.0" encoding="utf-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="2.0" unique-identifier="uuid_id">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:opf="http://www.idpf.org/2007/opf">
+    <dc:title>Rated Book</dc:title>
+    <dc:creator opf:role="aut" opf:file-as="Writer, Ann">Ann Writer</dc:creator>
+    <dc:contributor opf:role="bkp">calibre (8.16.2) [https://calibre-ebook.com]</dc:contributor>
+    <dc:description>&lt;p&gt;A blurb.&lt;/p&gt;</dc:description>
+    <meta name="calibre:rating" content="8.0"/>
+  </metadata>
+</package>`
+
+func TestParseRatingCommentsProducer(t *testing.T) {
+	meta, err := ParseBytes([]byte(calibreOPF))
+	if err != nil {
+		t.Fatalf("ParseBytes failed: %v", err)
+	}
+
+	if meta.Rating != 4 {
+		t.Errorf("Rating = %d, want 4", meta.Rating)
+	}
+	if meta.Comments != "<p>A blurb.</p>" {
+		t.Errorf("Comments = %q", meta.Comments)
+	}
+	if meta.BookProducer != "calibre (8.16.2) [https://calibre-ebook.com]" {
+		t.Errorf("BookProducer = %q", meta.BookProducer)
+	}
+}
+
+func TestConvertRating(t *testing.T) {
+	tests := map[float64]int{0: 0, 1: 1, 2: 1, 5: 3, 10: 5, 12: 5, -2: 0}
+	for in, want := range tests {
+		if got := convertRating(in); got != want {
+			t.Errorf("convertRating(%v) = %d, want %d", in, got, want)
+		}
+	}
+}
+
+func TestParseSeriesIndex(t *testing.T) {
+	tests := []struct {
+		content string
+		want    float64
+	}{
+		{"1.5", 1.5},
+		{"1,5", 1.5},
+		{"2-3", 2},
+		{" 4 ", 4},
+		{"garbage", 0},
+		{"", 0},
+	}
+	for _, tt := range tests {
+		opf := `<package xmlns="http://www.idpf.org/2007/opf"><metadata>
+<meta name="calibre:series" content="Saga"/>
+<meta name="calibre:series_index" content="` + tt.content + `"/>
+</metadata></package>`
+		meta, err := ParseBytes([]byte(opf))
+		if err != nil {
+			t.Fatalf("ParseBytes failed: %v", err)
+		}
+		if meta.SeriesIndex != tt.want {
+			t.Errorf("series_index %q: SeriesIndex = %v, want %v", tt.content, meta.SeriesIndex, tt.want)
+		}
+	}
+}
+
+const epub3OPF = `<?xml version="1.0" encoding="utf-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="uid">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:title>The Two Towers</dc:title>
+    <dc:creator id="creator01">J. R. R. Tolkien</dc:creator>
+    <meta refines="#creator01" property="role" scheme="marc:relators">aut</meta>
+    <meta refines="#creator01" property="file-as">Tolkien, J. R. R.</meta>
+    <dc:creator id="creator02">Alan Lee</dc:creator>
+    <meta refines="#creator02" property="role" scheme="marc:relators">ill</meta>
+    <meta property="belongs-to-collection" id="set">Middle-earth Box Set</meta>
+    <meta refines="#set" property="collection-type">set</meta>
+    <meta property="belongs-to-collection" id="c01">The Lord of the Rings</meta>
+    <meta refines="#c01" property="collection-type">series</meta>
+    <meta refines="#c01" property="group-position">2</meta>
+    <meta property="dcterms:modified">2024-01-01T00:00:00Z</meta>
+  </metadata>
+</package>`
+
+func TestParseEPUB3Refines(t *testing.T) {
+	meta, err := ParseBytes([]byte(epub3OPF))
+	if err != nil {
+		t.Fatalf("ParseBytes failed: %v", err)
+	}
+
+	if meta.Series != "The Lord of the Rings" || meta.SeriesIndex != 2 {
+		t.Errorf("Series = %q #%v, want The Lord of the Rings #2", meta.Series, meta.SeriesIndex)
+	}
+	if len(meta.Authors) != 1 || meta.Authors[0] != "J. R. R. Tolkien" {
+		t.Errorf("Authors = %q, want only the refined aut creator", meta.Authors)
+	}
+	if meta.AuthorSort != "Tolkien, J. R. R." {
+		t.Errorf("AuthorSort = %q", meta.AuthorSort)
+	}
+
+	// calibre:series wins over a collection
+	withCalibre := strings.Replace(epub3OPF, "</metadata>",
+		`<meta name="calibre:series" content="Calibre Series"/></metadata>`, 1)
+	meta, err = ParseBytes([]byte(withCalibre))
+	if err != nil {
+		t.Fatalf("ParseBytes failed: %v", err)
+	}
+	if meta.Series != "Calibre Series" || meta.SeriesIndex != 0 {
+		t.Errorf("Series = %q #%v, want Calibre Series without an index", meta.Series, meta.SeriesIndex)
+	}
+}
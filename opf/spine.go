@@ -0,0 +1,61 @@
+package opf
+
+// ParsedSpine is the spine with each itemref resolved through the manifest
+type ParsedSpine struct {
+	Items []SpineEntry
+}
+
+// SpineEntry is a spine itemref joined with its manifest item
+type SpineEntry struct {
+	IDRef     string
+	Href      string
+	MediaType string
+
+	// Linear is false for itemrefs marked linear="no", such as pop-up notes
+	// that sit outside the main reading flow
+	Linear bool
+}
+
+// ParsedSpine resolves the package's spine itemrefs against its manifest.
+// Itemrefs that don't match a manifest item are skipped.
+func (p *Package) ParsedSpine() *ParsedSpine {
+	spine := &ParsedSpine{}
+	for _, ref := range p.Spine {
+		item := p.ItemByID(ref.IDRef)
+		if item == nil {
+			continue
+		}
+		spine.Items = append(spine.Items, SpineEntry{
+			IDRef:     ref.IDRef,
+			Href:      item.Href,
+			MediaType: item.MediaType,
+			Linear:    ref.Linear != "no",
+		})
+	}
+	return spine
+}
+
+// ReadingOrder returns the manifest hrefs of the spine in reading order,
+// including non-linear items
+func (s *ParsedSpine) ReadingOrder() []string {
+	var hrefs []string
+	for _, entry := range s.Items {
+		hrefs = append(hrefs, entry.Href)
+	}
+	return hrefs
+}
+
+// ParseSpineFromEPUB returns an EPUB's content files in spine order, as
+// paths inside the zip (manifest hrefs resolved against the OPF's directory)
+func ParseSpineFromEPUB(epubPath string) ([]string, error) {
+	pkg, opfPath, err := ReadPackageFromEPUB(epubPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for _, href := range pkg.ParsedSpine().ReadingOrder() {
+		paths = append(paths, ResolveHref(opfPath, href))
+	}
+	return paths, nil
+}
@@ -0,0 +1,47 @@
+package opf
+
+import (
+	"reflect"
+	"testing"
+)
+
+const spineOPF = `<?xml version="1.0"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="2.0">
+<metadata/>
+<manifest>
+  <item id="ch2" href="text/ch2.xhtml" media-type="application/xhtml+xml"/>
+  <item id="ch1" href="text/ch1.xhtml" media-type="application/xhtml+xml"/>
+  <item id="notes" href="text/notes.xhtml" media-type="application/xhtml+xml"/>
+</manifest>
+<spine>
+  <itemref idref="ch1"/>
+  <itemref idref="notes" linear="no"/>
+  <itemref idref="missing"/>
+  <itemref idref="ch2"/>
+</spine>
+</package>`
+
+func TestParseSpineFromEPUB(t *testing.T) {
+	path := writeTestEPUB(t, map[string]string{
+		"META-INF/container.xml": `<container><rootfiles><rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/></rootfiles></container>`,
+		"OEBPS/content.opf":      spineOPF,
+	})
+
+	got, err := ParseSpineFromEPUB(path)
+	if err != nil {
+		t.Fatalf("ParseSpineFromEPUB failed: %v", err)
+	}
+	want := []string{"OEBPS/text/ch1.xhtml", "OEBPS/text/notes.xhtml", "OEBPS/text/ch2.xhtml"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	pkg, _, err := ReadPackageFromEPUB(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spine := pkg.ParsedSpine()
+	if len(spine.Items) != 3 || spine.Items[1].Linear || !spine.Items[0].Linear {
+		t.Errorf("unexpected spine entries: %+v", spine.Items)
+	}
+}
@@ -0,0 +1,144 @@
+package opf
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Marshal serializes metadata as an OPF 2.0 package document
+func Marshal(meta *ParsedMetadata) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := Write(&buf, meta); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Write writes metadata as an OPF 2.0 package document containing a
+// <metadata> block with Dublin Core elements and Calibre series meta tags.
+// The output can be read back with Parse.
+func Write(w io.Writer, meta *ParsedMetadata) error {
+	if meta == nil {
+		return fmt.Errorf("no metadata to write")
+	}
+
+	var b strings.Builder
+	b.WriteString(xml.Header)
+
+	identifiers := sortedIdentifiers(meta)
+	if len(identifiers) > 0 {
+		b.WriteString(`<package xmlns="http://www.idpf.org/2007/opf" version="2.0" unique-identifier="bookid">` + "\n")
+	} else {
+		b.WriteString(`<package xmlns="http://www.idpf.org/2007/opf" version="2.0">` + "\n")
+	}
+	b.WriteString(`  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:opf="http://www.idpf.org/2007/opf">` + "\n")
+
+	writeElement(&b, "dc:title", "", meta.Title)
+	for i, author := range meta.Authors {
+		attrs := ` opf:role="aut"`
+		if i == 0 && meta.AuthorSort != "" {
+			attrs += ` opf:file-as="` + escape(meta.AuthorSort) + `"`
+		}
+		writeElement(&b, "dc:creator", attrs, author)
+	}
+	writeElement(&b, "dc:contributor", ` opf:role="bkp"`, meta.BookProducer)
+	writeElement(&b, "dc:language", "", meta.Language)
+	writeElement(&b, "dc:publisher", "", meta.Publisher)
+	if !meta.PublishDate.IsZero() {
+		writeElement(&b, "dc:date", "", meta.PublishDate.Format(time.RFC3339))
+	}
+	for _, tag := range meta.Tags {
+		writeElement(&b, "dc:subject", "", tag)
+	}
+	writeElement(&b, "dc:description", "", meta.Description)
+	for i, id := range identifiers {
+		attrs := ` opf:scheme="` + escape(id[0]) + `"`
+		if i == 0 {
+			attrs = ` id="bookid"` + attrs
+		}
+		writeElement(&b, "dc:identifier", attrs, id[1])
+	}
+
+	if meta.Rating > 0 {
+		writeMeta(&b, "calibre:rating", strconv.Itoa(meta.Rating*2))
+	}
+	if meta.Comments != "" && meta.Comments != meta.Description {
+		writeMeta(&b, "calibre:comments", meta.Comments)
+	}
+	if meta.Series != "" {
+		writeMeta(&b, "calibre:series", meta.Series)
+		writeMeta(&b, "calibre:series_index", strconv.FormatFloat(meta.SeriesIndex, 'f', -1, 64))
+	}
+
+	b.WriteString("  </metadata>\n</package>\n")
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("failed to write OPF: %w", err)
+	}
+	return nil
+}
+
+// sortedIdentifiers returns scheme/value pairs, ISBN first and the rest sorted
+// by scheme
+func sortedIdentifiers(meta *ParsedMetadata) [][2]string {
+	var ids [][2]string
+	isbn := meta.ISBN
+	if isbn == "" {
+		isbn = meta.Identifiers["isbn"]
+	}
+	if isbn != "" {
+		ids = append(ids, [2]string{"ISBN", isbn})
+	}
+
+	var schemes []string
+	for scheme := range meta.Identifiers {
+		if strings.ToLower(scheme) != "isbn" && meta.Identifiers[scheme] != "" {
+			schemes = append(schemes, scheme)
+		}
+	}
+	sort.Strings(schemes)
+	for _, scheme := range schemes {
+		ids = append(ids, [2]string{scheme, meta.Identifiers[scheme]})
+	}
+
+	return ids
+}
+
+// writeElement writes a single element, skipping empty values
+func writeElement(b *strings.Builder, name, attrs, value string) {
+	if value == "" {
+		return
+	}
+	fmt.Fprintf(b, "    <%s%s>%s</%s>\n", name, attrs, escape(value), name)
+}
+
+// writeMeta writes a <meta name="..." content="..."/> element
+func writeMeta(b *strings.Builder, name, content string) {
+	fmt.Fprintf(b, "    <meta name=\"%s\" content=\"%s\"/>\n", escape(name), escape(content))
+}
+
+// escape escapes text for use in element content or attribute values
+func escape(s string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
+
+// calibreFingerprint matches the elements Calibre adds to an OPF when it
+// touches a book: calibre:* meta tags and the book producer contributor
+var calibreFingerprint = regexp.MustCompile(`(?s)\s*<meta\s[^>]*(?:name|property)\s*=\s*["']calibre:[^>]*?(?:/>|>.*?</meta>)` +
+	`|\s*<dc:contributor\s[^>]*role\s*=\s*["']bkp["'][^>]*>.*?</dc:contributor>`)
+
+// StripCalibreMeta removes the calibre:* meta elements and the book producer
+// contributor Calibre writes into an OPF, leaving the rest of the document
+// untouched
+func StripCalibreMeta(data []byte) []byte {
+	return calibreFingerprint.ReplaceAll(data, nil)
+}
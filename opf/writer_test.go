@@ -0,0 +1,68 @@
+package opf
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMarshalRoundTrip(t *testing.T) {
+	meta := &ParsedMetadata{
+		Title:        "Good Omens & Other Tales",
+		Authors:      []string{"Terry Pratchett", "Neil Gaiman"},
+		AuthorSort:   "Pratchett, Terry",
+		Publisher:    "Gollancz",
+		PublishDate:  time.Date(1990, 5, 1, 0, 0, 0, 0, time.UTC),
+		Language:     "en",
+		Tags:         []string{"Fantasy", "Humour"},
+		Description:  "<p>The world ends on Saturday.</p>",
+		Comments:     "Signed first edition",
+		Rating:       4,
+		BookProducer: "calibre (7.0.0)",
+		ISBN:         "9780575048003",
+		Identifiers:  map[string]string{"isbn": "9780575048003", "uuid": "abc-123"},
+		Series:       "Standalone",
+		SeriesIndex:  2.5,
+	}
+
+	data, err := Marshal(meta)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if !strings.Contains(string(data), `opf:role="aut"`) {
+		t.Errorf("creators should carry opf:role, got:\n%s", data)
+	}
+
+	parsed, err := ParseBytes(data)
+	if err != nil {
+		t.Fatalf("ParseBytes failed: %v\n%s", err, data)
+	}
+	if !reflect.DeepEqual(parsed, meta) {
+		t.Errorf("round trip mismatch:\n got %+v\nwant %+v", parsed, meta)
+	}
+}
+
+func TestWriteNil(t *testing.T) {
+	if _, err := Marshal(nil); err == nil {
+		t.Error("expected an error for nil metadata")
+	}
+}
+
+func TestStripCalibreMeta(t *testing.T) {
+	in := `<metadata>
+    <dc:title>Kept</dc:title>
+    <dc:contributor opf:role="bkp">calibre (8.16.2)</dc:contributor>
+    <meta name="calibre:timestamp" content="2024-01-01"/>
+    <meta property="calibre:title_sort">Kept</meta>
+    <meta name="cover" content="cover-img"/>
+  </metadata>`
+	want := `<metadata>
+    <dc:title>Kept</dc:title>
+    <meta name="cover" content="cover-img"/>
+  </metadata>`
+
+	if got := string(StripCalibreMeta([]byte(in))); got != want {
+		t.Errorf("StripCalibreMeta =\n%s\nwant\n%s", got, want)
+	}
+}
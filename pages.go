@@ -0,0 +1,82 @@
+package calibre
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ExtractPageRange returns the plain text of pages firstPage through
+// lastPage (1-based, inclusive) of a PDF. Page ranges only make sense for
+// paged formats, so reflowable books such as EPUB or MOBI return an error.
+//
+// ebook-convert has no page selection of its own, so the whole PDF is
+// converted to text and split at the form feeds marking its page breaks.
+// Whether those survive depends on the Calibre version and the PDF, so text
+// without any returns ErrNoPageBreaks rather than guessing; this includes
+// single-page PDFs. A lastPage past the end is clamped to the last page.
+func (c *Calibre) ExtractPageRange(ctx context.Context, pdfPath string, firstPage, lastPage int) (string, error) {
+	if firstPage < 1 || lastPage < 1 {
+		return "", fmt.Errorf("invalid page range %d-%d: pages start at 1", firstPage, lastPage)
+	}
+	if firstPage > lastPage {
+		return "", fmt.Errorf("invalid page range %d-%d: first page is after last page", firstPage, lastPage)
+	}
+
+	format, err := DetectFormat(pdfPath)
+	if err != nil {
+		return "", err
+	}
+	if format != "pdf" {
+		return "", fmt.Errorf("page ranges only apply to PDF input, not %s", format)
+	}
+
+	if c.ebookConvert == "" {
+		return "", toolNotFound("ebook-convert")
+	}
+
+	tmpDir, err := c.mkdirTemp("calibre-pages-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// No chapter marks, so the only form feeds left are page breaks
+	txtPath := filepath.Join(tmpDir, "book.txt")
+	start := time.Now()
+	_, err = c.runCommand(ctx, c.ebookConvert, pdfPath, txtPath,
+		"--chapter-mark", "none",
+		"--txt-output-formatting", "plain",
+	)
+	c.metrics().ObserveConversion(time.Since(start))
+	if err != nil {
+		return "", fmt.Errorf("ebook-convert to txt failed: %w", err)
+	}
+
+	data, err := os.ReadFile(txtPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read text output: %w", err)
+	}
+
+	text := strings.TrimRight(string(data), "\f\n")
+	if !strings.Contains(text, "\f") {
+		return "", fmt.Errorf("%w: can't select pages of %s", ErrNoPageBreaks, filepath.Base(pdfPath))
+	}
+
+	pages := strings.Split(text, "\f")
+	if firstPage > len(pages) {
+		return "", fmt.Errorf("page %d is past the end of the book (%d pages)", firstPage, len(pages))
+	}
+	if lastPage > len(pages) {
+		lastPage = len(pages)
+	}
+
+	selected := make([]string, 0, lastPage-firstPage+1)
+	for _, page := range pages[firstPage-1 : lastPage] {
+		selected = append(selected, strings.TrimSpace(page))
+	}
+	return strings.Join(selected, "\n\n"), nil
+}
@@ -0,0 +1,75 @@
+package calibre
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExtractPageRange(t *testing.T) {
+	pdf := filepath.Join(t.TempDir(), "book.pdf")
+	if err := os.WriteFile(pdf, []byte("%PDF-1.7\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := &Calibre{
+		Timeout:      DefaultTimeout,
+		ebookConvert: "ebook-convert",
+		Runner: func(ctx context.Context, name string, args ...string) ([]byte, error) {
+			text := "Page one\n\fPage two\n\fPage three\n\fPage four\n\f"
+			return nil, os.WriteFile(args[1], []byte(text), 0644)
+		},
+	}
+
+	got, err := c.ExtractPageRange(context.Background(), pdf, 2, 3)
+	if err != nil {
+		t.Fatalf("ExtractPageRange failed: %v", err)
+	}
+	if got != "Page two\n\nPage three" {
+		t.Errorf("pages 2-3 = %q", got)
+	}
+
+	// The last page is clamped
+	if got, _ := c.ExtractPageRange(context.Background(), pdf, 4, 10); got != "Page four" {
+		t.Errorf("pages 4-10 = %q", got)
+	}
+
+	for _, r := range [][2]int{{0, 2}, {3, 2}, {5, 6}} {
+		if _, err := c.ExtractPageRange(context.Background(), pdf, r[0], r[1]); err == nil {
+			t.Errorf("pages %d-%d: expected an error", r[0], r[1])
+		}
+	}
+}
+
+func TestExtractPageRangeNoPageBreaks(t *testing.T) {
+	pdf := filepath.Join(t.TempDir(), "book.pdf")
+	if err := os.WriteFile(pdf, []byte("%PDF-1.7\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := &Calibre{
+		Timeout:      DefaultTimeout,
+		ebookConvert: "ebook-convert",
+		Runner: func(ctx context.Context, name string, args ...string) ([]byte, error) {
+			return nil, os.WriteFile(args[1], []byte("Page one\nPage two\n"), 0644)
+		},
+	}
+
+	_, err := c.ExtractPageRange(context.Background(), pdf, 1, 1)
+	if !errors.Is(err, ErrNoPageBreaks) {
+		t.Errorf("expected ErrNoPageBreaks, got %v", err)
+	}
+}
+
+func TestExtractPageRangeReflowable(t *testing.T) {
+	epub := writeTestEPUB(t, map[string]string{"content.opf": "<package/>"})
+	c := &Calibre{Timeout: DefaultTimeout, ebookConvert: "ebook-convert"}
+
+	_, err := c.ExtractPageRange(context.Background(), epub, 1, 2)
+	if err == nil || !strings.Contains(err.Error(), "epub") {
+		t.Errorf("expected an error naming the EPUB format, got %v", err)
+	}
+}
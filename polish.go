@@ -0,0 +1,123 @@
+package calibre
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// PolishOptions selects the ebook-polish actions to run
+type PolishOptions struct {
+	// SubsetFonts removes unused glyphs from embedded fonts
+	SubsetFonts bool
+
+	// EmbedFonts embeds all fonts referenced in the CSS
+	EmbedFonts bool
+
+	// SmartenPunctuation converts plain quotes, dashes and ellipses
+	SmartenPunctuation bool
+
+	// RemoveUnusedCSS removes CSS rules that match nothing
+	RemoveUnusedCSS bool
+
+	// UpgradeBook upgrades EPUB 2 books to EPUB 3
+	UpgradeBook bool
+
+	// OutputPath, when set, receives the polished book and the original is
+	// left untouched. Otherwise, or when it names the book itself, the book
+	// is polished in place.
+	OutputPath string
+}
+
+// Polish runs ebook-polish on an EPUB or AZW3 file. Polishing large books can
+// be slow; the command is bounded by ctx and c.Timeout like every other command.
+func (c *Calibre) Polish(ctx context.Context, path string, opts PolishOptions) error {
+	if c.ebookPolish == "" {
+		return toolNotFound("ebook-polish")
+	}
+
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext != ".epub" && ext != ".azw3" {
+		return fmt.Errorf("ebook-polish only supports EPUB and AZW3, got %q", ext)
+	}
+
+	var args []string
+	if opts.SubsetFonts {
+		args = append(args, "--subset-fonts")
+	}
+	if opts.EmbedFonts {
+		args = append(args, "--embed-fonts")
+	}
+	if opts.SmartenPunctuation {
+		args = append(args, "--smarten-punctuation")
+	}
+	if opts.RemoveUnusedCSS {
+		args = append(args, "--remove-unused-css")
+	}
+	if opts.UpgradeBook {
+		args = append(args, "--upgrade-book")
+	}
+	if len(args) == 0 {
+		return fmt.Errorf("no polish actions selected")
+	}
+
+	// Polish a copy when the original must be preserved. An OutputPath that
+	// is the book itself just polishes it in place; copying would truncate it.
+	target := path
+	if opts.OutputPath != "" && !sameFile(path, opts.OutputPath) {
+		if err := copyFile(path, opts.OutputPath); err != nil {
+			return fmt.Errorf("failed to copy book for polishing: %w", err)
+		}
+		target = opts.OutputPath
+	}
+
+	// Passing the same file as input and output polishes it in place
+	args = append(args, target, target)
+
+	_, err := c.runCommand(ctx, c.ebookPolish, args...)
+	if err != nil {
+		return fmt.Errorf("ebook-polish failed: %w", err)
+	}
+
+	return nil
+}
+
+// copyFile copies src to dst, creating dst's directory if needed
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// sameFile reports whether a and b name the same existing file
+func sameFile(a, b string) bool {
+	ai, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	bi, err := os.Stat(b)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(ai, bi)
+}
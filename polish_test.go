@@ -0,0 +1,95 @@
+package calibre
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestPolishOutputPathPreservesOriginal(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "book.epub")
+	if err := os.WriteFile(src, []byte("original"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "polished", "book.epub")
+
+	var got []string
+	c := &Calibre{
+		Timeout:     DefaultTimeout,
+		ebookPolish: "ebook-polish",
+		Runner: func(ctx context.Context, name string, args ...string) ([]byte, error) {
+			got = args
+			return nil, os.WriteFile(args[len(args)-1], []byte("polished"), 0644)
+		},
+	}
+
+	err := c.Polish(context.Background(), src, PolishOptions{SubsetFonts: true, SmartenPunctuation: true, OutputPath: out})
+	if err != nil {
+		t.Fatalf("Polish failed: %v", err)
+	}
+
+	want := []string{"--subset-fonts", "--smarten-punctuation", out, out}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("args = %q, want %q", got, want)
+	}
+
+	if data, _ := os.ReadFile(src); string(data) != "original" {
+		t.Errorf("original was modified: %q", data)
+	}
+	if data, _ := os.ReadFile(out); string(data) != "polished" {
+		t.Errorf("output = %q", data)
+	}
+}
+
+func TestPolishOutputPathSameFile(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "book.epub")
+	if err := os.WriteFile(src, []byte("original"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	c := &Calibre{
+		Timeout:     DefaultTimeout,
+		ebookPolish: "ebook-polish",
+		Runner: func(ctx context.Context, name string, args ...string) ([]byte, error) {
+			got = args
+			data, err := os.ReadFile(args[len(args)-2])
+			if err != nil {
+				return nil, err
+			}
+			return nil, os.WriteFile(args[len(args)-1], append(data, " polished"...), 0644)
+		},
+	}
+
+	// The same book, spelled differently
+	out := dir + string(filepath.Separator) + "." + string(filepath.Separator) + "book.epub"
+	if err := c.Polish(context.Background(), src, PolishOptions{SubsetFonts: true, OutputPath: out}); err != nil {
+		t.Fatalf("Polish failed: %v", err)
+	}
+
+	if want := []string{"--subset-fonts", src, src}; !reflect.DeepEqual(got, want) {
+		t.Errorf("args = %q, want %q", got, want)
+	}
+	if data, _ := os.ReadFile(src); string(data) != "original polished" {
+		t.Errorf("book = %q, want it polished in place", data)
+	}
+}
+
+func TestPolishValidation(t *testing.T) {
+	c := &Calibre{Timeout: DefaultTimeout}
+	if err := c.Polish(context.Background(), "book.epub", PolishOptions{SubsetFonts: true}); err == nil {
+		t.Error("expected an error when ebook-polish is missing")
+	}
+
+	c.ebookPolish = "ebook-polish"
+	if err := c.Polish(context.Background(), "book.epub", PolishOptions{}); err == nil {
+		t.Error("expected an error when no actions are selected")
+	}
+	if err := c.Polish(context.Background(), "book.pdf", PolishOptions{SubsetFonts: true}); err == nil {
+		t.Error("expected an error for an unsupported format")
+	}
+}
@@ -0,0 +1,100 @@
+package calibre
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/anilpdv/go-calibre/ncx"
+)
+
+// ExtractPreview returns up to maxWords words from the opening of the book's
+// first body chapter, skipping front matter. For EPUBs only the chapters needed
+// to find the opening are read; other formats fall back to full extraction.
+func (c *Calibre) ExtractPreview(ctx context.Context, ebookPath string, maxWords int) (string, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if maxWords <= 0 {
+		return "", fmt.Errorf("maxWords must be positive, got %d", maxWords)
+	}
+
+	if isEPUB(ebookPath) {
+		preview, err := previewFromNCX(ctx, ebookPath, maxWords)
+		if err == nil {
+			return preview, nil
+		}
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+	}
+
+	chapters, err := c.ExtractChaptersContext(ctx, ebookPath)
+	if err != nil {
+		return "", err
+	}
+	for _, ch := range chapters {
+		if strings.TrimSpace(ch.Content) != "" {
+			return truncateWords(ch.Content, maxWords), nil
+		}
+	}
+
+	return "", fmt.Errorf("no chapter content found")
+}
+
+// previewFromNCX walks the EPUB's NCX and stops at the first body chapter
+func previewFromNCX(ctx context.Context, epubPath string, maxWords int) (string, error) {
+	ncxDoc, err := ncx.ExtractNCXFromEPUB(epubPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to extract NCX: %w", err)
+	}
+
+	toc := ncxDoc.GetTOC()
+	ncx.SortByPlayOrder(toc)
+
+	var filter ChapterFilter
+	entries := filterChapterEntries(skipGuideEntries(epubPath, toc), filter)
+	for i, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+
+		nextHref := ""
+		if i+1 < len(entries) {
+			nextHref = entries[i+1].Href
+		}
+
+		content, err := ncx.GetChapterContentRange(epubPath, entry.Href, nextHref)
+		if err != nil {
+			continue
+		}
+
+		// Same threshold as chapter extraction for skipping front matter
+		if len(strings.Fields(content)) < filter.minWords() {
+			continue
+		}
+
+		return truncateWords(content, maxWords), nil
+	}
+
+	return "", fmt.Errorf("no body chapter found in NCX")
+}
+
+// truncateWords cuts text after maxWords words, preserving the original spacing
+func truncateWords(text string, maxWords int) string {
+	count := 0
+	inWord := false
+	for i, r := range text {
+		if unicode.IsSpace(r) {
+			if inWord && count == maxWords {
+				return text[:i]
+			}
+			inWord = false
+		} else if !inWord {
+			inWord = true
+			count++
+		}
+	}
+	return text
+}
@@ -0,0 +1,51 @@
+package calibre
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestExtractPreview(t *testing.T) {
+	epub := writeTestEPUB(t, map[string]string{
+		"OEBPS/toc.ncx": testNCX(
+			[2]string{"Copyright", "copyright.xhtml"},
+			[2]string{"Chapter 1: The Beginning", "ch1.xhtml"},
+			[2]string{"Chapter 2: The Middle", "ch2.xhtml"},
+		),
+		"OEBPS/copyright.xhtml": testXHTML("<p>" + loremWords("Copyright", 80) + "</p>"),
+		"OEBPS/ch1.xhtml":       testXHTML("<h1>Chapter 1</h1><p>" + loremWords("Once", 200) + "</p>"),
+		"OEBPS/ch2.xhtml":       testXHTML("<p>" + loremWords("Later", 200) + "</p>"),
+	})
+
+	c := &Calibre{Timeout: DefaultTimeout}
+	preview, err := c.ExtractPreview(context.Background(), epub, 25)
+	if err != nil {
+		t.Fatalf("ExtractPreview failed: %v", err)
+	}
+
+	words := strings.Fields(preview)
+	if len(words) == 0 || len(words) > 25 {
+		t.Fatalf("expected 1-25 words, got %d", len(words))
+	}
+	if !strings.HasPrefix(preview, "Chapter 1") {
+		t.Errorf("preview should start with the opening chapter, got %q", preview)
+	}
+	if !strings.Contains(preview, "Once") {
+		t.Errorf("preview should contain the chapter's opening text, got %q", preview)
+	}
+	// A nil context is treated as context.Background, as elsewhere
+	if nilPreview, err := c.ExtractPreview(nil, epub, 25); err != nil || nilPreview != preview {
+		t.Errorf("ExtractPreview(nil ctx) = %q, %v", nilPreview, err)
+	}
+}
+
+func TestTruncateWords(t *testing.T) {
+	got := truncateWords("one two\n\nthree four", 3)
+	if got != "one two\n\nthree" {
+		t.Errorf("truncateWords = %q", got)
+	}
+	if got := truncateWords("short", 10); got != "short" {
+		t.Errorf("truncateWords = %q", got)
+	}
+}
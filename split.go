@@ -0,0 +1,83 @@
+package calibre
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// maxSlugLength caps the title part of SplitToFiles filenames, in runes
+const maxSlugLength = 60
+
+// SplitToFiles extracts a book's chapters and writes each one to outputDir as
+// NN-slug.txt, plus NN-slug.html when opts.KeepHTML is set. NN is the 1-based
+// chapter number, zero-padded so the files sort in reading order, and the
+// slug comes from the chapter title. Returns the written paths in order.
+func (c *Calibre) SplitToFiles(ctx context.Context, ebookPath, outputDir string, opts ChapterOptions) ([]string, error) {
+	chapters, err := c.ExtractChaptersWithOptions(ctx, ebookPath, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	width := len(strconv.Itoa(len(chapters)))
+	if width < 2 {
+		width = 2
+	}
+
+	var paths []string
+	for i, ch := range chapters {
+		base := filepath.Join(outputDir, fmt.Sprintf("%0*d-%s", width, i+1, slugify(ch.Title)))
+
+		path := base + ".txt"
+		if err := os.WriteFile(path, []byte(ch.Content), 0644); err != nil {
+			return paths, fmt.Errorf("failed to write chapter %d: %w", i+1, err)
+		}
+		paths = append(paths, path)
+
+		if opts.KeepHTML && ch.HTMLContent != "" {
+			path := base + ".html"
+			if err := os.WriteFile(path, []byte(ch.HTMLContent), 0644); err != nil {
+				return paths, fmt.Errorf("failed to write chapter %d: %w", i+1, err)
+			}
+			paths = append(paths, path)
+		}
+	}
+
+	return paths, nil
+}
+
+// slugify turns a title into a lowercase, filename-safe slug of letters and
+// digits joined by hyphens. Titles with nothing usable become "chapter".
+func slugify(title string) string {
+	var b strings.Builder
+	n := 0
+	pendingHyphen := false
+	for _, r := range strings.ToLower(title) {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			pendingHyphen = b.Len() > 0
+			continue
+		}
+		if n == maxSlugLength {
+			break
+		}
+		if pendingHyphen {
+			b.WriteByte('-')
+			pendingHyphen = false
+		}
+		b.WriteRune(r)
+		n++
+	}
+
+	if b.Len() == 0 {
+		return "chapter"
+	}
+	return b.String()
+}
@@ -0,0 +1,54 @@
+package calibre
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSplitToFiles(t *testing.T) {
+	epub := fourChapterEPUB(t)
+	c := &Calibre{Timeout: DefaultTimeout, ebookConvert: "ebook-convert"}
+	outDir := filepath.Join(t.TempDir(), "chapters")
+
+	paths, err := c.SplitToFiles(context.Background(), epub, outDir, ChapterOptions{KeepHTML: true})
+	if err != nil {
+		t.Fatalf("SplitToFiles failed: %v", err)
+	}
+
+	var names []string
+	for _, p := range paths {
+		names = append(names, filepath.Base(p))
+	}
+	want := "01-chapter-1.txt,01-chapter-1.html,02-chapter-2.txt,02-chapter-2.html," +
+		"03-chapter-3.txt,03-chapter-3.html,04-chapter-4.txt,04-chapter-4.html"
+	if got := strings.Join(names, ","); got != want {
+		t.Errorf("files = %q, want %q", got, want)
+	}
+
+	data, err := os.ReadFile(filepath.Join(outDir, "03-chapter-3.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "Three") {
+		t.Errorf("chapter 3 file has the wrong content: %.40q", data)
+	}
+}
+
+func TestSlugify(t *testing.T) {
+	tests := map[string]string{
+		"Chapter 1: The Boy Who Lived": "chapter-1-the-boy-who-lived",
+		"  ../../etc/passwd  ":         "etc-passwd",
+		"Café Économie":                "café-économie",
+		"***":                          "chapter",
+		"":                             "chapter",
+		strings.Repeat("a", 100):       strings.Repeat("a", maxSlugLength),
+	}
+	for in, want := range tests {
+		if got := slugify(in); got != want {
+			t.Errorf("slugify(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package calibre
+
+import (
+	"context"
+
+	"github.com/anilpdv/go-calibre/models"
+)
+
+// DefaultWordsPerPage is the default words-per-page used to estimate page counts
+const DefaultWordsPerPage = 300
+
+// StatsOptions configures book statistics
+type StatsOptions struct {
+	// WordsPerPage is the divisor for page estimates (defaults to DefaultWordsPerPage)
+	WordsPerPage int
+
+	// Chapters controls how chapters are extracted
+	Chapters ChapterOptions
+}
+
+// BookStats holds word, character, and page counts for a book
+type BookStats struct {
+	WordCount      int
+	CharCount      int
+	ChapterCount   int
+	EstimatedPages int
+
+	// Chapters is the per-chapter breakdown, in reading order
+	Chapters []ChapterStats
+}
+
+// ChapterStats holds the counts for a single chapter
+type ChapterStats struct {
+	Index          int
+	Title          string
+	WordCount      int
+	CharCount      int
+	EstimatedPages int
+}
+
+// BookStats extracts a book's chapters and aggregates their counts
+func (c *Calibre) BookStats(ctx context.Context, ebookPath string) (*BookStats, error) {
+	return c.BookStatsWithOptions(ctx, ebookPath, StatsOptions{})
+}
+
+// BookStatsWithOptions aggregates chapter counts with custom options
+func (c *Calibre) BookStatsWithOptions(ctx context.Context, ebookPath string, opts StatsOptions) (*BookStats, error) {
+	chapters, err := c.ExtractChaptersWithOptions(ctx, ebookPath, opts.Chapters)
+	if err != nil {
+		return nil, err
+	}
+
+	return statsFromChapters(chapters, opts.WordsPerPage), nil
+}
+
+// statsFromChapters sums chapter counts and estimates pages
+func statsFromChapters(chapters []models.Chapter, wordsPerPage int) *BookStats {
+	if wordsPerPage <= 0 {
+		wordsPerPage = DefaultWordsPerPage
+	}
+
+	stats := &BookStats{ChapterCount: len(chapters)}
+	for _, ch := range chapters {
+		stats.WordCount += ch.WordCount
+		stats.CharCount += ch.CharCount
+		stats.Chapters = append(stats.Chapters, ChapterStats{
+			Index:          ch.Index,
+			Title:          ch.Title,
+			WordCount:      ch.WordCount,
+			CharCount:      ch.CharCount,
+			EstimatedPages: estimatePages(ch.WordCount, wordsPerPage),
+		})
+	}
+	stats.EstimatedPages = estimatePages(stats.WordCount, wordsPerPage)
+
+	return stats
+}
+
+// estimatePages rounds up to whole pages, so any text counts as at least one
+func estimatePages(words, wordsPerPage int) int {
+	return (words + wordsPerPage - 1) / wordsPerPage
+}
@@ -0,0 +1,48 @@
+package calibre
+
+import (
+	"context"
+	"testing"
+
+	"github.com/anilpdv/go-calibre/models"
+)
+
+func TestStatsFromChapters(t *testing.T) {
+	chapters := []models.Chapter{
+		models.NewChapter(0, "One", loremWords("One", 450)),
+		models.NewChapter(1, "Two", loremWords("Two", 150)),
+	}
+
+	stats := statsFromChapters(chapters, 0)
+	if stats.ChapterCount != 2 || stats.WordCount != 600 {
+		t.Errorf("got %d chapters / %d words", stats.ChapterCount, stats.WordCount)
+	}
+	if stats.EstimatedPages != 2 {
+		t.Errorf("EstimatedPages = %d, want 2", stats.EstimatedPages)
+	}
+	if stats.Chapters[0].EstimatedPages != 2 || stats.Chapters[1].EstimatedPages != 1 {
+		t.Errorf("chapter pages = %d, %d", stats.Chapters[0].EstimatedPages, stats.Chapters[1].EstimatedPages)
+	}
+	if stats.CharCount != chapters[0].CharCount+chapters[1].CharCount {
+		t.Errorf("CharCount = %d", stats.CharCount)
+	}
+
+	if stats := statsFromChapters(chapters, 100); stats.EstimatedPages != 6 {
+		t.Errorf("with 100 words per page: EstimatedPages = %d, want 6", stats.EstimatedPages)
+	}
+}
+
+func TestBookStats(t *testing.T) {
+	c := &Calibre{Timeout: DefaultTimeout, ebookConvert: "ebook-convert"}
+
+	stats, err := c.BookStats(context.Background(), fourChapterEPUB(t))
+	if err != nil {
+		t.Fatalf("BookStats failed: %v", err)
+	}
+	if stats.ChapterCount != 4 || len(stats.Chapters) != 4 {
+		t.Errorf("ChapterCount = %d", stats.ChapterCount)
+	}
+	if stats.WordCount != 240 || stats.EstimatedPages != 1 {
+		t.Errorf("WordCount = %d, EstimatedPages = %d", stats.WordCount, stats.EstimatedPages)
+	}
+}
@@ -0,0 +1,222 @@
+package calibre
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/anilpdv/go-calibre/opf"
+)
+
+// Fields that StripMetadata can clear
+const (
+	FieldAuthors      = "authors"
+	FieldAuthorSort   = "author_sort"
+	FieldPublisher    = "publisher"
+	FieldDate         = "date"
+	FieldLanguage     = "language"
+	FieldTags         = "tags"
+	FieldSeries       = "series"
+	FieldComments     = "comments"
+	FieldRating       = "rating"
+	FieldBookProducer = "book_producer"
+	FieldIdentifiers  = "identifiers"
+
+	// FieldCalibreMeta removes the calibre:* meta elements Calibre adds to
+	// an EPUB's OPF. It has no effect on other formats.
+	FieldCalibreMeta = "calibre_meta"
+)
+
+// stripArgs are the ebook-meta arguments that clear each simple field
+var stripArgs = map[string][]string{
+	FieldAuthors:      {"--authors", ""},
+	FieldAuthorSort:   {"--author-sort", ""},
+	FieldPublisher:    {"--publisher", ""},
+	FieldDate:         {"--date", ""},
+	FieldLanguage:     {"--language", ""},
+	FieldTags:         {"--tags", ""},
+	FieldSeries:       {"--series", ""},
+	FieldComments:     {"--comments", ""},
+	FieldRating:       {"--rating", "0"},
+	FieldBookProducer: {"--book-producer", ""},
+}
+
+// StripMetadata clears the given metadata fields (the Field constants) from
+// an ebook using ebook-meta, e.g. before sharing it. Identifiers are cleared
+// one scheme at a time, so the book's OPF is read first.
+func (c *Calibre) StripMetadata(ctx context.Context, ebookPath string, fields []string) error {
+	var args []string
+	stripIdentifiers, stripCalibre := false, false
+	for _, field := range fields {
+		switch field {
+		case FieldIdentifiers:
+			stripIdentifiers = true
+		case FieldCalibreMeta:
+			stripCalibre = true
+		default:
+			flag, ok := stripArgs[field]
+			if !ok {
+				return fmt.Errorf("unknown metadata field: %s", field)
+			}
+			args = append(args, flag...)
+		}
+	}
+
+	if stripIdentifiers {
+		schemes, err := c.identifierSchemes(ctx, ebookPath)
+		if err != nil {
+			return err
+		}
+
+		// An identifier with an empty value is removed
+		for _, scheme := range schemes {
+			args = append(args, "--identifier", scheme+":")
+		}
+	}
+
+	if len(args) > 0 {
+		if c.ebookMeta == "" {
+			return toolNotFound("ebook-meta")
+		}
+		if _, err := c.runCommand(ctx, c.ebookMeta, append([]string{ebookPath}, args...)...); err != nil {
+			return fmt.Errorf("ebook-meta failed: %w", err)
+		}
+	}
+
+	if stripCalibre && isEPUB(ebookPath) {
+		return stripCalibreMeta(ebookPath)
+	}
+
+	return nil
+}
+
+// StripAll clears every metadata field except the title, including
+// Calibre's fingerprint meta elements in EPUBs
+func (c *Calibre) StripAll(ctx context.Context, ebookPath string) error {
+	fields := []string{FieldIdentifiers, FieldCalibreMeta}
+	for field := range stripArgs {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	return c.StripMetadata(ctx, ebookPath, fields)
+}
+
+// stripCalibreMeta rewrites an EPUB's OPF without Calibre's meta elements
+func stripCalibreMeta(epubPath string) error {
+	r, err := zip.OpenReader(epubPath)
+	if err != nil {
+		return fmt.Errorf("failed to open EPUB: %w", err)
+	}
+	opfPath, err := opf.FindOPFPath(&r.Reader)
+	if err != nil {
+		r.Close()
+		return err
+	}
+
+	var data []byte
+	for _, f := range r.File {
+		if f.Name == opfPath {
+			data, err = readZipFile(f)
+			break
+		}
+	}
+	r.Close()
+	if err != nil {
+		return err
+	}
+	if data == nil {
+		return fmt.Errorf("OPF %s not found in EPUB", opfPath)
+	}
+
+	return replaceZipEntry(epubPath, opfPath, opf.StripCalibreMeta(data))
+}
+
+// replaceZipEntry rewrites a zip file with one entry's content replaced,
+// copying every other entry as is. The file is replaced atomically.
+func replaceZipEntry(zipPath, name string, data []byte) error {
+	r, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", filepath.Base(zipPath), err)
+	}
+	defer r.Close()
+
+	tmp, err := os.CreateTemp(filepath.Dir(zipPath), ".calibre-rewrite-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	zw := zip.NewWriter(tmp)
+	for _, f := range r.File {
+		if f.Name != name {
+			// Keep entries, including an uncompressed mimetype, byte for byte
+			if err := zw.Copy(f); err != nil {
+				tmp.Close()
+				return fmt.Errorf("failed to copy %s: %w", f.Name, err)
+			}
+			continue
+		}
+
+		header := f.FileHeader
+		w, err := zw.CreateHeader(&header)
+		if err == nil {
+			_, err = w.Write(data)
+		}
+		if err != nil {
+			tmp.Close()
+			return fmt.Errorf("failed to write %s: %w", name, err)
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to finish %s: %w", filepath.Base(zipPath), err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to finish %s: %w", filepath.Base(zipPath), err)
+	}
+
+	// Keep the original permissions, and release the file before replacing it
+	if info, err := os.Stat(zipPath); err == nil {
+		os.Chmod(tmp.Name(), info.Mode().Perm())
+	}
+	r.Close()
+
+	if err := os.Rename(tmp.Name(), zipPath); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", filepath.Base(zipPath), err)
+	}
+	return nil
+}
+
+// identifierSchemes returns the schemes of a book's identifiers as its OPF
+// spells them (lowercased, as Calibre keys them), rather than normalized as
+// GetMetadata reports them, since ebook-meta only clears an exact match
+func (c *Calibre) identifierSchemes(ctx context.Context, ebookPath string) ([]string, error) {
+	raw, err := c.GetMetadataRaw(ctx, ebookPath)
+	if err != nil {
+		return nil, err
+	}
+	pkg, err := opf.ParsePackage(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("%w OPF: %w", ErrParse, err)
+	}
+
+	seen := make(map[string]bool)
+	var schemes []string
+	for _, id := range pkg.Metadata.Identifiers {
+		scheme := strings.ToLower(strings.TrimSpace(id.Scheme))
+		if scheme == "" || seen[scheme] {
+			continue
+		}
+		seen[scheme] = true
+		schemes = append(schemes, scheme)
+	}
+	sort.Strings(schemes)
+	return schemes, nil
+}
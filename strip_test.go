@@ -0,0 +1,141 @@
+package calibre
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/anilpdv/go-calibre/models"
+	"github.com/anilpdv/go-calibre/opf"
+)
+
+// fakeEbookMeta emulates ebook-meta over an in-memory book: --to-opf writes
+// it out, and the tag, comment and identifier flags update it
+func fakeEbookMeta(book *opf.ParsedMetadata) CommandRunner {
+	return func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		for i := 1; i+1 < len(args); i += 2 {
+			value := args[i+1]
+			switch args[i] {
+			case "--to-opf":
+				data, err := opf.Marshal(book)
+				if err != nil {
+					return nil, err
+				}
+				return nil, os.WriteFile(value, data, 0644)
+			case "--tags":
+				book.Tags = nil
+				if value != "" {
+					book.Tags = strings.Split(value, ",")
+				}
+			case "--comments":
+				book.Description, book.Comments = value, value
+			case "--identifier":
+				scheme, id, _ := strings.Cut(value, ":")
+				if id == "" {
+					delete(book.Identifiers, scheme)
+				} else {
+					book.Identifiers[scheme] = id
+				}
+			}
+		}
+		return nil, nil
+	}
+}
+
+func TestStripMetadata(t *testing.T) {
+	book := &opf.ParsedMetadata{Title: "Shared Book", Identifiers: map[string]string{}}
+	c := &Calibre{Timeout: DefaultTimeout, ebookMeta: "ebook-meta", Runner: fakeEbookMeta(book)}
+	ctx := context.Background()
+
+	err := c.SetMetadata(ctx, "book.mobi", &models.Metadata{
+		Tags:        []string{"private", "to-read"},
+		Description: "My notes",
+		Identifiers: map[string]string{"goodreads": "123", "uuid": "abc", "mobi-asin": "B000FA5ZEG"},
+	})
+	if err != nil {
+		t.Fatalf("SetMetadata failed: %v", err)
+	}
+
+	before, err := c.GetMetadataContext(ctx, "book.mobi")
+	if err != nil {
+		t.Fatalf("GetMetadata failed: %v", err)
+	}
+	if len(before.Tags) != 2 || len(before.Identifiers) != 3 {
+		t.Fatalf("SetMetadata didn't take: tags %v, identifiers %v", before.Tags, before.Identifiers)
+	}
+
+	if err := c.StripMetadata(ctx, "book.mobi", []string{FieldTags, FieldIdentifiers}); err != nil {
+		t.Fatalf("StripMetadata failed: %v", err)
+	}
+
+	meta, err := c.GetMetadataContext(ctx, "book.mobi")
+	if err != nil {
+		t.Fatalf("GetMetadata failed: %v", err)
+	}
+	if len(meta.Tags) != 0 {
+		t.Errorf("Tags = %v, want none", meta.Tags)
+	}
+	if len(meta.Identifiers) != 0 {
+		t.Errorf("Identifiers = %v, want none", meta.Identifiers)
+	}
+	if meta.Description != "My notes" || meta.Title != "Shared Book" {
+		t.Errorf("unrequested fields changed: %+v", meta)
+	}
+
+	if err := c.StripMetadata(ctx, "book.mobi", []string{"shoe_size"}); err == nil {
+		t.Error("expected an error for an unknown field")
+	}
+}
+
+func TestStripAllRemovesCalibreMeta(t *testing.T) {
+	epub := writeTestEPUB(t, map[string]string{
+		"META-INF/container.xml": testContainer("content.opf"),
+		"content.opf": testOPF(`<dc:title>Shared</dc:title>
+<dc:contributor opf:role="bkp">calibre (8.16.2)</dc:contributor>
+<meta name="calibre:timestamp" content="2024-01-01T00:00:00+00:00"/>
+<meta name="calibre:user_metadata:#read" content="{}"/>
+<meta name="cover" content="cover"/>`, "", ""),
+		"ch1.xhtml": "<html/>",
+	})
+
+	book := &opf.ParsedMetadata{Title: "Shared", Identifiers: map[string]string{"uuid": "abc"}}
+	fake := fakeEbookMeta(book)
+	var ran []string
+	c := &Calibre{
+		Timeout:   DefaultTimeout,
+		ebookMeta: "ebook-meta",
+		Runner: func(ctx context.Context, name string, args ...string) ([]byte, error) {
+			ran = append(ran, args...)
+			return fake(ctx, name, args...)
+		},
+	}
+
+	if err := c.StripAll(context.Background(), epub); err != nil {
+		t.Fatalf("StripAll failed: %v", err)
+	}
+	for _, arg := range ran {
+		if arg == "--title" {
+			t.Error("StripAll should keep the title")
+		}
+	}
+	if len(book.Identifiers) != 0 {
+		t.Errorf("Identifiers = %v, want none", book.Identifiers)
+	}
+
+	pkg, _, err := opf.ReadPackageFromEPUB(epub)
+	if err != nil {
+		t.Fatalf("stripped EPUB is unreadable: %v", err)
+	}
+	for _, m := range pkg.Metadata.Meta {
+		if strings.HasPrefix(m.Name, "calibre:") {
+			t.Errorf("calibre meta %q survived", m.Name)
+		}
+	}
+	if len(pkg.Metadata.Meta) != 1 || len(pkg.Metadata.Contributors) != 0 {
+		t.Errorf("meta = %+v, contributors = %+v", pkg.Metadata.Meta, pkg.Metadata.Contributors)
+	}
+	if pkg.Metadata.Title != "Shared" {
+		t.Errorf("Title = %q", pkg.Metadata.Title)
+	}
+}
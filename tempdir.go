@@ -0,0 +1,110 @@
+package calibre
+
+import (
+	"fmt"
+	"os"
+)
+
+// checkTempDir confirms dir is an existing directory we can create files in
+func checkTempDir(dir string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("temp dir %s is not usable: %w", dir, err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("temp dir %s is not a directory", dir)
+	}
+
+	probe, err := os.CreateTemp(dir, ".calibre-probe-*")
+	if err != nil {
+		return fmt.Errorf("temp dir %s is not writable: %w", dir, err)
+	}
+	probe.Close()
+	os.Remove(probe.Name())
+
+	return nil
+}
+
+// tempBase returns the directory temporary files are created in: TempDir,
+// or the OS temp dir
+func (c *Calibre) tempBase() (string, error) {
+	base := c.TempDir
+	if base == "" {
+		base = os.TempDir()
+	}
+	if err := checkTempDir(base); err != nil {
+		return "", err
+	}
+	return base, nil
+}
+
+// mkdirTemp creates a temp directory for a single operation, which removes it
+// when done
+func (c *Calibre) mkdirTemp(pattern string) (string, error) {
+	base, err := c.tempBase()
+	if err != nil {
+		return "", err
+	}
+
+	dir, err := os.MkdirTemp(base, pattern)
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	return dir, nil
+}
+
+// createTemp creates a temp file for a single operation, which removes it
+// when done
+func (c *Calibre) createTemp(pattern string) (*os.File, error) {
+	base, err := c.tempBase()
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.CreateTemp(base, pattern)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	return f, nil
+}
+
+// mkdirScratch creates a temp directory that outlives the call making it,
+// such as a BookHandle's, in the instance's scratch directory so Close can
+// remove whatever is left of it. The scratch directory is created on first
+// use, or again after Close.
+func (c *Calibre) mkdirScratch(pattern string) (string, error) {
+	c.scratchMu.Lock()
+	defer c.scratchMu.Unlock()
+
+	if c.scratch == "" {
+		base, err := c.tempBase()
+		if err != nil {
+			return "", err
+		}
+		if c.scratch, err = os.MkdirTemp(base, "go-calibre-*"); err != nil {
+			return "", fmt.Errorf("failed to create scratch dir: %w", err)
+		}
+	}
+
+	dir, err := os.MkdirTemp(c.scratch, pattern)
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	return dir, nil
+}
+
+// Close removes the instance's scratch directory, which holds the temporary
+// files of BookHandles, including ones that are still open. Other operations
+// clean up after themselves, so Close is only needed after using Open. The
+// instance can still be used afterwards.
+func (c *Calibre) Close() error {
+	c.scratchMu.Lock()
+	defer c.scratchMu.Unlock()
+
+	if c.scratch == "" {
+		return nil
+	}
+	err := os.RemoveAll(c.scratch)
+	c.scratch = ""
+	return err
+}
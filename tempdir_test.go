@@ -0,0 +1,85 @@
+package calibre
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTempDir(t *testing.T) {
+	base := t.TempDir()
+	var opfPath string
+	c := &Calibre{
+		Timeout:   DefaultTimeout,
+		TempDir:   base,
+		ebookMeta: "ebook-meta",
+		Runner: func(ctx context.Context, name string, args ...string) ([]byte, error) {
+			opfPath = args[len(args)-1]
+			return nil, os.WriteFile(opfPath, []byte(testOPF(`<dc:title>Scratch</dc:title>`, "", "")), 0644)
+		},
+	}
+
+	meta, err := c.GetMetadataContext(context.Background(), "book.mobi")
+	if err != nil {
+		t.Fatalf("GetMetadataContext failed: %v", err)
+	}
+	if meta.Title != "Scratch" {
+		t.Errorf("Title = %q, want Scratch", meta.Title)
+	}
+	if !strings.HasPrefix(opfPath, base+string(filepath.Separator)) {
+		t.Errorf("temp file %s is not under TempDir %s", opfPath, base)
+	}
+
+	// The operation removed its temp file itself
+	if entries, _ := os.ReadDir(base); len(entries) != 0 {
+		t.Errorf("TempDir holds %d entries after the operation", len(entries))
+	}
+
+	// A BookHandle's files stay until Close
+	book := filepath.Join(t.TempDir(), "book.mobi")
+	if err := os.WriteFile(book, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Open(context.Background(), book); err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if entries, _ := os.ReadDir(base); len(entries) != 1 {
+		t.Errorf("TempDir holds %d entries with a handle open, want 1", len(entries))
+	}
+	if err := c.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if entries, _ := os.ReadDir(base); len(entries) != 0 {
+		t.Errorf("TempDir still holds %d entries after Close", len(entries))
+	}
+}
+
+func TestTempDirUnusable(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing")
+	c := &Calibre{
+		Timeout:      DefaultTimeout,
+		TempDir:      missing,
+		ebookMeta:    "ebook-meta",
+		ebookConvert: "ebook-convert",
+		Runner: func(ctx context.Context, name string, args ...string) ([]byte, error) {
+			t.Fatalf("ran %s with an unusable TempDir", name)
+			return nil, nil
+		},
+	}
+
+	_, err := c.GetMetadataContext(context.Background(), "book.mobi")
+	if err == nil || !strings.Contains(err.Error(), "temp dir "+missing+" is not usable") {
+		t.Errorf("expected a temp dir error, got %v", err)
+	}
+
+	_, err = c.ExtractChaptersWithOptions(context.Background(), "book.mobi", ChapterOptions{})
+	if err == nil || !strings.Contains(err.Error(), missing) {
+		t.Errorf("expected a temp dir error, got %v", err)
+	}
+
+	if _, err := NewWithOptions(Options{TempDir: missing}); err == nil || !strings.Contains(err.Error(), missing) {
+		t.Errorf("NewWithOptions: expected a temp dir error, got %v", err)
+	}
+}
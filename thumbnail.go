@@ -0,0 +1,147 @@
+package calibre
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/gif" // register GIF covers for decoding
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// GenerateThumbnail extracts a book's cover and scales it down to fit within
+// maxWidth x maxHeight, keeping its aspect ratio. The output format is JPEG
+// or PNG depending on outputPath's extension. A cover that already fits is
+// copied unchanged when it is in the output format. Books without a cover
+// return ErrNoCover.
+func (c *Calibre) GenerateThumbnail(ctx context.Context, ebookPath, outputPath string, maxWidth, maxHeight int) error {
+	if maxWidth <= 0 || maxHeight <= 0 {
+		return fmt.Errorf("invalid thumbnail size %dx%d", maxWidth, maxHeight)
+	}
+
+	format := strings.ToLower(filepath.Ext(outputPath))
+	switch format {
+	case ".jpg", ".jpeg":
+		format = "jpeg"
+	case ".png":
+		format = "png"
+	default:
+		return fmt.Errorf("unsupported thumbnail format %q: use .jpg or .png", filepath.Ext(outputPath))
+	}
+
+	data, _, err := c.ExtractCoverData(ctx, ebookPath)
+	if err != nil {
+		return err
+	}
+
+	src, srcFormat, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("%w cover image: %w", ErrParse, err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	bounds := src.Bounds()
+	fits := bounds.Dx() <= maxWidth && bounds.Dy() <= maxHeight
+	if fits && srcFormat == format {
+		if err := os.WriteFile(outputPath, data, 0644); err != nil {
+			return fmt.Errorf("failed to write thumbnail: %w", err)
+		}
+		return nil
+	}
+
+	thumb := src
+	if !fits {
+		w, h := fitSize(bounds.Dx(), bounds.Dy(), maxWidth, maxHeight)
+		thumb = scaleImage(src, w, h)
+	}
+
+	out, err := encodeImage(thumb, format)
+	if err != nil {
+		return fmt.Errorf("failed to encode thumbnail: %w", err)
+	}
+
+	if err := os.WriteFile(outputPath, out, 0644); err != nil {
+		return fmt.Errorf("failed to write thumbnail: %w", err)
+	}
+	return nil
+}
+
+// encodeImage encodes img as "jpeg" or "png"
+func encodeImage(img image.Image, format string) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	if format == "png" {
+		err = png.Encode(&buf, img)
+	} else {
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: 85})
+	}
+	return buf.Bytes(), err
+}
+
+// fitSize scales w x h down to fit within maxW x maxH, keeping the aspect ratio
+func fitSize(w, h, maxW, maxH int) (int, int) {
+	scale := float64(maxW) / float64(w)
+	if s := float64(maxH) / float64(h); s < scale {
+		scale = s
+	}
+
+	nw, nh := int(float64(w)*scale+0.5), int(float64(h)*scale+0.5)
+	if nw < 1 {
+		nw = 1
+	}
+	if nh < 1 {
+		nh = 1
+	}
+	return nw, nh
+}
+
+// scaleImage downscales src to w x h by averaging the source pixels that
+// fall under each destination pixel
+func scaleImage(src image.Image, w, h int) image.Image {
+	b := src.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), src, b.Min, draw.Src)
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	sw, sh := b.Dx(), b.Dy()
+	for y := 0; y < h; y++ {
+		y0, y1 := y*sh/h, (y+1)*sh/h
+		if y1 == y0 {
+			y1 = y0 + 1
+		}
+		for x := 0; x < w; x++ {
+			x0, x1 := x*sw/w, (x+1)*sw/w
+			if x1 == x0 {
+				x1 = x0 + 1
+			}
+
+			var r, g, bl, a, n uint32
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					i := rgba.PixOffset(sx, sy)
+					r += uint32(rgba.Pix[i])
+					g += uint32(rgba.Pix[i+1])
+					bl += uint32(rgba.Pix[i+2])
+					a += uint32(rgba.Pix[i+3])
+					n++
+				}
+			}
+
+			i := dst.PixOffset(x, y)
+			dst.Pix[i] = uint8(r / n)
+			dst.Pix[i+1] = uint8(g / n)
+			dst.Pix[i+2] = uint8(bl / n)
+			dst.Pix[i+3] = uint8(a / n)
+		}
+	}
+
+	return dst
+}
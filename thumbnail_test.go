@@ -0,0 +1,78 @@
+package calibre
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func testPNG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.RGBA{uint8(x), uint8(y), 128, 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestGenerateThumbnail(t *testing.T) {
+	c := &Calibre{Timeout: DefaultTimeout, ebookMeta: "ebook-meta", Runner: coverRunner(testPNG(t, 400, 600))}
+	out := filepath.Join(t.TempDir(), "thumb.jpg")
+
+	if err := c.GenerateThumbnail(context.Background(), "book.epub", out, 100, 100); err != nil {
+		t.Fatalf("GenerateThumbnail failed: %v", err)
+	}
+
+	f, err := os.Open(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	img, err := jpeg.Decode(f)
+	if err != nil {
+		t.Fatalf("thumbnail is not a JPEG: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != 67 || b.Dy() != 100 {
+		t.Errorf("thumbnail is %dx%d, want 67x100", b.Dx(), b.Dy())
+	}
+}
+
+func TestGenerateThumbnailSmallCover(t *testing.T) {
+	cover := testPNG(t, 40, 60)
+	c := &Calibre{Timeout: DefaultTimeout, ebookMeta: "ebook-meta", Runner: coverRunner(cover)}
+	out := filepath.Join(t.TempDir(), "thumb.png")
+
+	if err := c.GenerateThumbnail(context.Background(), "book.epub", out, 100, 100); err != nil {
+		t.Fatalf("GenerateThumbnail failed: %v", err)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, cover) {
+		t.Error("a cover smaller than the target should be copied unchanged")
+	}
+}
+
+func TestGenerateThumbnailNoCover(t *testing.T) {
+	c := &Calibre{Timeout: DefaultTimeout, ebookMeta: "ebook-meta", Runner: coverRunner(nil)}
+	out := filepath.Join(t.TempDir(), "thumb.png")
+
+	err := c.GenerateThumbnail(context.Background(), "book.epub", out, 100, 100)
+	if !errors.Is(err, ErrNoCover) {
+		t.Errorf("expected ErrNoCover, got %v", err)
+	}
+}
@@ -0,0 +1,61 @@
+package calibre
+
+import "fmt"
+
+// toolFeatures describes what each Calibre tool is needed for
+var toolFeatures = map[string]string{
+	"ebook-meta":           "metadata extraction",
+	"ebook-convert":        "conversion and chapter extraction",
+	"fetch-ebook-metadata": "online metadata lookup",
+	"ebook-polish":         "polishing",
+	"calibredb":            "library management",
+}
+
+// MissingToolError is returned by RequireTool when a tool isn't installed.
+// It wraps ErrToolNotFound.
+type MissingToolError struct {
+	Tool    string
+	Feature string
+}
+
+func (e *MissingToolError) Error() string {
+	return fmt.Sprintf("%s not found: %s is unavailable", e.Tool, e.Feature)
+}
+
+// Unwrap lets errors.Is match ErrToolNotFound
+func (e *MissingToolError) Unwrap() error {
+	return ErrToolNotFound
+}
+
+// toolPaths maps each tool name to the field holding its detected path
+func (c *Calibre) toolPaths() map[string]*string {
+	return map[string]*string{
+		"ebook-meta":           &c.ebookMeta,
+		"ebook-convert":        &c.ebookConvert,
+		"fetch-ebook-metadata": &c.fetchMeta,
+		"ebook-polish":         &c.ebookPolish,
+		"calibredb":            &c.calibredb,
+	}
+}
+
+// AvailableTools reports which Calibre command-line tools were found
+func (c *Calibre) AvailableTools() map[string]bool {
+	tools := make(map[string]bool)
+	for name, path := range c.toolPaths() {
+		tools[name] = *path != ""
+	}
+	return tools
+}
+
+// RequireTool returns a *MissingToolError naming the feature that is blocked
+// if the tool isn't available, e.g. for a startup capability check
+func (c *Calibre) RequireTool(name string) error {
+	path, ok := c.toolPaths()[name]
+	if !ok {
+		return fmt.Errorf("unknown calibre tool: %s", name)
+	}
+	if *path == "" {
+		return &MissingToolError{Tool: name, Feature: toolFeatures[name]}
+	}
+	return nil
+}
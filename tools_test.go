@@ -0,0 +1,48 @@
+package calibre
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestAvailableTools(t *testing.T) {
+	c := &Calibre{ebookMeta: "/usr/bin/ebook-meta", ebookConvert: "/usr/bin/ebook-convert"}
+
+	tools := c.AvailableTools()
+	want := map[string]bool{
+		"ebook-meta":           true,
+		"ebook-convert":        true,
+		"fetch-ebook-metadata": false,
+		"ebook-polish":         false,
+		"calibredb":            false,
+	}
+	if len(tools) != len(want) {
+		t.Fatalf("AvailableTools = %v", tools)
+	}
+	for name, ok := range want {
+		if tools[name] != ok {
+			t.Errorf("%s: available = %v, want %v", name, tools[name], ok)
+		}
+	}
+}
+
+func TestRequireTool(t *testing.T) {
+	c := &Calibre{ebookMeta: "/usr/bin/ebook-meta"}
+
+	if err := c.RequireTool("ebook-meta"); err != nil {
+		t.Errorf("ebook-meta: %v", err)
+	}
+
+	err := c.RequireTool("ebook-polish")
+	var missing *MissingToolError
+	if !errors.As(err, &missing) || missing.Tool != "ebook-polish" || missing.Feature != "polishing" {
+		t.Errorf("expected MissingToolError for ebook-polish, got %v", err)
+	}
+	if !errors.Is(err, ErrToolNotFound) {
+		t.Errorf("expected error to wrap ErrToolNotFound, got %v", err)
+	}
+
+	if err := c.RequireTool("ebook-viewer"); err == nil || errors.Is(err, ErrToolNotFound) {
+		t.Errorf("unknown tool: got %v", err)
+	}
+}
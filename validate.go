@@ -0,0 +1,266 @@
+package calibre
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/anilpdv/go-calibre/ncx"
+	"github.com/anilpdv/go-calibre/opf"
+)
+
+// IssueKind categorizes a structural problem found in an EPUB
+type IssueKind string
+
+const (
+	// IssueUnreadable means the EPUB, OPF, or NCX could not be read at all
+	IssueUnreadable IssueKind = "unreadable"
+
+	// IssueDanglingNCXHref means an NCX entry points at no manifest item
+	IssueDanglingNCXHref IssueKind = "dangling-ncx-href"
+
+	// IssueMissingSpineItem means a spine itemref names no manifest item
+	IssueMissingSpineItem IssueKind = "missing-spine-item"
+
+	// IssueOrphanManifestItem means a content document is not in the spine
+	IssueOrphanManifestItem IssueKind = "orphan-manifest-item"
+
+	// IssueBadMimetype means the mimetype entry is missing, not first,
+	// compressed, or has the wrong value
+	IssueBadMimetype IssueKind = "bad-mimetype"
+
+	// IssueBadContainer means META-INF/container.xml is missing or names no OPF
+	IssueBadContainer IssueKind = "bad-container"
+
+	// IssueBadOPF means the package document is missing or doesn't parse
+	IssueBadOPF IssueKind = "bad-opf"
+
+	// IssueMissingFile means a manifest href has no matching zip entry
+	IssueMissingFile IssueKind = "missing-file"
+)
+
+// epubMimetype is the required content of an EPUB's mimetype entry
+const epubMimetype = "application/epub+zip"
+
+// ValidationIssue describes a single structural problem in an EPUB
+type ValidationIssue struct {
+	Kind    IssueKind
+	Href    string // Offending href or idref, when there is one
+	Message string
+}
+
+// ValidationReport lists the structural problems found by ValidateEPUB
+type ValidationReport struct {
+	Issues []ValidationIssue
+}
+
+// Valid reports whether no problems were found
+func (r *ValidationReport) Valid() bool {
+	return len(r.Issues) == 0
+}
+
+func (r *ValidationReport) add(kind IssueKind, href, format string, args ...interface{}) {
+	r.Issues = append(r.Issues, ValidationIssue{Kind: kind, Href: href, Message: fmt.Sprintf(format, args...)})
+}
+
+// ValidateEPUB checks an EPUB's container structure without converting it:
+// the mimetype entry must come first, be stored uncompressed, and contain
+// "application/epub+zip"; META-INF/container.xml must point at an OPF that
+// parses; and every manifest href and spine itemref must resolve. Every
+// problem found is listed in the report. An error is returned only if the
+// file can't be opened as a zip at all.
+func ValidateEPUB(epubPath string) (*ValidationReport, error) {
+	r, err := zip.OpenReader(epubPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open EPUB: %w", err)
+	}
+	defer r.Close()
+
+	report := &ValidationReport{}
+	validateMimetype(&r.Reader, report)
+
+	opfPath, err := opf.FindOPFPath(&r.Reader)
+	if err != nil {
+		report.add(IssueBadContainer, "META-INF/container.xml", "%v", err)
+		return report, nil
+	}
+
+	f, err := r.Open(opfPath)
+	if err != nil {
+		report.add(IssueBadOPF, opfPath, "container.xml points at %s, which is not in the EPUB", opfPath)
+		return report, nil
+	}
+	pkg, err := opf.ParsePackage(f)
+	f.Close()
+	if err != nil {
+		report.add(IssueBadOPF, opfPath, "%v", err)
+		return report, nil
+	}
+
+	entries := make(map[string]bool)
+	for _, f := range r.File {
+		entries[f.Name] = true
+	}
+
+	for _, item := range pkg.Manifest.Items {
+		if name := opf.ResolveHref(opfPath, opf.UnescapeHref(item.Href)); !entries[name] {
+			report.add(IssueMissingFile, item.Href, "manifest item %q points at %s, which is not in the EPUB", item.ID, name)
+		}
+	}
+
+	for _, ref := range pkg.Spine {
+		if pkg.ItemByID(ref.IDRef) == nil {
+			report.add(IssueMissingSpineItem, ref.IDRef, "spine itemref %q has no manifest item", ref.IDRef)
+		}
+	}
+
+	return report, nil
+}
+
+// validateMimetype checks the EPUB's mimetype entry
+func validateMimetype(zr *zip.Reader, report *ValidationReport) {
+	var mimetype *zip.File
+	for _, f := range zr.File {
+		if f.Name == "mimetype" {
+			mimetype = f
+			break
+		}
+	}
+	if mimetype == nil {
+		report.add(IssueBadMimetype, "mimetype", "mimetype entry is missing")
+		return
+	}
+
+	if zr.File[0] != mimetype {
+		report.add(IssueBadMimetype, "mimetype", "mimetype must be the first entry in the zip")
+	}
+	if mimetype.Method != zip.Store {
+		report.add(IssueBadMimetype, "mimetype", "mimetype must be stored uncompressed")
+	}
+
+	rc, err := mimetype.Open()
+	if err != nil {
+		report.add(IssueBadMimetype, "mimetype", "failed to read mimetype: %v", err)
+		return
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		report.add(IssueBadMimetype, "mimetype", "failed to read mimetype: %v", err)
+		return
+	}
+	if string(data) != epubMimetype {
+		report.add(IssueBadMimetype, "mimetype", "mimetype is %q, want %q", data, epubMimetype)
+	}
+}
+
+// ValidateReadingOrder checks the EPUB's spine, manifest, and NCX against each
+// other: every NCX href must resolve to a manifest item, every spine itemref
+// must exist in the manifest, and every content document in the manifest
+// should appear in the spine. It returns nil when no problems are found.
+func ValidateReadingOrder(epubPath string) []ValidationIssue {
+	r, err := zip.OpenReader(epubPath)
+	if err != nil {
+		return []ValidationIssue{{Kind: IssueUnreadable, Message: fmt.Sprintf("failed to open EPUB: %v", err)}}
+	}
+	defer r.Close()
+
+	pkg, opfPath, err := opf.ReadPackage(&r.Reader)
+	if err != nil {
+		return []ValidationIssue{{Kind: IssueUnreadable, Message: err.Error()}}
+	}
+
+	var issues []ValidationIssue
+
+	// Index manifest items by resolved zip path
+	manifestPaths := make(map[string]bool)
+	for _, item := range pkg.Manifest.Items {
+		manifestPaths[opf.ResolveHref(opfPath, opf.UnescapeHref(item.Href))] = true
+	}
+
+	// Every spine itemref must exist in the manifest
+	inSpine := make(map[string]bool)
+	for _, ref := range pkg.Spine {
+		if pkg.ItemByID(ref.IDRef) == nil {
+			issues = append(issues, ValidationIssue{
+				Kind:    IssueMissingSpineItem,
+				Href:    ref.IDRef,
+				Message: fmt.Sprintf("spine itemref %q has no manifest item", ref.IDRef),
+			})
+			continue
+		}
+		inSpine[ref.IDRef] = true
+	}
+
+	// Content documents missing from the spine are orphans
+	for _, item := range pkg.Manifest.Items {
+		if !isContentDocument(item) || inSpine[item.ID] {
+			continue
+		}
+		issues = append(issues, ValidationIssue{
+			Kind:    IssueOrphanManifestItem,
+			Href:    item.Href,
+			Message: fmt.Sprintf("manifest item %q is not in the spine", item.ID),
+		})
+	}
+
+	// Every NCX href must resolve to a manifest item
+	ncxPath, ncxDoc, err := readManifestNCX(&r.Reader, pkg, opfPath)
+	if err != nil {
+		issues = append(issues, ValidationIssue{Kind: IssueUnreadable, Message: err.Error()})
+	} else if ncxDoc != nil {
+		for _, entry := range ncxDoc.GetTOC() {
+			file := strings.SplitN(entry.Href, "#", 2)[0]
+			if file == "" {
+				continue
+			}
+			if !manifestPaths[path.Join(path.Dir(ncxPath), opf.UnescapeHref(file))] {
+				issues = append(issues, ValidationIssue{
+					Kind:    IssueDanglingNCXHref,
+					Href:    entry.Href,
+					Message: fmt.Sprintf("NCX entry %q points at %q, which is not in the manifest", entry.Title, entry.Href),
+				})
+			}
+		}
+	}
+
+	return issues
+}
+
+// isContentDocument reports whether a manifest item is a reading-order document
+func isContentDocument(item opf.ManifestItem) bool {
+	for _, prop := range strings.Fields(item.Properties) {
+		if prop == "nav" {
+			return false
+		}
+	}
+	return item.MediaType == "application/xhtml+xml" || item.MediaType == "text/html"
+}
+
+// readManifestNCX parses the NCX declared in the manifest and returns its zip
+// path. A book without an NCX returns a nil document and no error.
+func readManifestNCX(zr *zip.Reader, pkg *opf.Package, opfPath string) (string, *ncx.NCX, error) {
+	for _, item := range pkg.Manifest.Items {
+		if item.MediaType != "application/x-dtbncx+xml" {
+			continue
+		}
+
+		ncxPath := opf.ResolveHref(opfPath, opf.UnescapeHref(item.Href))
+		f, err := zr.Open(ncxPath)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to open NCX %s: %w", ncxPath, err)
+		}
+		defer f.Close()
+
+		doc, err := ncx.ParseNCX(f)
+		if err != nil {
+			return "", nil, err
+		}
+		return ncxPath, doc, nil
+	}
+
+	return "", nil, nil
+}
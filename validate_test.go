@@ -0,0 +1,119 @@
+package calibre
+
+import "testing"
+
+func TestValidateReadingOrder(t *testing.T) {
+	epub := writeTestEPUB(t, map[string]string{
+		"META-INF/container.xml": testContainer("OEBPS/content.opf"),
+		"OEBPS/content.opf": testOPF(
+			`<dc:title>Broken TOC</dc:title>`,
+			`<item id="ncx" href="toc.ncx" media-type="application/x-dtbncx+xml"/>
+<item id="ch1" href="text/ch1.xhtml" media-type="application/xhtml+xml"/>
+<item id="ch2" href="text/Chapter%202.xhtml" media-type="application/xhtml+xml"/>
+<item id="extra" href="text/extra.xhtml" media-type="application/xhtml+xml"/>`,
+			`<itemref idref="ch1"/><itemref idref="ch2"/><itemref idref="ghost"/>`,
+		),
+		"OEBPS/toc.ncx": testNCX(
+			[2]string{"Chapter 1", "text/ch1.xhtml"},
+			[2]string{"Chapter 2", "text/Chapter%202.xhtml#part2"},
+			[2]string{"Chapter 3", "text/missing.xhtml"},
+		),
+		"OEBPS/text/ch1.xhtml":       testXHTML("<p>One</p>"),
+		"OEBPS/text/Chapter 2.xhtml": testXHTML("<p>Two</p>"),
+		"OEBPS/text/extra.xhtml":     testXHTML("<p>Extra</p>"),
+	})
+
+	issues := ValidateReadingOrder(epub)
+
+	found := make(map[IssueKind][]string)
+	for _, issue := range issues {
+		found[issue.Kind] = append(found[issue.Kind], issue.Href)
+	}
+
+	if hrefs := found[IssueDanglingNCXHref]; len(hrefs) != 1 || hrefs[0] != "text/missing.xhtml" {
+		t.Errorf("dangling NCX hrefs = %v", hrefs)
+	}
+	if refs := found[IssueMissingSpineItem]; len(refs) != 1 || refs[0] != "ghost" {
+		t.Errorf("missing spine items = %v", refs)
+	}
+	if orphans := found[IssueOrphanManifestItem]; len(orphans) != 1 || orphans[0] != "text/extra.xhtml" {
+		t.Errorf("orphan manifest items = %v", orphans)
+	}
+	if len(issues) != 3 {
+		t.Errorf("expected 3 issues, got %d: %+v", len(issues), issues)
+	}
+}
+
+func TestValidateReadingOrderUnreadable(t *testing.T) {
+	issues := ValidateReadingOrder("does-not-exist.epub")
+	if len(issues) != 1 || issues[0].Kind != IssueUnreadable {
+		t.Errorf("expected a single unreadable issue, got %+v", issues)
+	}
+}
+
+func TestValidateEPUB(t *testing.T) {
+	epub := writeTestEPUB(t, map[string]string{
+		"META-INF/container.xml": testContainer("OEBPS/content.opf"),
+		"OEBPS/content.opf": testOPF(`<dc:title>Valid</dc:title>`,
+			`<item id="ch1" href="text/ch1.xhtml" media-type="application/xhtml+xml"/>
+<item id="ch2" href="text/Chapter%202.xhtml" media-type="application/xhtml+xml"/>`,
+			`<itemref idref="ch1"/><itemref idref="ch2"/>`),
+		"OEBPS/text/ch1.xhtml":       testXHTML("<p>One</p>"),
+		"OEBPS/text/Chapter 2.xhtml": testXHTML("<p>Two</p>"),
+	})
+
+	report, err := ValidateEPUB(epub)
+	if err != nil {
+		t.Fatalf("ValidateEPUB failed: %v", err)
+	}
+	if !report.Valid() {
+		t.Errorf("expected a valid EPUB, got %+v", report.Issues)
+	}
+}
+
+func TestValidateEPUBReportsEveryProblem(t *testing.T) {
+	// mimetype is compressed, not first, and has a trailing newline
+	epub := writeTestEPUB(t, map[string]string{
+		"META-INF/container.xml": testContainer("OEBPS/content.opf"),
+		"mimetype":               "application/epub+zip\n",
+		"OEBPS/content.opf": testOPF(`<dc:title>Broken</dc:title>`,
+			`<item id="ch1" href="text/ch1.xhtml" media-type="application/xhtml+xml"/>
+<item id="img" href="images/missing.png" media-type="image/png"/>`,
+			`<itemref idref="ch1"/><itemref idref="ghost"/>`),
+		"OEBPS/text/ch1.xhtml": testXHTML("<p>One</p>"),
+	})
+
+	report, err := ValidateEPUB(epub)
+	if err != nil {
+		t.Fatalf("ValidateEPUB failed: %v", err)
+	}
+
+	counts := make(map[IssueKind]int)
+	for _, issue := range report.Issues {
+		counts[issue.Kind]++
+	}
+	if counts[IssueBadMimetype] != 3 {
+		t.Errorf("expected 3 mimetype issues, got %d: %+v", counts[IssueBadMimetype], report.Issues)
+	}
+	if counts[IssueMissingFile] != 1 || counts[IssueMissingSpineItem] != 1 {
+		t.Errorf("unexpected issues: %+v", report.Issues)
+	}
+}
+
+func TestValidateEPUBMissingOPF(t *testing.T) {
+	epub := writeTestEPUB(t, map[string]string{
+		"META-INF/container.xml": testContainer("OEBPS/content.opf"),
+	})
+
+	report, err := ValidateEPUB(epub)
+	if err != nil {
+		t.Fatalf("ValidateEPUB failed: %v", err)
+	}
+	if len(report.Issues) != 1 || report.Issues[0].Kind != IssueBadOPF {
+		t.Errorf("expected a single bad-opf issue, got %+v", report.Issues)
+	}
+
+	if _, err := ValidateEPUB("does-not-exist.epub"); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}